@@ -0,0 +1,211 @@
+// Package fixtures records the HTTP exchanges with Anna's Archive and
+// replays them later, so that parsing, downloads, and error paths can be
+// exercised against real responses without reaching the live site.
+//
+// Both the recorder and the replayer are http.RoundTrippers, and plug into
+// the client with anna.WithHTTPClient.
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNotRecorded is returned by the replayer for requests without a
+// recorded response.
+var ErrNotRecorded = errors.New("no recorded response for the request")
+
+// redactedParams are the query parameters holding credentials, which are
+// never written to fixtures.
+var redactedParams = []string{"key", "secret", "api_key"}
+
+// redactedHeaders are the response headers that are dropped from fixtures.
+var redactedHeaders = []string{"Set-Cookie"}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	// Body holds text responses such as HTML and JSON as is, so fixtures
+	// can be read and edited, and BinaryBody holds the others.
+	Body       string `json:"body,omitempty"`
+	BinaryBody []byte `json:"binary_body,omitempty"`
+}
+
+// Recorder forwards requests to a base transport and saves every exchange
+// to a directory of fixtures.
+type Recorder struct {
+	dir  string
+	base http.RoundTripper
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a recorder writing to dir. A nil base uses
+// http.DefaultTransport.
+func NewRecorder(dir string, base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Recorder{dir: dir, base: base}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	for _, name := range redactedHeaders {
+		header.Del(name)
+	}
+
+	interaction := Interaction{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Status: resp.StatusCode,
+		Header: header,
+	}
+	if utf8.Valid(data) {
+		interaction.Body = string(data)
+	} else {
+		interaction.BinaryBody = data
+	}
+	if err := r.save(key(req, body), interaction); err != nil {
+		return nil, fmt.Errorf("recording %s: %w", interaction.URL, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	return resp, nil
+}
+
+func (r *Recorder) save(name string, interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(r.dir, name), data, 0o644)
+}
+
+// Replayer answers requests from a directory of fixtures written by a
+// Recorder, without any network access.
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer returns a replayer reading from dir.
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, key(req, body)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, redactURL(req.URL))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("parsing fixture for %s: %w", redactURL(req.URL), err)
+	}
+
+	body = interaction.BinaryBody
+	if body == nil {
+		body = []byte(interaction.Body)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// requestBody reads the body of the request and puts it back, so that it
+// can be part of the fixture key.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// key names the fixture of a request after its method, redacted URL, and
+// body.
+func key(req *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, redactURL(req.URL))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))[:32] + ".json"
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, param := range redactedParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+
+	return redacted.String()
+}
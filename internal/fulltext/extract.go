@@ -0,0 +1,279 @@
+package fulltext
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"golang.org/x/net/html"
+)
+
+// maxTextSize bounds the text extracted from a single book, which keeps
+// the index small for huge scans.
+const maxTextSize = 8 << 20
+
+var containerRootfilePattern = regexp.MustCompile(`<rootfile\b[^>]*\bfull-path="([^"]+)"`)
+
+// Chapter is a document of the spine of an EPUB, rendered as plain text.
+type Chapter struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// Supported reports whether text can be extracted from files of the format.
+func Supported(format string) bool {
+	switch strings.ToLower(format) {
+	case "epub", "pdf", "txt":
+		return true
+	default:
+		return false
+	}
+}
+
+// ExtractText returns the plain text of an EPUB, PDF, or text file.
+func ExtractText(filePath, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "epub":
+		chapters, err := EPUBChapters(filePath)
+		if err != nil {
+			return "", err
+		}
+
+		var sb strings.Builder
+		for _, chapter := range chapters {
+			if sb.Len() >= maxTextSize {
+				break
+			}
+			sb.WriteString(chapter.Text)
+			sb.WriteString("\n\n")
+		}
+		return sb.String(), nil
+	case "pdf":
+		return pdfText(filePath)
+	case "txt":
+		f, err := os.Open(filePath)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		data, err := io.ReadAll(io.LimitReader(f, maxTextSize))
+		return string(data), err
+	default:
+		return "", fmt.Errorf("cannot extract text from %s files", format)
+	}
+}
+
+// pdfText returns the plain text of a PDF.
+func pdfText(filePath string) (text string, err error) {
+	// The parser panics on some malformed files instead of failing, also
+	// while opening them.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	plain, err := r.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(plain, maxTextSize))
+	return string(data), err
+}
+
+type opfPackage struct {
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// EPUBChapters returns the documents of the spine of an EPUB in reading
+// order. Documents without text, such as cover pages, are skipped.
+func EPUBChapters(filePath string) ([]Chapter, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	container, err := readZipFile(files["META-INF/container.xml"])
+	if err != nil {
+		return nil, fmt.Errorf("reading container: %w", err)
+	}
+	match := containerRootfilePattern.FindSubmatch(container)
+	if match == nil {
+		return nil, errors.New("no rootfile in container")
+	}
+	opfPath := string(match[1])
+
+	opfData, err := readZipFile(files[opfPath])
+	if err != nil {
+		return nil, fmt.Errorf("reading package: %w", err)
+	}
+	var pkg opfPackage
+	if err := xml.Unmarshal(opfData, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing package: %w", err)
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+
+	chapters := make([]Chapter, 0, len(pkg.Spine))
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		// The fragment and escaping of hrefs do not appear in zip names.
+		href, _, _ = strings.Cut(href, "#")
+		name := path.Join(path.Dir(opfPath), unescapePath(href))
+
+		data, err := readZipFile(files[name])
+		if err != nil {
+			continue
+		}
+
+		title, text := htmlText(data)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", len(chapters)+1)
+		}
+		chapters = append(chapters, Chapter{Title: title, Text: text})
+	}
+
+	return chapters, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f == nil {
+		return nil, errors.New("file not found in archive")
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(io.LimitReader(rc, maxTextSize))
+}
+
+func unescapePath(href string) string {
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		return unescaped
+	}
+
+	return href
+}
+
+// blockElements end a line of text.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "section": true, "article": true, "pre": true,
+}
+
+// htmlText renders an XHTML document as plain text with one paragraph per
+// line, also returning its first heading.
+func htmlText(data []byte) (string, string) {
+	z := html.NewTokenizer(bytes.NewReader(data))
+
+	var (
+		sb      strings.Builder
+		heading strings.Builder
+		title   string
+		skip    int
+		inHead  int
+	)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return title, collapseLines(sb.String())
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style" || tag == "head":
+				skip++
+			case tag == "h1" || tag == "h2" || tag == "h3":
+				if title == "" {
+					inHead++
+				}
+				sb.WriteString("\n")
+			case blockElements[tag]:
+				sb.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style" || tag == "head":
+				if skip > 0 {
+					skip--
+				}
+			case tag == "h1" || tag == "h2" || tag == "h3":
+				if inHead > 0 {
+					inHead--
+					title = strings.Join(strings.Fields(heading.String()), " ")
+				}
+				sb.WriteString("\n")
+			case blockElements[tag]:
+				sb.WriteString("\n")
+			}
+		case html.SelfClosingTagToken:
+			if name, _ := z.TagName(); blockElements[string(name)] {
+				sb.WriteString("\n")
+			}
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := string(z.Text())
+			sb.WriteString(text)
+			if inHead > 0 {
+				heading.WriteString(text)
+			}
+		}
+	}
+}
+
+// collapseLines normalizes the whitespace of every line and drops empty
+// ones.
+func collapseLines(text string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
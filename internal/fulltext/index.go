@@ -0,0 +1,169 @@
+package fulltext
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Document is a downloaded book in the full-text index.
+type Document struct {
+	Hash     string `json:"hash"`
+	Title    string `json:"title"`
+	Authors  string `json:"authors"`
+	Format   string `json:"format"`
+	Location string `json:"location"`
+	Scope    string `json:"scope"`
+	Text     string `json:"text"`
+}
+
+// Hit is a book matching a full-text search, with the passages that
+// matched.
+type Hit struct {
+	Hash      string   `json:"hash"`
+	Title     string   `json:"title"`
+	Authors   string   `json:"authors"`
+	Format    string   `json:"format"`
+	Location  string   `json:"location"`
+	Score     float64  `json:"score"`
+	Fragments []string `json:"fragments"`
+}
+
+// defaultScope stands in for the empty scope, which keyword fields cannot
+// match on.
+const defaultScope = "-"
+
+// Index is a full-text index of downloaded books. It is safe for concurrent
+// use.
+type Index struct {
+	index bleve.Index
+}
+
+var (
+	indexesMu sync.Mutex
+	indexes   = make(map[string]*Index)
+)
+
+// Open returns the index at the given path, creating it if it does not
+// exist. Indexes are shared per path, as only one process can hold them open.
+func Open(path string) (*Index, error) {
+	indexesMu.Lock()
+	defer indexesMu.Unlock()
+
+	if idx, ok := indexes[path]; ok {
+		return idx, nil
+	}
+
+	index, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		index, err = bleve.New(path, newMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &Index{index: index}
+	indexes[path] = idx
+
+	return idx, nil
+}
+
+func newMapping() mapping.IndexMapping {
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = keyword.Name
+
+	storedField := bleve.NewTextFieldMapping()
+	storedField.Index = false
+
+	textField := bleve.NewTextFieldMapping()
+	textField.Store = true
+	textField.IncludeTermVectors = true
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("hash", keywordField)
+	doc.AddFieldMappingsAt("scope", keywordField)
+	doc.AddFieldMappingsAt("format", keywordField)
+	doc.AddFieldMappingsAt("location", storedField)
+	doc.AddFieldMappingsAt("title", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("authors", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("text", textField)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = doc
+
+	return indexMapping
+}
+
+func documentID(scope, hash string) string {
+	if scope == "" {
+		scope = defaultScope
+	}
+
+	return scope + "/" + hash
+}
+
+// Add indexes a book, replacing a previous version of it.
+func (idx *Index) Add(doc Document) error {
+	if doc.Scope == "" {
+		doc.Scope = defaultScope
+	}
+
+	return idx.index.Index(documentID(doc.Scope, doc.Hash), doc)
+}
+
+// Remove drops a book of the scope from the index.
+func (idx *Index) Remove(scope, hash string) error {
+	return idx.index.Delete(documentID(scope, hash))
+}
+
+// Contains reports whether a book of the scope is indexed.
+func (idx *Index) Contains(scope, hash string) bool {
+	doc, err := idx.index.Document(documentID(scope, hash))
+	return err == nil && doc != nil
+}
+
+// Search returns the books of the scope whose text matches the query, best
+// matches first. The query uses the bleve query string syntax, so phrases can
+// be quoted.
+func (idx *Index) Search(scope, queryString string, limit int) ([]Hit, error) {
+	if scope == "" {
+		scope = defaultScope
+	}
+
+	textQuery := bleve.NewQueryStringQuery(queryString)
+	scopeQuery := bleve.NewTermQuery(scope)
+	scopeQuery.SetField("scope")
+
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery([]query.Query{textQuery, scopeQuery}...), limit, 0, false)
+	req.Fields = []string{"hash", "title", "authors", "format", "location"}
+	req.Highlight = bleve.NewHighlight()
+	req.Highlight.AddField("text")
+
+	result, err := idx.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(result.Hits))
+	for _, match := range result.Hits {
+		hit := Hit{
+			Score:     match.Score,
+			Fragments: match.Fragments["text"],
+		}
+		hit.Hash, _ = match.Fields["hash"].(string)
+		hit.Title, _ = match.Fields["title"].(string)
+		hit.Authors, _ = match.Fields["authors"].(string)
+		hit.Format, _ = match.Fields["format"].(string)
+		hit.Location, _ = match.Fields["location"].(string)
+		if hit.Fragments == nil {
+			hit.Fragments = []string{}
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
@@ -0,0 +1,97 @@
+package fulltext
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// maxSampledPages bounds the pages inspected in a PDF, spread over the whole
+// document, so that huge scans are inspected quickly.
+const maxSampledPages = 20
+
+// minPageText is the number of characters below which a page is considered
+// to have no text layer, as page numbers and headers alone fall below it.
+const minPageText = 80
+
+// ErrEncryptedPDF is returned for PDFs that cannot be opened without a
+// password.
+var ErrEncryptedPDF = errors.New("the PDF is encrypted with a password")
+
+// PDFInfo describes the pages of a PDF, as found on a sample of them.
+type PDFInfo struct {
+	Pages int
+	// Sampled is the number of pages inspected, TextPages and ImagePages
+	// the number of those with a text layer and with images.
+	Sampled    int
+	TextPages  int
+	ImagePages int
+	// Encrypted is set for PDFs that open without a password but restrict
+	// what readers may do with them, such as printing or copying.
+	Encrypted bool
+}
+
+// InspectPDF counts the pages of a PDF and samples them for a text layer and
+// images, which tell scans from born-digital documents.
+func InspectPDF(filePath string) (info PDFInfo, err error) {
+	// The parser panics on some malformed files instead of failing, also
+	// while opening them.
+	defer func() {
+		if r := recover(); r != nil {
+			info, err = PDFInfo{}, fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+
+	f, r, err := pdf.Open(filePath)
+	if errors.Is(err, pdf.ErrInvalidPassword) {
+		return info, ErrEncryptedPDF
+	}
+	if err != nil {
+		return info, err
+	}
+	defer f.Close()
+
+	info.Pages = r.NumPage()
+	info.Encrypted = !r.Trailer().Key("Encrypt").IsNull()
+
+	step := max(1, (info.Pages+maxSampledPages-1)/maxSampledPages)
+	for num := 1; num <= info.Pages && info.Sampled < maxSampledPages; num += step {
+		page := r.Page(num)
+		if page.V.IsNull() {
+			continue
+		}
+		info.Sampled++
+
+		text, err := page.GetPlainText(nil)
+		if err == nil && len(strings.Join(strings.Fields(text), " ")) >= minPageText {
+			info.TextPages++
+		}
+		if hasImages(page.Resources(), 3) {
+			info.ImagePages++
+		}
+	}
+
+	return info, nil
+}
+
+// hasImages reports whether the resources of a page hold an image, directly
+// or inside a form. Forms nested deeper than depth are not looked into, which
+// also guards against cycles.
+func hasImages(resources pdf.Value, depth int) bool {
+	objects := resources.Key("XObject")
+	for _, name := range objects.Keys() {
+		object := objects.Key(name)
+		switch object.Key("Subtype").Name() {
+		case "Image":
+			return true
+		case "Form":
+			if depth > 0 && hasImages(object.Key("Resources"), depth-1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
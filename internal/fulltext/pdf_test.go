@@ -0,0 +1,69 @@
+package fulltext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// minimalPDF returns a PDF with a single page.
+func minimalPDF() string {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 100 100] /Contents 4 0 R >>",
+		"<< /Length 13 >>\nstream\nBT (Hi) Tj ET\nendstream",
+	}
+
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.4\n")
+	offsets := make([]int, 0, len(objects))
+	for i, object := range objects {
+		offsets = append(offsets, sb.Len())
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return sb.String()
+}
+
+func writePDF(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "book.pdf")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestInspectPDF(t *testing.T) {
+	info, err := InspectPDF(writePDF(t, minimalPDF()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Pages != 1 || info.Sampled != 1 {
+		t.Errorf("InspectPDF returned %+v, want a single sampled page", info)
+	}
+}
+
+// The parser panics on a delimiter in the cross-reference table while
+// opening the file.
+func TestMalformedPDFDoesNotPanic(t *testing.T) {
+	path := writePDF(t, strings.Replace(minimalPDF(), "0000000000 65535 f", "00000>0000 65535 f", 1))
+
+	if _, err := InspectPDF(path); err == nil || !strings.Contains(err.Error(), "malformed PDF") {
+		t.Errorf("InspectPDF returned %v, want a malformed PDF error", err)
+	}
+	if _, err := ExtractText(path, "pdf"); err == nil || !strings.Contains(err.Error(), "malformed PDF") {
+		t.Errorf("ExtractText returned %v, want a malformed PDF error", err)
+	}
+}
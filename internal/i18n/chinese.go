@@ -0,0 +1,107 @@
+package i18n
+
+var chinese = map[string]string{
+	// Tools.
+	"Search books": "搜索图书",
+	"Term to search for, matched against all the metadata":                                               "搜索词，与所有元数据匹配",
+	"Words to find in the title only, combined with the term if both are given":                          "仅在书名中查找的词，若同时提供搜索词则一并使用",
+	"Words to find in the authors only":                                                                  "仅在作者中查找的词",
+	"Words to find in the publisher only":                                                                "仅在出版社中查找的词",
+	"Formats to leave out of the results, for example djvu or cbr. Defaults to ANNAS_EXCLUDE_FORMATS.":   "从结果中排除的格式，例如 djvu 或 cbr。默认为 ANNAS_EXCLUDE_FORMATS。",
+	"Earliest publication year, results without a year are left out":                                     "最早出版年份，没有年份的结果会被排除",
+	"Latest publication year, results without a year are left out":                                       "最晚出版年份，没有年份的结果会被排除",
+	"Smallest file size, for example 500KB, to skip broken files. Results of unknown size are left out.": "最小文件大小，例如 500KB，用于跳过损坏的文件。大小未知的结果会被排除。",
+	"Largest file size, for example 200MB, to skip large scans. Results of unknown size are left out.":   "最大文件大小，例如 200MB，用于跳过大型扫描件。大小未知的结果会被排除。",
+	"Approximate number of tokens the results may take, shortened to fit":                                "结果可占用的大致 token 数，超出时会缩短",
+	"Search with several query variants (title, title and author, ISBN) at once and return the merged, ranked results. Slower than search, but with better recall for ambiguous requests.": "同时使用多种查询变体（书名、书名加作者、ISBN）进行搜索，并返回合并排序后的结果。比 search 慢，但对含糊的请求召回率更高。",
+	"Deep search":        "深度搜索",
+	"Title of the book":  "书名",
+	"Author of the book": "作者",
+	"ISBN of the book, resolved from the title and author if omitted": "图书的 ISBN，省略时根据书名和作者查找",
+	"Translate a title, author, year range, and format into the exact query and search URL sent to Anna's Archive, and run it. The year and format are checked on the results, which are returned with the reasons the others were excluded. Useful to understand why a search missed a book.": "将书名、作者、年份范围和格式转换为发送给 Anna's Archive 的确切查询和搜索 URL 并执行。年份和格式会在结果上检查，返回结果时附上其余结果被排除的原因。可用于了解搜索为何没有找到某本书。",
+	"Build query":                          "构建查询",
+	"Earliest publication year":            "最早出版年份",
+	"Latest publication year":              "最晚出版年份",
+	"File format, for example pdf or epub": "文件格式，例如 pdf 或 epub",
+	"Download a book by its MD5 hash. Requires ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables.": "按 MD5 哈希下载图书。需要设置环境变量 ANNAS_SECRET_KEY 和 ANNAS_DOWNLOAD_PATH。",
+	"Download book":                        "下载图书",
+	"MD5 hash of the book to download":     "要下载的图书的 MD5 哈希",
+	"Book title, used for filename":        "书名，用作文件名",
+	"Book format, for example pdf or epub": "图书格式，例如 pdf 或 epub",
+	"Download even if the library already holds the same book in another format":                                                                                                   "即使书库中已有同一本书的其他格式也下载",
+	"Also download the supplementary files listed on the detail page, such as solutions or companion archives, into a folder next to the book. Each of them counts as a download.": "同时将详情页列出的补充文件（如习题答案或配套压缩包）下载到图书旁边的文件夹中。每个文件都计为一次下载。",
+	"List recent searches, newest first, with the results that were downloaded afterwards":                                                                                         "列出最近的搜索（最新的在前），以及之后下载过的结果",
+	"Search history": "搜索历史",
+	"Maximum number of searches to return, 20 by default":                                               "返回的最大搜索数，默认为 20",
+	"Summarize the downloaded books by format, language, author, size on disk, and downloads per month": "按格式、语言、作者、占用空间和每月下载量汇总已下载的图书",
+	"Library statistics": "书库统计",
+	"Report the uptime of the server, the calls and failures of every tool, the errors by cause, the cache hit rate, and the current rate limits, to find out why calls such as downloads fail": "报告服务器的运行时间、每个工具的调用和失败次数、按原因分类的错误、缓存命中率以及当前的速率限制，用于查明下载等调用失败的原因",
+	"Server statistics": "服务器统计",
+	"Move the downloaded books to the folders of the organization template set in ANNAS_ORGANIZE, for example after changing it": "将已下载的图书移动到 ANNAS_ORGANIZE 中设置的整理模板对应的文件夹，例如在修改模板之后",
+	"Reorganize library":                 "重新整理书库",
+	"List the moves without making them": "仅列出移动操作而不执行",
+	"Hash the files of the download directory and report those that are corrupted, missing, or unknown to the library index and Anna's Archive": "计算下载目录中文件的哈希，报告损坏、缺失或不在书库索引和 Anna's Archive 中的文件",
+	"Verify downloaded files": "校验已下载的文件",
+	"Do not look up the files missing from the library index on Anna's Archive":                                                                  "不在 Anna's Archive 上查找书库索引中缺失的文件",
+	"Search the text of the downloaded EPUB and PDF books offline, to find which of them mention something. Requires ANNAS_FULLTEXT_INDEX=true.": "离线搜索已下载的 EPUB 和 PDF 图书的正文，找出提到某内容的图书。需要 ANNAS_FULLTEXT_INDEX=true。",
+	"Search downloaded books": "搜索已下载的图书",
+	"Words or quoted phrases to look for in the text of the downloaded books":          "要在已下载图书正文中查找的词或带引号的短语",
+	"Maximum number of books to return, 10 by default":                                 "返回的最大图书数，默认为 10",
+	"Get the full metadata of a book, including its description and table of contents": "获取图书的完整元数据，包括简介和目录",
+	"Get book details":     "获取图书详情",
+	"MD5 hash of the book": "图书的 MD5 哈希",
+	"List all download links of a book (partner servers, mirrors, IPFS, torrents) with their typical wait times, for when the download tool fails": "列出图书的所有下载链接（合作服务器、镜像、IPFS、种子）及其通常的等待时间，供 download 工具失败时使用",
+	"List download options": "列出下载选项",
+	"Compare two or more books side by side to choose the best copy": "并排比较两本或更多图书，以选出最佳副本",
+	"Compare books":                      "比较图书",
+	"MD5 hashes of the books to compare": "要比较的图书的 MD5 哈希",
+
+	// Messages.
+	"Found %s by %s (%s, %s), hash %s":                                                               "找到 %s，作者 %s（%s，%s），哈希 %s",
+	"%d results found by several query variants were merged.":                                        "已合并 %d 个被多个查询变体找到的结果。",
+	"%d results were excluded by the filters: %s.":                                                   "筛选条件排除了 %d 个结果：%s。",
+	"Cover of %s (hash %s):":                                                                         "%s 的封面（哈希 %s）：",
+	"Skipped download: %s. Set allow_duplicate_formats to download this format anyway.":              "已跳过下载：%s。设置 allow_duplicate_formats 可仍然下载此格式。",
+	"Book downloaded successfully to path: %s":                                                       "图书已成功下载到路径：%s",
+	"Note: the requested copy could not be reached, so the copy with hash %s was downloaded instead": "注意：无法访问所请求的副本，因此改为下载了哈希为 %s 的副本",
+	"Note: %s":                               "注意：%s",
+	"Archive kept at path: %s":               "压缩包保留在路径：%s",
+	"Warning: the file may be truncated, %s": "警告：文件可能不完整，%s",
+	"Pages: %d":                              "页数：%d",
+	"text layer: %s":                         "文本层：%s",
+	"Warning: possibly a bad copy, %s":       "警告：可能是劣质副本，%s",
+	"Also delivered to: %s":                  "同时分发到：%s",
+	"Supplementary file downloaded to: %s":   "补充文件已下载到：%s",
+	"Chapters can be read one at a time from the resource %s":                      "可以通过资源 %s 逐章阅读",
+	"Supplementary files, downloaded along with the book when supplements is set:": "补充文件，设置 supplements 时会随图书一起下载：",
+	"%s, hash %s":                       "%s，哈希 %s",
+	"No downloaded book mentions this.": "没有已下载的图书提到这些内容。",
+	"No books found.":                   "未找到图书。",
+	"To find the book:":                 "可以这样查找：",
+	"Query: %s":                         "查询：%s",
+	"The filters left out %d results (%s), loosen or remove them.":                               "筛选条件排除了 %d 个结果（%s），请放宽或移除筛选条件。",
+	"Some words look mistyped, check their spelling.":                                            "部分词语似乎拼写有误，请检查拼写。",
+	"Many uploads are indexed under a romanized title and author, try the Latin spelling.":       "许多文件以罗马化的书名和作者收录，请尝试拉丁字母拼写。",
+	"Try the spelling without accents, which some uploads use.":                                  "请尝试不带重音符号的拼写，部分文件使用这种拼写。",
+	"Names transliterated from other scripts are spelled in several ways, try another spelling.": "从其他文字音译的人名有多种拼法，请尝试另一种拼写。",
+	"Drop the subtitle, which uploads often leave out or word differently.":                      "去掉副标题，文件常常省略副标题或用不同措辞。",
+	"Drop the edition, which uploads rarely mention in their titles.":                            "去掉版次，文件标题很少注明版次。",
+	"Search without the author, whose name may be spelled differently in the metadata.":          "不带作者搜索，作者姓名在元数据中可能拼写不同。",
+	"Search the words anywhere in the metadata, without fields or quotes.":                       "在全部元数据中搜索这些词，不限字段，不加引号。",
+	"Hashed %d of %d files": "已计算 %d/%d 个文件的哈希",
+	"List the downloads that the retention policy removes to free disk space, the oldest first, and remove them once the user confirms. The library index keeps them, marked as deleted.": "列出保留策略为释放磁盘空间而删除的下载，最旧的在前，并在用户确认后删除。图书馆索引会保留这些记录，并标记为已删除。",
+	"Total size the downloads must fit in, such as 20GB, instead of ANNAS_RETENTION_MAX_SIZE":                                                                                             "下载的总大小上限，例如 20GB，替代 ANNAS_RETENTION_MAX_SIZE",
+	"How long downloads are kept, such as 90d, instead of ANNAS_RETENTION_MAX_AGE":                                                                                                        "下载的保留时间，例如 90d，替代 ANNAS_RETENTION_MAX_AGE",
+	"Remove the listed downloads, after the user agreed to it. Without it, they are only listed.":                                                                                         "在用户同意后删除列出的下载。未设置时仅列出。",
+	"Clean up old downloads": "清理旧下载",
+	"Nothing was removed yet. Ask the user to confirm, then call cleanup again with confirm set.":                                                                                           "尚未删除任何内容。请先征得用户确认，然后设置 confirm 再次调用 cleanup。",
+	"Also search the title and author romanized if they are in another script than Latin, such as Cyrillic or Chinese, and merge the results. Many uploads are indexed in a single script.": "如果标题和作者使用拉丁字母以外的文字（如西里尔字母或汉字），也搜索其罗马化形式，并合并结果。许多上传只以一种文字编入索引。",
+	"Title in its original script, searched along with the given one and its romanization, and merged":                                                                                      "原文书写的标题，与给定标题及其罗马化形式一同搜索并合并",
+	"Author in the original script, searched along with the given one and its romanization, and merged":                                                                                     "原文书写的作者，与给定作者及其罗马化形式一同搜索并合并",
+	"List the filesystem roots of the client that downloads can be saved in, instead of the download directory of the server":                                                               "列出客户端中可用于保存下载的文件系统根目录，以替代服务器的下载目录",
+	"List download roots": "列出下载根目录",
+	"Filesystem root of the client to save the book in instead of the download directory of the server, given by its name, URI, or path, or a directory inside a root. See list_roots.": "用于保存图书的客户端文件系统根目录，替代服务器的下载目录，可以用名称、URI 或路径指定，也可以是根目录内的某个目录。参见 list_roots。",
+	"Downloads to the roots of the client are only allowed over stdio, or over HTTP with ANNAS_HTTP_ROOTS=true.":                                                                        "只有通过 stdio，或通过设置了 ANNAS_HTTP_ROOTS=true 的 HTTP，才允许下载到客户端的根目录。",
+	"The client exposes no filesystem roots, downloads go to the download directory of the server.":                                                                                     "客户端没有提供文件系统根目录，下载将保存到服务器的下载目录。",
+	"Downloads can be saved to these roots of the client, by passing their name or path as root:":                                                                                       "将名称或路径作为 root 传入，即可把下载保存到客户端的以下根目录：",
+}
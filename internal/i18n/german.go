@@ -0,0 +1,106 @@
+package i18n
+
+var german = map[string]string{
+	// Tools.
+	"Search books": "Bücher suchen",
+	"Term to search for, matched against all the metadata":                                               "Suchbegriff, der mit allen Metadaten abgeglichen wird",
+	"Words to find in the title only, combined with the term if both are given":                          "Wörter, die nur im Titel gesucht werden, mit dem Suchbegriff kombiniert, wenn beide angegeben sind",
+	"Words to find in the authors only":                                                                  "Wörter, die nur bei den Autoren gesucht werden",
+	"Words to find in the publisher only":                                                                "Wörter, die nur beim Verlag gesucht werden",
+	"Formats to leave out of the results, for example djvu or cbr. Defaults to ANNAS_EXCLUDE_FORMATS.":   "Formate, die aus den Ergebnissen ausgeschlossen werden, zum Beispiel djvu oder cbr. Standardmäßig ANNAS_EXCLUDE_FORMATS.",
+	"Earliest publication year, results without a year are left out":                                     "Frühestes Erscheinungsjahr, Ergebnisse ohne Jahr werden ausgeschlossen",
+	"Latest publication year, results without a year are left out":                                       "Spätestes Erscheinungsjahr, Ergebnisse ohne Jahr werden ausgeschlossen",
+	"Smallest file size, for example 500KB, to skip broken files. Results of unknown size are left out.": "Kleinste Dateigröße, zum Beispiel 500KB, um defekte Dateien zu überspringen. Ergebnisse unbekannter Größe werden ausgeschlossen.",
+	"Largest file size, for example 200MB, to skip large scans. Results of unknown size are left out.":   "Größte Dateigröße, zum Beispiel 200MB, um große Scans zu überspringen. Ergebnisse unbekannter Größe werden ausgeschlossen.",
+	"Approximate number of tokens the results may take, shortened to fit":                                "Ungefähre Anzahl an Tokens, die die Ergebnisse belegen dürfen; sie werden passend gekürzt",
+	"Search with several query variants (title, title and author, ISBN) at once and return the merged, ranked results. Slower than search, but with better recall for ambiguous requests.": "Sucht gleichzeitig mit mehreren Varianten der Anfrage (Titel, Titel und Autor, ISBN) und gibt die zusammengeführten, gereihten Ergebnisse zurück. Langsamer als search, findet aber bei mehrdeutigen Anfragen mehr.",
+	"Deep search":        "Tiefensuche",
+	"Title of the book":  "Titel des Buches",
+	"Author of the book": "Autor des Buches",
+	"ISBN of the book, resolved from the title and author if omitted": "ISBN des Buches, wird aus Titel und Autor ermittelt, wenn sie fehlt",
+	"Translate a title, author, year range, and format into the exact query and search URL sent to Anna's Archive, and run it. The year and format are checked on the results, which are returned with the reasons the others were excluded. Useful to understand why a search missed a book.": "Übersetzt Titel, Autor, Jahresbereich und Format in die genaue Anfrage und Such-URL, die an Anna's Archive gesendet werden, und führt sie aus. Jahr und Format werden an den Ergebnissen geprüft, die zusammen mit den Gründen für den Ausschluss der übrigen zurückgegeben werden. Hilfreich, um zu verstehen, warum eine Suche ein Buch nicht gefunden hat.",
+	"Build query":                          "Anfrage erstellen",
+	"Earliest publication year":            "Frühestes Erscheinungsjahr",
+	"Latest publication year":              "Spätestes Erscheinungsjahr",
+	"File format, for example pdf or epub": "Dateiformat, zum Beispiel pdf oder epub",
+	"Download a book by its MD5 hash. Requires ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables.": "Lädt ein Buch anhand seines MD5-Hashes herunter. Erfordert die Umgebungsvariablen ANNAS_SECRET_KEY und ANNAS_DOWNLOAD_PATH.",
+	"Download book":                        "Buch herunterladen",
+	"MD5 hash of the book to download":     "MD5-Hash des herunterzuladenden Buches",
+	"Book title, used for filename":        "Buchtitel, wird als Dateiname verwendet",
+	"Book format, for example pdf or epub": "Buchformat, zum Beispiel pdf oder epub",
+	"Download even if the library already holds the same book in another format":                                                                                                   "Auch herunterladen, wenn die Bibliothek dasselbe Buch bereits in einem anderen Format enthält",
+	"Also download the supplementary files listed on the detail page, such as solutions or companion archives, into a folder next to the book. Each of them counts as a download.": "Auch die auf der Detailseite aufgeführten Zusatzdateien, etwa Lösungen oder Begleitarchive, in einen Ordner neben dem Buch herunterladen. Jede davon zählt als Download.",
+	"List recent searches, newest first, with the results that were downloaded afterwards":                                                                                         "Listet die letzten Suchen auf, die neueste zuerst, mit den Ergebnissen, die danach heruntergeladen wurden",
+	"Search history": "Suchverlauf",
+	"Maximum number of searches to return, 20 by default":                                               "Maximale Anzahl zurückgegebener Suchen, standardmäßig 20",
+	"Summarize the downloaded books by format, language, author, size on disk, and downloads per month": "Fasst die heruntergeladenen Bücher nach Format, Sprache, Autor, Speicherplatz und Downloads pro Monat zusammen",
+	"Library statistics": "Bibliotheksstatistik",
+	"Report the uptime of the server, the calls and failures of every tool, the errors by cause, the cache hit rate, and the current rate limits, to find out why calls such as downloads fail": "Meldet die Laufzeit des Servers, die Aufrufe und Fehlschläge jedes Werkzeugs, die Fehler nach Ursache, die Cache-Trefferquote und die aktuellen Ratenlimits, um herauszufinden, warum Aufrufe wie Downloads fehlschlagen",
+	"Server statistics": "Serverstatistik",
+	"Move the downloaded books to the folders of the organization template set in ANNAS_ORGANIZE, for example after changing it": "Verschiebt die heruntergeladenen Bücher in die Ordner der in ANNAS_ORGANIZE festgelegten Ordnungsvorlage, etwa nachdem sie geändert wurde",
+	"Reorganize library":                 "Bibliothek neu ordnen",
+	"List the moves without making them": "Die Verschiebungen auflisten, ohne sie auszuführen",
+	"Hash the files of the download directory and report those that are corrupted, missing, or unknown to the library index and Anna's Archive": "Berechnet die Hashes der Dateien im Download-Verzeichnis und meldet jene, die beschädigt sind, fehlen oder weder im Bibliotheksindex noch bei Anna's Archive bekannt sind",
+	"Verify downloaded files": "Heruntergeladene Dateien prüfen",
+	"Do not look up the files missing from the library index on Anna's Archive":                                                                  "Die im Bibliotheksindex fehlenden Dateien nicht bei Anna's Archive nachschlagen",
+	"Search the text of the downloaded EPUB and PDF books offline, to find which of them mention something. Requires ANNAS_FULLTEXT_INDEX=true.": "Durchsucht offline den Text der heruntergeladenen EPUB- und PDF-Bücher, um herauszufinden, welche etwas erwähnen. Erfordert ANNAS_FULLTEXT_INDEX=true.",
+	"Search downloaded books": "Heruntergeladene Bücher durchsuchen",
+	"Words or quoted phrases to look for in the text of the downloaded books":          "Wörter oder Phrasen in Anführungszeichen, die im Text der heruntergeladenen Bücher gesucht werden",
+	"Maximum number of books to return, 10 by default":                                 "Maximale Anzahl zurückgegebener Bücher, standardmäßig 10",
+	"Get the full metadata of a book, including its description and table of contents": "Ruft alle Metadaten eines Buches ab, einschließlich Beschreibung und Inhaltsverzeichnis",
+	"Get book details":     "Buchdetails abrufen",
+	"MD5 hash of the book": "MD5-Hash des Buches",
+	"List all download links of a book (partner servers, mirrors, IPFS, torrents) with their typical wait times, for when the download tool fails": "Listet alle Download-Links eines Buches (Partnerserver, Spiegel, IPFS, Torrents) mit ihren üblichen Wartezeiten auf, für den Fall, dass das Werkzeug download fehlschlägt",
+	"List download options": "Download-Optionen auflisten",
+	"Compare two or more books side by side to choose the best copy": "Vergleicht zwei oder mehr Bücher nebeneinander, um die beste Kopie auszuwählen",
+	"Compare books":                      "Bücher vergleichen",
+	"MD5 hashes of the books to compare": "MD5-Hashes der zu vergleichenden Bücher",
+
+	// Messages.
+	"Found %s by %s (%s, %s), hash %s":                                                               "Gefunden: %s von %s (%s, %s), Hash %s",
+	"%d results found by several query variants were merged.":                                        "%d Ergebnisse, die von mehreren Varianten der Anfrage gefunden wurden, wurden zusammengeführt.",
+	"%d results were excluded by the filters: %s.":                                                   "%d Ergebnisse wurden von den Filtern ausgeschlossen: %s.",
+	"Cover of %s (hash %s):":                                                                         "Cover von %s (Hash %s):",
+	"Skipped download: %s. Set allow_duplicate_formats to download this format anyway.":              "Download übersprungen: %s. Setze allow_duplicate_formats, um dieses Format trotzdem herunterzuladen.",
+	"Book downloaded successfully to path: %s":                                                       "Buch erfolgreich heruntergeladen nach: %s",
+	"Note: the requested copy could not be reached, so the copy with hash %s was downloaded instead": "Hinweis: Die angeforderte Kopie war nicht erreichbar, daher wurde stattdessen die Kopie mit dem Hash %s heruntergeladen",
+	"Note: %s":                               "Hinweis: %s",
+	"Archive kept at path: %s":               "Archiv aufbewahrt unter: %s",
+	"Warning: the file may be truncated, %s": "Warnung: Die Datei ist möglicherweise unvollständig, %s",
+	"Pages: %d":                              "Seiten: %d",
+	"text layer: %s":                         "Textebene: %s",
+	"Warning: possibly a bad copy, %s":       "Warnung: möglicherweise eine schlechte Kopie, %s",
+	"Also delivered to: %s":                  "Auch zugestellt nach: %s",
+	"Supplementary file downloaded to: %s":   "Zusatzdatei heruntergeladen nach: %s",
+	"Chapters can be read one at a time from the resource %s":                      "Die Kapitel können einzeln über die Ressource %s gelesen werden",
+	"Supplementary files, downloaded along with the book when supplements is set:": "Zusatzdateien, die mit dem Buch heruntergeladen werden, wenn supplements gesetzt ist:",
+	"No downloaded book mentions this.":                                            "Kein heruntergeladenes Buch erwähnt dies.",
+	"No books found.":                                                              "Keine Bücher gefunden.",
+	"To find the book:":                                                            "So lässt sich das Buch finden:",
+	"Query: %s":                                                                    "Anfrage: %s",
+	"The filters left out %d results (%s), loosen or remove them.":                 "Die Filter haben %d Ergebnisse ausgeschlossen (%s), lockere oder entferne sie.",
+	"Some words look mistyped, check their spelling.":                              "Einige Wörter scheinen vertippt zu sein, prüfe ihre Schreibweise.",
+	"Many uploads are indexed under a romanized title and author, try the Latin spelling.":       "Viele Uploads sind unter einem romanisierten Titel und Autor erfasst, versuche die lateinische Schreibweise.",
+	"Try the spelling without accents, which some uploads use.":                                  "Versuche die Schreibweise ohne Akzente, die manche Uploads verwenden.",
+	"Names transliterated from other scripts are spelled in several ways, try another spelling.": "Aus anderen Schriften transliterierte Namen werden unterschiedlich geschrieben, versuche eine andere Schreibweise.",
+	"Drop the subtitle, which uploads often leave out or word differently.":                      "Lass den Untertitel weg, den Uploads oft auslassen oder anders formulieren.",
+	"Drop the edition, which uploads rarely mention in their titles.":                            "Lass die Auflage weg, die Uploads selten im Titel nennen.",
+	"Search without the author, whose name may be spelled differently in the metadata.":          "Suche ohne den Autor, dessen Name in den Metadaten anders geschrieben sein kann.",
+	"Search the words anywhere in the metadata, without fields or quotes.":                       "Suche die Wörter überall in den Metadaten, ohne Felder oder Anführungszeichen.",
+	"Hashed %d of %d files": "%d von %d Dateien gehasht",
+	"List the downloads that the retention policy removes to free disk space, the oldest first, and remove them once the user confirms. The library index keeps them, marked as deleted.": "Listet die Downloads auf, die die Aufbewahrungsrichtlinie entfernt, um Speicherplatz freizugeben, die ältesten zuerst, und entfernt sie, sobald der Benutzer zustimmt. Der Bibliotheksindex behält sie, als gelöscht markiert.",
+	"Total size the downloads must fit in, such as 20GB, instead of ANNAS_RETENTION_MAX_SIZE":                                                                                             "Gesamtgröße, in die die Downloads passen müssen, etwa 20GB, anstelle von ANNAS_RETENTION_MAX_SIZE",
+	"How long downloads are kept, such as 90d, instead of ANNAS_RETENTION_MAX_AGE":                                                                                                        "Wie lange Downloads aufbewahrt werden, etwa 90d, anstelle von ANNAS_RETENTION_MAX_AGE",
+	"Remove the listed downloads, after the user agreed to it. Without it, they are only listed.":                                                                                         "Die aufgelisteten Downloads entfernen, nachdem der Benutzer zugestimmt hat. Ohne diese Angabe werden sie nur aufgelistet.",
+	"Clean up old downloads": "Alte Downloads aufräumen",
+	"Nothing was removed yet. Ask the user to confirm, then call cleanup again with confirm set.":                                                                                           "Es wurde noch nichts entfernt. Bitte den Benutzer um Bestätigung und rufe cleanup dann erneut mit gesetztem confirm auf.",
+	"Also search the title and author romanized if they are in another script than Latin, such as Cyrillic or Chinese, and merge the results. Many uploads are indexed in a single script.": "Sucht Titel und Autor auch in lateinischer Umschrift, wenn sie in einer anderen Schrift wie Kyrillisch oder Chinesisch stehen, und führt die Ergebnisse zusammen. Viele Uploads sind nur in einer Schrift indexiert.",
+	"Title in its original script, searched along with the given one and its romanization, and merged":                                                                                      "Titel in seiner Originalschrift, der zusammen mit dem angegebenen und seiner Umschrift gesucht und zusammengeführt wird",
+	"Author in the original script, searched along with the given one and its romanization, and merged":                                                                                     "Autor in der Originalschrift, der zusammen mit dem angegebenen und seiner Umschrift gesucht und zusammengeführt wird",
+	"List the filesystem roots of the client that downloads can be saved in, instead of the download directory of the server":                                                               "Listet die Dateisystem-Wurzeln des Clients auf, in denen Downloads statt im Download-Verzeichnis des Servers gespeichert werden können",
+	"List download roots": "Download-Wurzeln auflisten",
+	"Filesystem root of the client to save the book in instead of the download directory of the server, given by its name, URI, or path, or a directory inside a root. See list_roots.": "Dateisystem-Wurzel des Clients, in der das Buch statt im Download-Verzeichnis des Servers gespeichert wird, angegeben durch ihren Namen, ihre URI oder ihren Pfad, oder ein Verzeichnis innerhalb einer Wurzel. Siehe list_roots.",
+	"Downloads to the roots of the client are only allowed over stdio, or over HTTP with ANNAS_HTTP_ROOTS=true.":                                                                        "Downloads in die Wurzeln des Clients sind nur über stdio erlaubt, oder über HTTP mit ANNAS_HTTP_ROOTS=true.",
+	"The client exposes no filesystem roots, downloads go to the download directory of the server.":                                                                                     "Der Client stellt keine Dateisystem-Wurzeln bereit, Downloads landen im Download-Verzeichnis des Servers.",
+	"Downloads can be saved to these roots of the client, by passing their name or path as root:":                                                                                       "Downloads können in diesen Wurzeln des Clients gespeichert werden, indem ihr Name oder Pfad als root übergeben wird:",
+}
@@ -0,0 +1,57 @@
+// Package i18n translates the tool descriptions and the messages of the MCP
+// server, so that clients talking to the model in another language get tool
+// descriptions and results in that language.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported languages, English being the one the messages are written in.
+const (
+	English = "en"
+	Spanish = "es"
+	German  = "de"
+	Chinese = "zh"
+)
+
+// catalogs map the English messages to their translations.
+var catalogs = map[string]map[string]string{
+	Spanish: spanish,
+	German:  german,
+	Chinese: chinese,
+}
+
+// Parse returns the supported language of a tag such as "de", "de-AT", or
+// "zh_CN.UTF-8". An empty tag is English.
+func Parse(tag string) (string, error) {
+	lang := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(lang, "-_."); i >= 0 {
+		lang = lang[:i]
+	}
+
+	switch lang {
+	case "":
+		return English, nil
+	case English, Spanish, German, Chinese:
+		return lang, nil
+	default:
+		return "", fmt.Errorf("unsupported language %q, expected one of en, es, de, zh", tag)
+	}
+}
+
+// T returns the translation of an English message, or the message itself if
+// it is not translated.
+func T(lang, message string) string {
+	if translated, ok := catalogs[lang][message]; ok {
+		return translated
+	}
+
+	return message
+}
+
+// Sprintf formats the translation of an English format string.
+func Sprintf(lang, format string, args ...any) string {
+	return fmt.Sprintf(T(lang, format), args...)
+}
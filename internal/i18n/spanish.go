@@ -0,0 +1,106 @@
+package i18n
+
+var spanish = map[string]string{
+	// Tools.
+	"Search books": "Buscar libros",
+	"Term to search for, matched against all the metadata":                                               "Término de búsqueda, comparado con todos los metadatos",
+	"Words to find in the title only, combined with the term if both are given":                          "Palabras que buscar solo en el título, combinadas con el término si se indican ambos",
+	"Words to find in the authors only":                                                                  "Palabras que buscar solo en los autores",
+	"Words to find in the publisher only":                                                                "Palabras que buscar solo en la editorial",
+	"Formats to leave out of the results, for example djvu or cbr. Defaults to ANNAS_EXCLUDE_FORMATS.":   "Formatos que excluir de los resultados, por ejemplo djvu o cbr. Por defecto, ANNAS_EXCLUDE_FORMATS.",
+	"Earliest publication year, results without a year are left out":                                     "Año de publicación más antiguo; se excluyen los resultados sin año",
+	"Latest publication year, results without a year are left out":                                       "Año de publicación más reciente; se excluyen los resultados sin año",
+	"Smallest file size, for example 500KB, to skip broken files. Results of unknown size are left out.": "Tamaño mínimo del archivo, por ejemplo 500KB, para omitir archivos dañados. Se excluyen los resultados de tamaño desconocido.",
+	"Largest file size, for example 200MB, to skip large scans. Results of unknown size are left out.":   "Tamaño máximo del archivo, por ejemplo 200MB, para omitir escaneos grandes. Se excluyen los resultados de tamaño desconocido.",
+	"Approximate number of tokens the results may take, shortened to fit":                                "Número aproximado de tokens que pueden ocupar los resultados, que se acortan para caber",
+	"Search with several query variants (title, title and author, ISBN) at once and return the merged, ranked results. Slower than search, but with better recall for ambiguous requests.": "Busca con varias variantes de la consulta a la vez (título, título y autor, ISBN) y devuelve los resultados combinados y ordenados. Más lento que search, pero encuentra más en peticiones ambiguas.",
+	"Deep search":        "Búsqueda exhaustiva",
+	"Title of the book":  "Título del libro",
+	"Author of the book": "Autor del libro",
+	"ISBN of the book, resolved from the title and author if omitted": "ISBN del libro; si se omite, se obtiene a partir del título y el autor",
+	"Translate a title, author, year range, and format into the exact query and search URL sent to Anna's Archive, and run it. The year and format are checked on the results, which are returned with the reasons the others were excluded. Useful to understand why a search missed a book.": "Traduce un título, un autor, un rango de años y un formato a la consulta exacta y la URL de búsqueda enviadas a Anna's Archive, y la ejecuta. El año y el formato se comprueban en los resultados, que se devuelven junto con los motivos por los que se excluyeron los demás. Útil para entender por qué una búsqueda no encontró un libro.",
+	"Build query":                          "Construir consulta",
+	"Earliest publication year":            "Año de publicación más antiguo",
+	"Latest publication year":              "Año de publicación más reciente",
+	"File format, for example pdf or epub": "Formato del archivo, por ejemplo pdf o epub",
+	"Download a book by its MD5 hash. Requires ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables.": "Descarga un libro por su hash MD5. Requiere las variables de entorno ANNAS_SECRET_KEY y ANNAS_DOWNLOAD_PATH.",
+	"Download book":                        "Descargar libro",
+	"MD5 hash of the book to download":     "Hash MD5 del libro que descargar",
+	"Book title, used for filename":        "Título del libro, usado como nombre del archivo",
+	"Book format, for example pdf or epub": "Formato del libro, por ejemplo pdf o epub",
+	"Download even if the library already holds the same book in another format":                                                                                                   "Descargar aunque la biblioteca ya tenga el mismo libro en otro formato",
+	"Also download the supplementary files listed on the detail page, such as solutions or companion archives, into a folder next to the book. Each of them counts as a download.": "Descargar también los archivos complementarios de la página de detalles, como soluciones o archivos adjuntos, en una carpeta junto al libro. Cada uno cuenta como una descarga.",
+	"List recent searches, newest first, with the results that were downloaded afterwards":                                                                                         "Lista las búsquedas recientes, de la más nueva a la más antigua, con los resultados que se descargaron después",
+	"Search history": "Historial de búsquedas",
+	"Maximum number of searches to return, 20 by default":                                               "Número máximo de búsquedas que devolver, 20 por defecto",
+	"Summarize the downloaded books by format, language, author, size on disk, and downloads per month": "Resume los libros descargados por formato, idioma, autor, espacio en disco y descargas por mes",
+	"Library statistics": "Estadísticas de la biblioteca",
+	"Report the uptime of the server, the calls and failures of every tool, the errors by cause, the cache hit rate, and the current rate limits, to find out why calls such as downloads fail": "Informa del tiempo de actividad del servidor, las llamadas y fallos de cada herramienta, los errores por causa, la tasa de aciertos de la caché y los límites de frecuencia actuales, para averiguar por qué fallan llamadas como las descargas",
+	"Server statistics": "Estadísticas del servidor",
+	"Move the downloaded books to the folders of the organization template set in ANNAS_ORGANIZE, for example after changing it": "Mueve los libros descargados a las carpetas de la plantilla de organización definida en ANNAS_ORGANIZE, por ejemplo después de cambiarla",
+	"Reorganize library":                 "Reorganizar la biblioteca",
+	"List the moves without making them": "Listar los movimientos sin hacerlos",
+	"Hash the files of the download directory and report those that are corrupted, missing, or unknown to the library index and Anna's Archive": "Calcula el hash de los archivos del directorio de descargas e informa de los que están dañados, faltan o no constan en el índice de la biblioteca ni en Anna's Archive",
+	"Verify downloaded files": "Verificar los archivos descargados",
+	"Do not look up the files missing from the library index on Anna's Archive":                                                                  "No buscar en Anna's Archive los archivos que faltan en el índice de la biblioteca",
+	"Search the text of the downloaded EPUB and PDF books offline, to find which of them mention something. Requires ANNAS_FULLTEXT_INDEX=true.": "Busca sin conexión en el texto de los libros EPUB y PDF descargados, para saber cuáles mencionan algo. Requiere ANNAS_FULLTEXT_INDEX=true.",
+	"Search downloaded books": "Buscar en los libros descargados",
+	"Words or quoted phrases to look for in the text of the downloaded books":          "Palabras o frases entre comillas que buscar en el texto de los libros descargados",
+	"Maximum number of books to return, 10 by default":                                 "Número máximo de libros que devolver, 10 por defecto",
+	"Get the full metadata of a book, including its description and table of contents": "Obtiene todos los metadatos de un libro, incluidos su descripción y su índice",
+	"Get book details":     "Obtener los detalles del libro",
+	"MD5 hash of the book": "Hash MD5 del libro",
+	"List all download links of a book (partner servers, mirrors, IPFS, torrents) with their typical wait times, for when the download tool fails": "Lista todos los enlaces de descarga de un libro (servidores asociados, réplicas, IPFS, torrents) con sus tiempos de espera habituales, para cuando falla la herramienta download",
+	"List download options": "Listar las opciones de descarga",
+	"Compare two or more books side by side to choose the best copy": "Compara dos o más libros lado a lado para elegir la mejor copia",
+	"Compare books":                      "Comparar libros",
+	"MD5 hashes of the books to compare": "Hashes MD5 de los libros que comparar",
+
+	// Messages.
+	"Found %s by %s (%s, %s), hash %s":                                                               "Encontrado %s de %s (%s, %s), hash %s",
+	"%d results found by several query variants were merged.":                                        "Se combinaron %d resultados encontrados por varias variantes de la consulta.",
+	"%d results were excluded by the filters: %s.":                                                   "Los filtros excluyeron %d resultados: %s.",
+	"Cover of %s (hash %s):":                                                                         "Portada de %s (hash %s):",
+	"Skipped download: %s. Set allow_duplicate_formats to download this format anyway.":              "Descarga omitida: %s. Activa allow_duplicate_formats para descargar este formato de todos modos.",
+	"Book downloaded successfully to path: %s":                                                       "Libro descargado correctamente en la ruta: %s",
+	"Note: the requested copy could not be reached, so the copy with hash %s was downloaded instead": "Nota: no se pudo acceder a la copia solicitada, así que se descargó en su lugar la copia con hash %s",
+	"Note: %s":                               "Nota: %s",
+	"Archive kept at path: %s":               "Archivo comprimido conservado en la ruta: %s",
+	"Warning: the file may be truncated, %s": "Aviso: puede que el archivo esté truncado, %s",
+	"Pages: %d":                              "Páginas: %d",
+	"text layer: %s":                         "capa de texto: %s",
+	"Warning: possibly a bad copy, %s":       "Advertencia: posiblemente una copia defectuosa, %s",
+	"Also delivered to: %s":                  "También entregado en: %s",
+	"Supplementary file downloaded to: %s":   "Archivo complementario descargado en: %s",
+	"Chapters can be read one at a time from the resource %s":                      "Los capítulos se pueden leer de uno en uno desde el recurso %s",
+	"Supplementary files, downloaded along with the book when supplements is set:": "Archivos complementarios, que se descargan con el libro si se activa supplements:",
+	"No downloaded book mentions this.":                                            "Ningún libro descargado menciona esto.",
+	"No books found.":                                                              "No se encontraron libros.",
+	"To find the book:":                                                            "Para encontrar el libro:",
+	"Query: %s":                                                                    "Consulta: %s",
+	"The filters left out %d results (%s), loosen or remove them.":                 "Los filtros dejaron fuera %d resultados (%s), relájalos o elimínalos.",
+	"Some words look mistyped, check their spelling.":                              "Algunas palabras parecen mal escritas, revisa su ortografía.",
+	"Many uploads are indexed under a romanized title and author, try the Latin spelling.":       "Muchos archivos están indexados con el título y el autor romanizados, prueba la grafía latina.",
+	"Try the spelling without accents, which some uploads use.":                                  "Prueba la grafía sin acentos, que usan algunos archivos.",
+	"Names transliterated from other scripts are spelled in several ways, try another spelling.": "Los nombres transliterados de otras escrituras se escriben de varias formas, prueba otra grafía.",
+	"Drop the subtitle, which uploads often leave out or word differently.":                      "Quita el subtítulo, que los archivos a menudo omiten o redactan de otra manera.",
+	"Drop the edition, which uploads rarely mention in their titles.":                            "Quita la edición, que los archivos rara vez mencionan en sus títulos.",
+	"Search without the author, whose name may be spelled differently in the metadata.":          "Busca sin el autor, cuyo nombre puede estar escrito de otra forma en los metadatos.",
+	"Search the words anywhere in the metadata, without fields or quotes.":                       "Busca las palabras en cualquier parte de los metadatos, sin campos ni comillas.",
+	"Hashed %d of %d files": "Se calcularon los hashes de %d de %d archivos",
+	"List the downloads that the retention policy removes to free disk space, the oldest first, and remove them once the user confirms. The library index keeps them, marked as deleted.": "Lista las descargas que la política de retención elimina para liberar espacio en disco, las más antiguas primero, y las elimina cuando el usuario lo confirma. El índice de la biblioteca las conserva, marcadas como eliminadas.",
+	"Total size the downloads must fit in, such as 20GB, instead of ANNAS_RETENTION_MAX_SIZE":                                                                                             "Tamaño total en el que deben caber las descargas, como 20GB, en lugar de ANNAS_RETENTION_MAX_SIZE",
+	"How long downloads are kept, such as 90d, instead of ANNAS_RETENTION_MAX_AGE":                                                                                                        "Cuánto tiempo se conservan las descargas, como 90d, en lugar de ANNAS_RETENTION_MAX_AGE",
+	"Remove the listed downloads, after the user agreed to it. Without it, they are only listed.":                                                                                         "Elimina las descargas listadas, después de que el usuario lo haya aceptado. Sin esto, solo se listan.",
+	"Clean up old downloads": "Limpiar descargas antiguas",
+	"Nothing was removed yet. Ask the user to confirm, then call cleanup again with confirm set.":                                                                                           "Todavía no se eliminó nada. Pide confirmación al usuario y vuelve a llamar a cleanup con confirm activado.",
+	"Also search the title and author romanized if they are in another script than Latin, such as Cyrillic or Chinese, and merge the results. Many uploads are indexed in a single script.": "Busca también el título y el autor romanizados si están en una escritura distinta de la latina, como el cirílico o el chino, y combina los resultados. Muchas subidas están indexadas en una sola escritura.",
+	"Title in its original script, searched along with the given one and its romanization, and merged":                                                                                      "Título en su escritura original, buscado junto con el indicado y su romanización, y combinado",
+	"Author in the original script, searched along with the given one and its romanization, and merged":                                                                                     "Autor en la escritura original, buscado junto con el indicado y su romanización, y combinado",
+	"List the filesystem roots of the client that downloads can be saved in, instead of the download directory of the server":                                                               "Lista las raíces del sistema de archivos del cliente en las que se pueden guardar las descargas, en lugar del directorio de descargas del servidor",
+	"List download roots": "Listar raíces de descarga",
+	"Filesystem root of the client to save the book in instead of the download directory of the server, given by its name, URI, or path, or a directory inside a root. See list_roots.": "Raíz del sistema de archivos del cliente en la que guardar el libro en lugar del directorio de descargas del servidor, indicada por su nombre, URI o ruta, o un directorio dentro de una raíz. Consulta list_roots.",
+	"Downloads to the roots of the client are only allowed over stdio, or over HTTP with ANNAS_HTTP_ROOTS=true.":                                                                        "Las descargas a las raíces del cliente solo se permiten por stdio, o por HTTP con ANNAS_HTTP_ROOTS=true.",
+	"The client exposes no filesystem roots, downloads go to the download directory of the server.":                                                                                     "El cliente no expone raíces del sistema de archivos, las descargas van al directorio de descargas del servidor.",
+	"Downloads can be saved to these roots of the client, by passing their name or path as root:":                                                                                       "Las descargas se pueden guardar en estas raíces del cliente, pasando su nombre o ruta como root:",
+}
@@ -0,0 +1,66 @@
+package library
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mozillazg/go-unidecode"
+)
+
+var (
+	// bracketedPattern matches series and edition notes such as
+	// "(Penguin Classics)" or "[2nd ed.]".
+	bracketedPattern = regexp.MustCompile(`\([^)]*\)|\[[^\]]*\]`)
+	nonAlnumPattern  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+var titleStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true,
+}
+
+// Fingerprint normalizes a title and authors into a key that is equal for
+// editions of the same work in different formats. Titles lose their
+// subtitles, bracketed notes, articles, accents, and punctuation, and authors
+// are reduced to the sorted set of their name parts, so that "Austen, Jane"
+// and "Jane Austen" match.
+func Fingerprint(title, authors string) string {
+	return normalizeTitle(title) + "|" + normalizeAuthors(authors)
+}
+
+func normalizeTitle(title string) string {
+	title = bracketedPattern.ReplaceAllString(title, " ")
+	if idx := strings.IndexAny(title, ":;"); idx > 0 {
+		title = title[:idx]
+	}
+
+	words := make([]string, 0)
+	for _, word := range tokenize(title) {
+		if !titleStopwords[word] {
+			words = append(words, word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+func normalizeAuthors(authors string) string {
+	seen := make(map[string]bool)
+	parts := make([]string, 0)
+	for _, part := range tokenize(authors) {
+		// Initials are written inconsistently, so only full name parts count.
+		if len(part) < 2 || seen[part] {
+			continue
+		}
+		seen[part] = true
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, " ")
+}
+
+func tokenize(s string) []string {
+	s = strings.ToLower(unidecode.Unidecode(s))
+	return strings.Fields(nonAlnumPattern.ReplaceAllString(s, " "))
+}
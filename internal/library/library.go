@@ -0,0 +1,458 @@
+package library
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/lockfile"
+)
+
+// Entry is a downloaded book recorded in the library index.
+type Entry struct {
+	Hash     string `json:"hash"`
+	Title    string `json:"title"`
+	Authors  string `json:"authors,omitempty"`
+	Format   string `json:"format"`
+	Language string `json:"language,omitempty"`
+	Year     string `json:"year,omitempty"`
+	// Fingerprint identifies the work independently of the edition and
+	// format, see Fingerprint.
+	Fingerprint string `json:"fingerprint"`
+	Location    string `json:"location"`
+	Size        int64  `json:"size,omitempty"`
+	// Transform records how the downloaded file was changed before it was
+	// stored, for example when it was unwrapped from a zip archive.
+	Transform string `json:"transform,omitempty"`
+	// ArchiveMember is the path of the file inside the archive it was
+	// unpacked from.
+	ArchiveMember string `json:"archive_member,omitempty"`
+	// Archive is the location of the original download, kept when the file
+	// was unpacked from an archive holding other files too. Its hash is the
+	// one of the entry.
+	Archive string `json:"archive,omitempty"`
+	// SizeMismatch flags files that did not match the size advertised on
+	// the detail page.
+	SizeMismatch string `json:"size_mismatch,omitempty"`
+	// Pages, TextLayer, and QualityWarnings are the findings of the
+	// inspection of the file after the download.
+	Pages           int      `json:"pages,omitempty"`
+	TextLayer       string   `json:"text_layer,omitempty"`
+	QualityWarnings []string `json:"quality_warnings,omitempty"`
+	// Copies lists the delivery targets the file was linked or copied to.
+	Copies       []string  `json:"copies,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	// DeletedAt is set once the file was removed by a cleanup. The entry is
+	// kept for the history, but the book is no longer in the library.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Search is a search recorded in the search history.
+type Search struct {
+	Query string `json:"query"`
+	Scope string `json:"scope,omitempty"`
+	// Results holds the hashes of the first results, in order.
+	Results     []string `json:"results"`
+	ResultCount int      `json:"result_count"`
+	// Downloaded holds the hashes of the results that were downloaded
+	// afterwards.
+	Downloaded []string  `json:"downloaded,omitempty"`
+	SearchedAt time.Time `json:"searched_at"`
+}
+
+// Limits of the search history.
+const (
+	maxSearches      = 500
+	maxSearchResults = 50
+)
+
+// Index is the list of downloaded books and recent searches, persisted as a
+// JSON file. It is safe for concurrent use.
+type Index struct {
+	path string
+
+	mu       sync.Mutex
+	entries  []*Entry
+	searches []*Search
+	// loaded identifies the version of the file the index was read from or
+	// last written, to notice writes of other processes.
+	loaded fs.FileInfo
+}
+
+// indexFile is the on-disk layout of an index.
+type indexFile struct {
+	Books    []*Entry  `json:"books"`
+	Searches []*Search `json:"searches"`
+}
+
+var (
+	indexesMu sync.Mutex
+	indexes   = make(map[string]*Index)
+)
+
+// Open returns the index stored at the given path, loading it on first use.
+// A missing file is an empty index. Indexes are shared per path, so all
+// callers in the process see the same entries.
+func Open(path string) (*Index, error) {
+	indexesMu.Lock()
+	defer indexesMu.Unlock()
+
+	if idx, ok := indexes[path]; ok {
+		return idx, nil
+	}
+
+	idx := &Index{path: path}
+
+	file, info, err := readIndexFile(path)
+	if err != nil {
+		return nil, err
+	}
+	idx.entries = file.Books
+	idx.searches = file.Searches
+	idx.loaded = info
+
+	indexes[path] = idx
+
+	return idx, nil
+}
+
+// readIndexFile reads the index at the given path, along with the file
+// information identifying its version. A missing file is an empty index.
+func readIndexFile(path string) (indexFile, fs.FileInfo, error) {
+	file := indexFile{
+		Books:    make([]*Entry, 0),
+		Searches: make([]*Search, 0),
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil, nil
+	}
+	if err != nil {
+		return file, nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, nil, err
+	}
+
+	switch {
+	case len(bytes.TrimSpace(data)) > 0 && bytes.TrimSpace(data)[0] == '[':
+		// Indexes written before the search history was added only hold
+		// the books.
+		if err := json.Unmarshal(data, &file.Books); err != nil {
+			return file, nil, err
+		}
+	case len(bytes.TrimSpace(data)) > 0:
+		var stored indexFile
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return file, nil, err
+		}
+		if stored.Books != nil {
+			file.Books = stored.Books
+		}
+		if stored.Searches != nil {
+			file.Searches = stored.Searches
+		}
+	}
+
+	return file, info, nil
+}
+
+// Add records an entry, replacing a previous one with the same hash and
+// scope, and saves the index. The most recent search of the scope that
+// returned the book is marked as leading to the download.
+func (idx *Index) Add(entry Entry) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for i := len(idx.searches) - 1; i >= 0; i-- {
+		search := idx.searches[i]
+		if search.Scope == entry.Scope && slices.Contains(search.Results, entry.Hash) {
+			if !slices.Contains(search.Downloaded, entry.Hash) {
+				search.Downloaded = append(search.Downloaded, entry.Hash)
+			}
+			break
+		}
+	}
+
+	if entry.Fingerprint == "" {
+		entry.Fingerprint = Fingerprint(entry.Title, entry.Authors)
+	}
+
+	replaced := false
+	for i, existing := range idx.entries {
+		if existing.Hash == entry.Hash && existing.Scope == entry.Scope {
+			idx.entries[i] = &entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		idx.entries = append(idx.entries, &entry)
+	}
+
+	return idx.save()
+}
+
+// Entries returns copies of all entries of the given scope, leaving out the
+// deleted ones.
+func (idx *Index) Entries(scope string) []Entry {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entries := make([]Entry, 0)
+	for _, entry := range idx.entries {
+		if entry.Scope == scope && entry.DeletedAt == nil {
+			entries = append(entries, *entry)
+		}
+	}
+
+	return entries
+}
+
+// Deleted returns copies of the deleted entries of the given scope.
+func (idx *Index) Deleted(scope string) []Entry {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entries := make([]Entry, 0)
+	for _, entry := range idx.entries {
+		if entry.Scope == scope && entry.DeletedAt != nil {
+			entries = append(entries, *entry)
+		}
+	}
+
+	return entries
+}
+
+// Entry returns the entry with the given hash in the given scope, unless it
+// was deleted.
+func (idx *Index) Entry(scope, hash string) (Entry, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, entry := range idx.entries {
+		if entry.Scope == scope && entry.Hash == hash && entry.DeletedAt == nil {
+			return *entry, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+// MarkDeleted records that the file of the entry with the given hash in the
+// given scope was removed, and saves the index. Downloading the book again
+// replaces the entry.
+func (idx *Index) MarkDeleted(scope, hash string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, entry := range idx.entries {
+		if entry.Scope == scope && entry.Hash == hash && entry.DeletedAt == nil {
+			now := time.Now().UTC()
+			entry.DeletedAt = &now
+			return idx.save()
+		}
+	}
+
+	return nil
+}
+
+// Refresh adds the entries and searches that other processes saved since
+// the index was last read or written.
+func (idx *Index) Refresh() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	lock, err := lockfile.Acquire(idx.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return idx.merge()
+}
+
+// AddSearch records a search in the history and saves the index. Only the
+// most recent searches are kept.
+func (idx *Index) AddSearch(search Search) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if len(search.Results) > maxSearchResults {
+		search.Results = search.Results[:maxSearchResults]
+	}
+
+	idx.searches = append(idx.searches, &search)
+	if len(idx.searches) > maxSearches {
+		idx.searches = idx.searches[len(idx.searches)-maxSearches:]
+	}
+
+	return idx.save()
+}
+
+// Searches returns copies of the most recent searches of the given scope,
+// newest first. A limit of zero returns all of them.
+func (idx *Index) Searches(scope string, limit int) []Search {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	searches := make([]Search, 0)
+	for i := len(idx.searches) - 1; i >= 0; i-- {
+		if limit > 0 && len(searches) == limit {
+			break
+		}
+		if search := idx.searches[i]; search.Scope == scope {
+			copied := *search
+			copied.Results = slices.Clone(search.Results)
+			copied.Downloaded = slices.Clone(search.Downloaded)
+			searches = append(searches, copied)
+		}
+	}
+
+	return searches
+}
+
+// SameWork returns the entries of the given scope whose fingerprint matches
+// the title and authors. Entries or queries without authors match on the
+// title alone.
+func (idx *Index) SameWork(scope, title, authors string) []Entry {
+	query := Fingerprint(title, authors)
+
+	matches := make([]Entry, 0)
+	for _, entry := range idx.Entries(scope) {
+		if fingerprintsMatch(entry.Fingerprint, query) {
+			matches = append(matches, entry)
+		}
+	}
+
+	return matches
+}
+
+// save writes the index atomically, so a crash never leaves a truncated
+// file behind. Processes sharing the index take turns through a lock file,
+// and changes another process saved in the meantime are merged in first. The
+// caller must hold the lock.
+func (idx *Index) save() error {
+	lock, err := lockfile.Acquire(idx.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := idx.merge(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(indexFile{Books: idx.entries, Searches: idx.searches}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), ".library-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		return err
+	}
+
+	idx.loaded, err = os.Stat(idx.path)
+
+	return err
+}
+
+// merge adds the entries and searches that another process saved since the
+// index was loaded. Entries of this process win for books both recorded. The
+// caller must hold both locks.
+func (idx *Index) merge() error {
+	info, err := os.Stat(idx.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if idx.loaded != nil && os.SameFile(info, idx.loaded) && info.ModTime().Equal(idx.loaded.ModTime()) && info.Size() == idx.loaded.Size() {
+		return nil
+	}
+
+	file, _, err := readIndexFile(idx.path)
+	if err != nil {
+		return err
+	}
+
+	for _, stored := range file.Books {
+		known := slices.ContainsFunc(idx.entries, func(entry *Entry) bool {
+			return entry.Hash == stored.Hash && entry.Scope == stored.Scope
+		})
+		if !known {
+			idx.entries = append(idx.entries, stored)
+		}
+	}
+
+	for _, stored := range file.Searches {
+		known := slices.ContainsFunc(idx.searches, func(search *Search) bool {
+			return search.Scope == stored.Scope && search.Query == stored.Query && search.SearchedAt.Equal(stored.SearchedAt)
+		})
+		if !known {
+			idx.searches = append(idx.searches, stored)
+		}
+	}
+	slices.SortStableFunc(idx.searches, func(a, b *Search) int {
+		return a.SearchedAt.Compare(b.SearchedAt)
+	})
+	if len(idx.searches) > maxSearches {
+		idx.searches = idx.searches[len(idx.searches)-maxSearches:]
+	}
+
+	return nil
+}
+
+// IsSameWork reports whether two books are editions of the same work, see
+// Fingerprint. Books without authors are matched by their title alone.
+func IsSameWork(title, authors, otherTitle, otherAuthors string) bool {
+	return fingerprintsMatch(Fingerprint(title, authors), Fingerprint(otherTitle, otherAuthors))
+}
+
+func fingerprintsMatch(a, b string) bool {
+	titleA, authorsA, _ := strings.Cut(a, "|")
+	titleB, authorsB, _ := strings.Cut(b, "|")
+
+	if titleA == "" || titleA != titleB {
+		return false
+	}
+
+	return authorsA == "" || authorsB == "" || authorsA == authorsB
+}
+
+// DuplicateError is returned when a book is already in the library in
+// another format.
+type DuplicateError struct {
+	Existing Entry
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%q is already in the library as %s at %s", e.Existing.Title, e.Existing.Format, e.Existing.Location)
+}
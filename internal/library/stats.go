@@ -0,0 +1,65 @@
+package library
+
+import "strings"
+
+// Stats summarizes the books of a library.
+type Stats struct {
+	Books int `json:"books"`
+	// TotalSize is the size of all stored files in bytes.
+	TotalSize  int64          `json:"total_size"`
+	ByFormat   map[string]int `json:"by_format"`
+	ByLanguage map[string]int `json:"by_language"`
+	ByAuthor   map[string]int `json:"by_author"`
+	// ByMonth counts the downloads per month, keyed as "2006-01".
+	ByMonth map[string]int `json:"by_month"`
+}
+
+// Stats returns the statistics of the books of the given scope. Books with
+// several authors count once for each of them.
+func (idx *Index) Stats(scope string) Stats {
+	stats := Stats{
+		ByFormat:   make(map[string]int),
+		ByLanguage: make(map[string]int),
+		ByAuthor:   make(map[string]int),
+		ByMonth:    make(map[string]int),
+	}
+
+	for _, entry := range idx.Entries(scope) {
+		stats.Books++
+		stats.TotalSize += entry.Size
+		stats.ByFormat[orUnknown(strings.ToLower(entry.Format))]++
+		stats.ByLanguage[orUnknown(entry.Language)]++
+		for _, author := range splitAuthors(entry.Authors) {
+			stats.ByAuthor[author]++
+		}
+		if !entry.DownloadedAt.IsZero() {
+			stats.ByMonth[entry.DownloadedAt.Format("2006-01")]++
+		}
+	}
+
+	return stats
+}
+
+// splitAuthors splits the author list of Anna's Archive, which separates
+// authors with semicolons.
+func splitAuthors(authors string) []string {
+	result := make([]string, 0)
+	for _, author := range strings.Split(authors, ";") {
+		if author = strings.TrimSpace(author); author != "" {
+			result = append(result, author)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, "unknown")
+	}
+
+	return result
+}
+
+func orUnknown(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "unknown"
+	}
+
+	return value
+}
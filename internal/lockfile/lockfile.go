@@ -0,0 +1,98 @@
+// Package lockfile coordinates processes sharing the same files through
+// advisory locks, which the operating system releases when a process exits,
+// even if it crashes.
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HeldError is returned by TryAcquire when another process holds the lock.
+type HeldError struct {
+	Path string
+	// PID is the process holding the lock, zero if unknown.
+	PID int
+}
+
+func (e *HeldError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("%s is locked by another process (PID %d)", e.Path, e.PID)
+	}
+
+	return fmt.Sprintf("%s is locked by another process", e.Path)
+}
+
+// Lock is an exclusive lock on a file.
+type Lock struct {
+	f *os.File
+}
+
+func open(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+}
+
+// Acquire waits until the lock at the given path is free and takes it.
+func Acquire(path string) (*Lock, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lock(f, true); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Lock{f: f}, nil
+}
+
+// TryAcquire takes the lock at the given path, or returns a *HeldError if
+// another process holds it. The lock file records the PID of its holder, so
+// that the error can name it.
+func TryAcquire(path string) (*Lock, error) {
+	f, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lock(f, false); err != nil {
+		f.Close()
+		if errors.Is(err, errWouldBlock) {
+			return nil, &HeldError{Path: path, PID: readPID(path)}
+		}
+		return nil, err
+	}
+
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &Lock{f: f}, nil
+}
+
+// Release frees the lock.
+func (l *Lock) Release() error {
+	unlock(l.f)
+
+	return l.f.Close()
+}
+
+func readPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+
+	return pid
+}
@@ -15,7 +15,7 @@ func init() {
 	// Check if we're running the MCP server
 	isMCPMode := false
 	for _, arg := range os.Args[1:] {
-		if arg == "mcp" {
+		if arg == "mcp" || arg == "serve" {
 			isMCPMode = true
 			break
 		}
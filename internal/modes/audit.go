@@ -0,0 +1,173 @@
+package modes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Requester identifies who asked for a download in the audit log. Clients
+// are named by their key scope, so that bearer tokens never end up in the
+// log.
+type Requester struct {
+	Interface string `json:"interface"`
+	Client    string `json:"client,omitempty"`
+	Session   string `json:"session,omitempty"`
+}
+
+type requesterKey struct{}
+
+func withRequester(ctx context.Context, r Requester) context.Context {
+	return context.WithValue(ctx, requesterKey{}, r)
+}
+
+func requesterFrom(ctx context.Context) Requester {
+	r, _ := ctx.Value(requesterKey{}).(Requester)
+	return r
+}
+
+// mcpRequester identifies the client of an MCP tool call.
+func mcpRequester(ss *mcp.ServerSession, keyScope string) Requester {
+	r := Requester{Interface: "mcp", Client: keyScope}
+	if ss != nil {
+		r.Session = ss.ID()
+	}
+
+	return r
+}
+
+// cliRequester identifies the local user running a CLI command.
+func cliRequester() Requester {
+	r := Requester{Interface: "cli"}
+	if u, err := user.Current(); err == nil {
+		r.Client = u.Username
+	}
+
+	return r
+}
+
+// Results of the downloads recorded in the audit log.
+const (
+	auditDownloaded = "downloaded"
+	auditDuplicate  = "duplicate"
+	auditFailed     = "failed"
+)
+
+// auditRecord is a line of the audit log.
+type auditRecord struct {
+	Time time.Time `json:"time"`
+	Requester
+	Scope string `json:"scope,omitempty"`
+	// Query is the most recent search of the scope that returned the book.
+	Query       string `json:"query,omitempty"`
+	Hash        string `json:"hash"`
+	FetchedHash string `json:"fetched_hash,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Result      string `json:"result"`
+	Error       string `json:"error,omitempty"`
+}
+
+var auditMu sync.Mutex
+
+// auditDownload appends the outcome of a download to the audit log set in
+// ANNAS_AUDIT_LOG, one JSON object per line. Failures to write it are
+// logged, since the download itself is finished.
+func auditDownload(ctx context.Context, env *Env, book *anna.Book, scope string, result *anna.DownloadResult, err error) {
+	path := os.Getenv("ANNAS_AUDIT_LOG")
+	if path == "" {
+		return
+	}
+
+	record := auditRecord{
+		Time:      time.Now().UTC(),
+		Requester: requesterFrom(ctx),
+		Scope:     scope,
+		Query:     requestingQuery(env, scope, book.Hash),
+		Hash:      book.Hash,
+		Title:     book.Title,
+		Format:    book.Format,
+		Result:    auditDownloaded,
+	}
+
+	var duplicate *library.DuplicateError
+	switch {
+	case errors.As(err, &duplicate):
+		record.Result = auditDuplicate
+		record.Destination = duplicate.Existing.Location
+	case err != nil:
+		record.Result = auditFailed
+		record.Error = err.Error()
+	default:
+		record.FetchedHash = result.FetchedHash
+		record.Format = result.Format
+		record.Destination = result.Location
+		record.Size = result.Size
+	}
+
+	if err := appendAuditRecord(path, record); err != nil {
+		logger.GetLogger().Error("Failed to write the audit log",
+			zap.String("path", path),
+			zap.String("bookHash", book.Hash),
+			zap.Error(err),
+		)
+	}
+}
+
+// requestingQuery returns the most recent search of the scope that returned
+// the book, if the history has one.
+func requestingQuery(env *Env, scope, hash string) string {
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return ""
+	}
+
+	for _, search := range idx.Searches(scope, 0) {
+		if slices.Contains(search.Results, hash) {
+			return search.Query
+		}
+	}
+
+	return ""
+}
+
+func appendAuditRecord(path string, record auditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Appends of a single line are atomic, so instances sharing the log do
+	// not interleave their records.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,114 @@
+package modes
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+type identityKey struct{}
+
+// Authenticator checks the bearer token of HTTP requests against static
+// tokens and, if configured, an OIDC issuer.
+type Authenticator struct {
+	tokens   []string
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewAuthenticatorFromEnv builds an authenticator from ANNAS_HTTP_TOKENS (a
+// comma-separated list of static tokens) and ANNAS_OIDC_ISSUER with
+// ANNAS_OIDC_AUDIENCE. It returns nil if neither is set, in which case the
+// HTTP transport is left open.
+func NewAuthenticatorFromEnv(ctx context.Context) (*Authenticator, error) {
+	tokens := make([]string, 0)
+	for _, token := range strings.Split(os.Getenv("ANNAS_HTTP_TOKENS"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	issuer := os.Getenv("ANNAS_OIDC_ISSUER")
+	if len(tokens) == 0 && issuer == "" {
+		return nil, nil
+	}
+
+	auth := &Authenticator{tokens: tokens}
+
+	if issuer != "" {
+		audience := os.Getenv("ANNAS_OIDC_AUDIENCE")
+		if audience == "" {
+			return nil, errors.New("ANNAS_OIDC_AUDIENCE must be set when ANNAS_OIDC_ISSUER is set")
+		}
+
+		provider, err := oidc.NewProvider(ctx, issuer)
+		if err != nil {
+			return nil, err
+		}
+		auth.verifier = provider.Verifier(&oidc.Config{ClientID: audience})
+	}
+
+	return auth, nil
+}
+
+// authenticate returns the identity behind a token. Static tokens are their
+// own identity, while OIDC tokens are identified by their issuer and subject
+// so that scopes survive token refreshes.
+func (a *Authenticator) authenticate(ctx context.Context, token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+
+	for _, allowed := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return token, true
+		}
+	}
+
+	if a.verifier != nil {
+		idToken, err := a.verifier.Verify(ctx, token)
+		if err == nil {
+			return idToken.Issuer + "|" + idToken.Subject, true
+		}
+	}
+
+	return "", false
+}
+
+// Middleware rejects requests without a valid bearer token and attaches the
+// authenticated identity to the request context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	l := logger.GetLogger()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := a.authenticate(r.Context(), bearerToken(r))
+		if !ok {
+			l.Warn("Rejected unauthenticated HTTP request",
+				zap.String("remoteAddr", r.RemoteAddr),
+				zap.String("path", r.URL.Path),
+			)
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="annas-mcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, identity)))
+	})
+}
+
+// requestIdentity returns the authenticated identity of a request, falling
+// back to the raw bearer token when authentication is disabled.
+func requestIdentity(r *http.Request) string {
+	if identity, ok := r.Context().Value(identityKey{}).(string); ok {
+		return identity
+	}
+
+	return bearerToken(r)
+}
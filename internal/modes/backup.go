@@ -0,0 +1,321 @@
+package modes
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/joho/godotenv"
+)
+
+// backupVersion is the version of the layout of backups, raised when restores
+// of older backups need to convert them.
+const backupVersion = 1
+
+// Names of the files in a backup.
+const (
+	backupManifest = "manifest.json"
+	backupLibrary  = "library.json"
+	backupJobs     = "jobs.json"
+	backupAuditLog = "audit.log"
+	backupConfig   = "config.env"
+)
+
+// configPath is the .env file the CLI loads its configuration from.
+const configPath = ".env"
+
+// BackupManifest describes the files of a backup.
+type BackupManifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// DownloadPath is the download directory of the backed up server, which
+	// the locations of the books in the library index start with.
+	DownloadPath string       `json:"download_path,omitempty"`
+	Files        []BackupFile `json:"files"`
+}
+
+// BackupFile is a file of a backup. Path is where it was backed up from, or
+// where it was restored to.
+type BackupFile struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// Skipped tells why a file was not restored.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// backupSources returns the locations of the files of the configured server
+// that backups hold, by their name in the backup. The audit log is left out
+// if ANNAS_AUDIT_LOG is not set.
+func backupSources() map[string]string {
+	downloadPath := os.Getenv("ANNAS_DOWNLOAD_PATH")
+	sources := map[string]string{
+		backupLibrary: libraryPath(downloadPath),
+		backupJobs:    jobPlanPath(downloadPath),
+		backupConfig:  configPath,
+	}
+	if path := os.Getenv("ANNAS_AUDIT_LOG"); path != "" {
+		sources[backupAuditLog] = path
+	}
+
+	return sources
+}
+
+// backupOrder lists the files of a backup in the order they are written and
+// restored. The configuration comes first, as it tells where the others go.
+var backupOrder = []string{backupConfig, backupLibrary, backupJobs, backupAuditLog}
+
+// Backup writes the library index, the download queue, the audit log, and the
+// .env configuration of the server to w, as a gzipped tarball. Files that do
+// not exist yet are left out. Secrets kept in the keychain are not backed up.
+func Backup(w io.Writer) (*BackupManifest, error) {
+	manifest := &BackupManifest{
+		Version:      backupVersion,
+		CreatedAt:    time.Now().UTC(),
+		DownloadPath: os.Getenv("ANNAS_DOWNLOAD_PATH"),
+		Files:        make([]BackupFile, 0),
+	}
+
+	// The files are read first, so that the manifest listing them can lead
+	// the archive.
+	sources := backupSources()
+	contents := make(map[string][]byte)
+	for _, name := range backupOrder {
+		path, ok := sources[name]
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		contents[name] = data
+		manifest.Files = append(manifest.Files, BackupFile{Name: name, Path: path, Size: int64(len(data))})
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	write := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0o600,
+			Size:    int64(len(data)),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(backupManifest, manifestData); err != nil {
+		return nil, err
+	}
+	for _, file := range manifest.Files {
+		if err := write(file.Name, contents[file.Name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// Restore puts the files of a backup written by Backup where the configured
+// server keeps them, which may differ from where they were backed up from.
+// The configuration is restored first and loaded, without overriding the
+// variables set in the environment. Files that exist already are skipped,
+// unless overwrite is set. If the download directory changed, the locations
+// of the books in the library index are moved to the new one.
+//
+// The caller must make sure that no server is using the library.
+func Restore(r io.Reader, overwrite bool) (*BackupManifest, error) {
+	manifest, contents, err := readBackup(r)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := &BackupManifest{
+		Version:      manifest.Version,
+		CreatedAt:    manifest.CreatedAt,
+		DownloadPath: manifest.DownloadPath,
+		Files:        make([]BackupFile, 0),
+	}
+	for _, name := range backupOrder {
+		data, ok := contents[name]
+		if !ok {
+			continue
+		}
+
+		// Paths depend on the configuration, which may have just been
+		// restored.
+		file := BackupFile{Name: name, Path: backupSources()[name], Size: int64(len(data))}
+		switch {
+		case file.Path == "":
+			file.Skipped = "ANNAS_AUDIT_LOG is not set"
+		case !overwrite && fileExists(file.Path):
+			file.Skipped = "the file exists already"
+		}
+		if file.Skipped != "" {
+			restored.Files = append(restored.Files, file)
+			continue
+		}
+
+		if name == backupLibrary {
+			if data, err = rebaseLibrary(data, manifest.DownloadPath, os.Getenv("ANNAS_DOWNLOAD_PATH")); err != nil {
+				return nil, fmt.Errorf("invalid library index: %w", err)
+			}
+		}
+		if err := restoreFile(file.Path, data); err != nil {
+			return nil, err
+		}
+		if abs, err := filepath.Abs(file.Path); err == nil {
+			file.Path = abs
+		}
+		restored.Files = append(restored.Files, file)
+
+		if name == backupConfig {
+			if err := godotenv.Load(file.Path); err != nil {
+				return nil, fmt.Errorf("invalid configuration: %w", err)
+			}
+		}
+	}
+
+	return restored, nil
+}
+
+// readBackup returns the manifest and the files of a backup, by their name.
+func readBackup(r io.Reader) (*BackupManifest, map[string][]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a backup: %w", err)
+	}
+	defer gr.Close()
+
+	var manifest *BackupManifest
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("not a backup: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if header.Name == backupManifest {
+			manifest = &BackupManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+			continue
+		}
+		contents[header.Name] = data
+	}
+
+	if manifest == nil {
+		return nil, nil, errors.New("not a backup: the manifest is missing")
+	}
+	if manifest.Version > backupVersion {
+		return nil, nil, fmt.Errorf("the backup was made by a newer version of annas-mcp (layout %d)", manifest.Version)
+	}
+
+	return manifest, contents, nil
+}
+
+// rebaseLibrary moves the locations of the books of a library index from the
+// old download directory to the new one.
+func rebaseLibrary(data []byte, from, to string) ([]byte, error) {
+	if from == "" || to == "" || filepath.Clean(from) == filepath.Clean(to) {
+		return data, nil
+	}
+
+	var index struct {
+		Books    []*library.Entry  `json:"books"`
+		Searches []*library.Search `json:"searches"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	for _, entry := range index.Books {
+		entry.Location = rebasePath(entry.Location, from, to)
+		entry.Archive = rebasePath(entry.Archive, from, to)
+	}
+
+	return json.MarshalIndent(index, "", "  ")
+}
+
+func rebasePath(path, from, to string) string {
+	rel, err := filepath.Rel(from, path)
+	if path == "" || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return filepath.Join(to, rel)
+}
+
+func restoreFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// formatBackup describes the files of a backup, or of a restore, where
+// operation is "back up" or "restore".
+func formatBackup(manifest *BackupManifest, operation string) string {
+	var sb strings.Builder
+	for _, file := range manifest.Files {
+		if file.Skipped != "" {
+			fmt.Fprintf(&sb, "Skipped %s: %s\n", file.Name, file.Skipped)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s: %s (%s)\n", file.Name, file.Path, formatByteSize(file.Size))
+	}
+	if len(manifest.Files) == 0 {
+		fmt.Fprintf(&sb, "No files to %s.\n", operation)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,14 @@
+package modes
+
+import "testing"
+
+func TestFormatEmptyBackup(t *testing.T) {
+	for operation, want := range map[string]string{
+		"back up": "No files to back up.\n",
+		"restore": "No files to restore.\n",
+	} {
+		if got := formatBackup(&BackupManifest{}, operation); got != want {
+			t.Errorf("formatBackup of an empty manifest to %s returned %q, want %q", operation, got, want)
+		}
+	}
+}
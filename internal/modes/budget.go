@@ -0,0 +1,92 @@
+package modes
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// bytesPerToken approximates the size of a token of English text, which is
+// close enough to keep responses within the context of a model.
+const bytesPerToken = 4
+
+// responseBudget returns the number of tokens a response may take, as given
+// with the call or set in ANNAS_MAX_RESPONSE_TOKENS, or zero for no limit.
+func responseBudget(requested int) int {
+	if requested > 0 {
+		return requested
+	}
+
+	budget, err := strconv.Atoi(os.Getenv("ANNAS_MAX_RESPONSE_TOKENS"))
+	if err != nil || budget < 0 {
+		return 0
+	}
+
+	return budget
+}
+
+// fitToBudget joins the renderings of a list of results within a budget of
+// tokens. If the full renderings do not fit, the compact ones are used, and
+// if these do not fit either, the list is cut short. It returns the text, the
+// number of results it holds, and whether the compact renderings were used.
+func fitToBudget(full, compact []string, budget int) (string, int, bool) {
+	if budget <= 0 || len(strings.Join(full, "")) <= budget*bytesPerToken {
+		return strings.Join(full, ""), len(full), false
+	}
+
+	limit := budget * bytesPerToken
+	size := 0
+	for i, item := range compact {
+		if size+len(item) > limit {
+			return strings.Join(compact[:i], ""), i, true
+		}
+		size += len(item)
+	}
+
+	return strings.Join(compact, ""), len(compact), true
+}
+
+// compactBook renders a search result on a single line, without the URL,
+// publisher, and cover of the book.
+func compactBook(b *anna.Book) string {
+	details := nonEmpty(b.Format, b.Size, b.Year, b.LanguageCode)
+	return fmt.Sprintf("%s by %s (%s), hash %s\n", b.Title, b.Authors, strings.Join(details, ", "), b.Hash)
+}
+
+// compactCopy returns a copy of a search result without the fields
+// compactBook leaves out.
+func compactCopy(b *anna.Book) *anna.Book {
+	book := *b
+	book.URL = ""
+	book.Publisher = ""
+	book.CoverURL = ""
+
+	return &book
+}
+
+// truncationNote tells the model that results were left out or shortened to
+// fit the budget, and is empty otherwise.
+func truncationNote(shown, total int, compact bool, budget int) string {
+	switch {
+	case shown < total:
+		return fmt.Sprintf("\nResults were shortened to fit %d tokens: showing %d of %d, without URLs and publishers. Refine the search or raise max_response_tokens to see more.\n", budget, shown, total)
+	case compact:
+		return fmt.Sprintf("\nResults were shortened to fit %d tokens, without URLs and publishers.\n", budget)
+	default:
+		return ""
+	}
+}
+
+// truncationMeta flags truncated results in the metadata of a tool result,
+// for clients that do not read the text.
+func truncationMeta(shown, total int, compact bool) mcp.Meta {
+	if shown == total && !compact {
+		return nil
+	}
+
+	return mcp.Meta{"truncated": true, "shown": shown, "total": total}
+}
@@ -1,21 +1,35 @@
 package modes
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/fang"
-	"github.com/iosifache/annas-mcp/internal/anna"
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/lockfile"
 	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
 	"github.com/iosifache/annas-mcp/internal/version"
+	"github.com/iosifache/annas-mcp/pkg/anna"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"golang.org/x/term"
 )
 
+// jsonOutput is set by the persistent --json flag and switches all commands
+// from human-readable to JSON output.
+var jsonOutput bool
+
 func StartCLI() {
 	l := logger.GetLogger()
 	defer l.Sync()
@@ -25,28 +39,104 @@ func StartCLI() {
 	}
 
 	rootCmd := &cobra.Command{
-		Use:   "annas-mcp",
-		Short: "Anna's Archive MCP CLI",
-		Long:  "A command-line interface for searching and downloading books from Anna's Archive.",
-		CompletionOptions: cobra.CompletionOptions{
-			DisableDefaultCmd: true,
-		},
+		Use:     "annas-mcp",
+		Short:   "Anna's Archive MCP CLI",
+		Long:    "A command-line interface for searching and downloading books from Anna's Archive.",
 		Version: version.GetVersion(),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cmd.Help()
 		},
 	}
 	rootCmd.SetVersionTemplate("{{.Version}}\n")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print the output as JSON")
+
+	rootCmd.AddCommand(
+		newSearchCmd(),
+		newGetCmd(),
+		newDownloadCmd(),
+		newServeCmd(),
+		newInstallServiceCmd(),
+		newUninstallServiceCmd(),
+		newSecretCmd(),
+		newStatusCmd(),
+		newHistoryCmd(),
+		newReindexCmd(),
+		newReorganizeCmd(),
+		newCleanupCmd(),
+		newVerifyCmd(),
+		newImportCmd(),
+		newImportGoodreadsCmd(),
+		newBackupCmd(),
+		newRestoreCmd(),
+		newExportCmd(),
+		newExportNotesCmd(),
+		newDoctorCmd(),
+	)
+
+	if err := fang.Execute(
+		context.Background(),
+		rootCmd,
+		fang.WithVersion(version.GetVersion()),
+		fang.WithErrorHandler(handleError),
+	); err != nil {
+		os.Exit(exitCode(err))
+	}
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func newSearchCmd() *cobra.Command {
+	l := logger.GetLogger()
 
-	searchCmd := &cobra.Command{
+	var (
+		excludeFormats   []string
+		yearFrom, yearTo int
+		minSize, maxSize string
+		fields           anna.FieldQuery
+		romanize         bool
+		originalTitle    string
+		originalAuthor   string
+	)
+
+	cmd := &cobra.Command{
 		Use:   "search [term]",
 		Short: "Search for books",
-		Args:  cobra.ExactArgs(1),
+		Long:  "Search for books. Several arguments are joined into a single search term, which --title, --author, and --publisher narrow down to fields of the metadata. With --romanize, a title or author in another script is also searched romanized, and --original-title and --original-author are searched along with the given ones, with the results merged.",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && fields.Title == "" && fields.Author == "" && fields.Publisher == "" && originalTitle == "" && originalAuthor == "" {
+				return errors.New("requires a term, or one of --title, --author, or --publisher")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			searchTerm := args[0]
+			fields.Term = strings.Join(args, " ")
+			searchTerm := fields.String()
 			l.Info("Search command called", zap.String("searchTerm", searchTerm))
 
-			books, err := anna.FindBook(searchTerm)
+			filter, err := searchFilter(excludeFormats, yearFrom, yearTo, minSize, maxSize)
+			if err != nil {
+				l.Error("Search command failed", zap.Error(err))
+				return err
+			}
+
+			var (
+				books  []*anna.Book
+				ranked []*anna.RankedBook
+			)
+			if romanize || originalTitle != "" || originalAuthor != "" {
+				variants := scriptVariants(fields, originalTitle, originalAuthor)
+				searchTerm = variants[0].String()
+				ranked, _, err = GetClient().ScriptSearch(cmd.Context(), variants)
+				for _, book := range ranked {
+					books = append(books, book.Book)
+				}
+			} else {
+				books, err = GetClient().Search(cmd.Context(), searchTerm)
+			}
 			if err != nil {
 				l.Error("Search command failed",
 					zap.String("searchTerm", searchTerm),
@@ -54,43 +144,142 @@ func StartCLI() {
 				)
 				return fmt.Errorf("failed to search books: %w", err)
 			}
+			books, excluded := filter.Apply(books)
+			ranked = slices.DeleteFunc(ranked, func(book *anna.RankedBook) bool {
+				return !slices.Contains(books, book.Book)
+			})
 
-			if len(books) == 0 {
-				fmt.Println("No books found.")
-				return nil
-			}
-
-			for i, book := range books {
-				fmt.Printf("Book %d:\n%s\n", i+1, book.String())
-				if i < len(books)-1 {
-					fmt.Println()
-				}
-			}
+			recordSearch("", searchTerm, books)
 
 			l.Info("Search command completed successfully",
 				zap.String("searchTerm", searchTerm),
 				zap.Int("resultsCount", len(books)),
 			)
 
+			switch {
+			case jsonOutput && ranked != nil:
+				if err := printJSON(ranked); err != nil {
+					return err
+				}
+			case jsonOutput:
+				if err := printJSON(books); err != nil {
+					return err
+				}
+			case len(books) == 0:
+				fmt.Print(formatSuggestions(searchSuggestions(fields, excluded)))
+			case ranked != nil:
+				for i, book := range ranked {
+					fmt.Printf("Book %d:\n%s\nMatched queries: %s\n", i+1, book.String(), strings.Join(book.MatchedQueries, "; "))
+					if i < len(ranked)-1 {
+						fmt.Println()
+					}
+				}
+			default:
+				for i, book := range books {
+					fmt.Printf("Book %d:\n%s\n", i+1, book.String())
+					if i < len(books)-1 {
+						fmt.Println()
+					}
+				}
+			}
+
+			if len(books) == 0 {
+				return errNoResults
+			}
+
 			return nil
 		},
 	}
 
-	downloadCmd := &cobra.Command{
-		Use:   "download [hash] [filename]",
-		Short: "Download a book by its MD5 hash",
-		Long:  "Download a book by its MD5 hash to the specified filename. Requires ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables.",
-		Args:  cobra.ExactArgs(2),
+	cmd.Flags().StringSliceVar(&excludeFormats, "exclude-formats", nil, "Formats to leave out of the results, for example djvu,cbr (defaults to ANNAS_EXCLUDE_FORMATS)")
+	cmd.Flags().IntVar(&yearFrom, "year-from", 0, "Earliest publication year")
+	cmd.Flags().IntVar(&yearTo, "year-to", 0, "Latest publication year")
+	cmd.Flags().StringVar(&minSize, "min-size", "", "Smallest file size, for example 500KB")
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Largest file size, for example 200MB")
+	cmd.Flags().StringVar(&fields.Title, "title", "", "Words to find in the title only")
+	cmd.Flags().StringVar(&fields.Author, "author", "", "Words to find in the authors only")
+	cmd.Flags().StringVar(&fields.Publisher, "publisher", "", "Words to find in the publisher only")
+	cmd.Flags().BoolVar(&romanize, "romanize", false, "Also search a title or author in another script romanized, with the schemes of ANNAS_TRANSLITERATION")
+	cmd.Flags().StringVar(&originalTitle, "original-title", "", "Title in its original script, searched along with the given one")
+	cmd.Flags().StringVar(&originalAuthor, "original-author", "", "Author in the original script, searched along with the given one")
+
+	return cmd
+}
+
+func newGetCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "get [hash]",
+		Short: "Show the full metadata of a book",
+		Long:  "Show the full metadata of a book by its MD5 hash, including its description and table of contents.",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			bookHash := args[0]
-			filename := args[1]
+			l.Info("Get command called", zap.String("bookHash", bookHash))
+
+			details, err := GetClient().GetBook(cmd.Context(), bookHash)
+			if err != nil {
+				l.Error("Get command failed",
+					zap.String("bookHash", bookHash),
+					zap.Error(err),
+				)
+				return fmt.Errorf("failed to get book: %w", err)
+			}
 
-			ext := filepath.Ext(filename)
-			if ext == "" {
-				return fmt.Errorf("filename must include an extension (e.g., .pdf, .epub)")
+			l.Info("Get command completed successfully", zap.String("bookHash", bookHash))
+
+			if jsonOutput {
+				return printJSON(details.Book)
+			}
+
+			fmt.Println(details.Book.String())
+
+			return nil
+		},
+	}
+}
+
+func newDownloadCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var allowDuplicateFormats, supplements bool
+
+	cmd := &cobra.Command{
+		Use:   "download [hash or URL] [filename]",
+		Short: "Download a book by its MD5 hash or URL",
+		Long:  "Download a book by its MD5 hash to the specified filename. Instead of the hash, the URL of the book on Anna's Archive, Library Genesis, or Z-Library can be given, and without a filename the title and format of the book are used. Requires ANNAS_SECRET_KEY (or ANNAS_ACCOUNT_COOKIE) and ANNAS_DOWNLOAD_PATH environment variables.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := GetClient()
+
+			bookHash, err := client.ResolveHash(cmd.Context(), args[0])
+			if err != nil {
+				l.Error("Failed to resolve book hash", zap.String("input", args[0]), zap.Error(err))
+				return fmt.Errorf("failed to resolve book hash: %w", err)
+			}
+
+			var title, format, filename string
+			if len(args) == 2 {
+				filename = args[1]
+
+				ext := filepath.Ext(filename)
+				if ext == "" {
+					return fmt.Errorf("filename must include an extension (e.g., .pdf, .epub)")
+				}
+				format = strings.TrimPrefix(ext, ".")
+				title = strings.TrimSuffix(filepath.Base(filename), ext)
+			} else {
+				details, err := client.GetBook(cmd.Context(), bookHash)
+				if err != nil {
+					l.Error("Failed to get book details", zap.String("bookHash", bookHash), zap.Error(err))
+					return fmt.Errorf("failed to get book details: %w", err)
+				}
+				title, format = details.Book.Title, details.Book.Format
+				if format == "" {
+					return fmt.Errorf("the format of the book is unknown, pass a filename with an extension")
+				}
 			}
-			format := strings.TrimPrefix(ext, ".")
-			title := strings.TrimSuffix(filepath.Base(filename), ext)
 
 			l.Info("Download command called",
 				zap.String("bookHash", bookHash),
@@ -102,7 +291,7 @@ func StartCLI() {
 			env, err := GetEnv()
 			if err != nil {
 				l.Error("Failed to get environment variables", zap.Error(err))
-				return fmt.Errorf("failed to get environment: %w", err)
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
 			}
 
 			book := &anna.Book{
@@ -111,7 +300,21 @@ func StartCLI() {
 				Format: format,
 			}
 
-			err = book.Download(env.SecretKey, env.DownloadPath)
+			store, err := storage.New(env.Storage)
+			if err != nil {
+				l.Error("Failed to initialize storage", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to initialize storage: %w", err))
+			}
+
+			result, err := downloadToLibrary(withRequester(cmd.Context(), cliRequester()), env, store, book, "", downloadOptions{
+				allowDuplicateFormats: allowDuplicateFormats,
+				supplements:           supplements,
+			})
+			var duplicate *library.DuplicateError
+			if errors.As(err, &duplicate) {
+				fmt.Fprintf(os.Stderr, "Warning: skipped download, %s. Pass --allow-duplicate-formats to download it anyway.\n", duplicate.Error())
+				return nil
+			}
 			if err != nil {
 				l.Error("Download command failed",
 					zap.String("bookHash", bookHash),
@@ -121,40 +324,814 @@ func StartCLI() {
 				return fmt.Errorf("failed to download book: %w", err)
 			}
 
-			fullPath := filepath.Join(env.DownloadPath, filename)
-			fmt.Printf("Book downloaded successfully to: %s\n", fullPath)
-
 			l.Info("Download command completed successfully",
 				zap.String("bookHash", bookHash),
-				zap.String("downloadPath", env.DownloadPath),
+				zap.String("location", result.Location),
 				zap.String("filename", filename),
 			)
 
+			if jsonOutput {
+				return printJSON(result)
+			}
+
+			fmt.Printf("Book downloaded successfully to: %s\n", result.Location)
+			if result.FetchedHash != "" {
+				fmt.Printf("Note: the requested copy could not be reached, so the copy with hash %s was downloaded instead\n", result.FetchedHash)
+			}
+			if result.Transform != "" {
+				fmt.Printf("Note: %s\n", result.Transform)
+			}
+			if result.Archive != "" {
+				fmt.Printf("Archive kept at: %s\n", result.Archive)
+			}
+			if result.SizeMismatch != "" {
+				fmt.Printf("Warning: the file may be truncated, %s\n", result.SizeMismatch)
+			}
+			if result.Pages > 0 {
+				fmt.Printf("Pages: %d", result.Pages)
+				if result.TextLayer != "" {
+					fmt.Printf(", text layer: %s", result.TextLayer)
+				}
+				fmt.Println()
+			}
+			for _, warning := range result.QualityWarnings {
+				fmt.Printf("Warning: possibly a bad copy, %s\n", warning)
+			}
+			for _, location := range result.Copies {
+				fmt.Printf("Also delivered to: %s\n", location)
+			}
+			for _, supplement := range result.Supplements {
+				fmt.Printf("Supplementary file downloaded to: %s\n", supplement.Location)
+			}
+			if summary := result.QuotaSummary(); summary != "" {
+				fmt.Println(summary)
+			}
+
 			return nil
 		},
 	}
 
-	mcpCmd := &cobra.Command{
-		Use:   "mcp",
-		Short: "Start the MCP server",
-		Long:  "Start the Model Context Protocol (MCP) server for integration with AI assistants.",
-		Args:  cobra.NoArgs,
+	cmd.Flags().BoolVar(&allowDuplicateFormats, "allow-duplicate-formats", false, "Download even if the library already holds the book in another format")
+	cmd.Flags().BoolVar(&supplements, "supplements", false, "Also download the supplementary files listed on the detail page, such as solutions or companion archives")
+
+	return cmd
+}
+
+func newServeCmd() *cobra.Command {
+	var serveOpts ServeOptions
+
+	cmd := &cobra.Command{
+		Use:     "serve",
+		Aliases: []string{"mcp"},
+		Short:   "Start the MCP server",
+		Long:    "Start the Model Context Protocol (MCP) server for integration with AI assistants.",
+		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			switch serveOpts.Transport {
+			case TransportStdio, TransportHTTP, TransportSSE:
+			default:
+				return fmt.Errorf("unknown transport %q, expected stdio, http, or sse", serveOpts.Transport)
+			}
+			if (serveOpts.TLSCertFile == "") != (serveOpts.TLSKeyFile == "") {
+				return fmt.Errorf("--tls-cert and --tls-key must be set together")
+			}
+			if serveOpts.TLSCertFile != "" && len(serveOpts.AutocertDomains) > 0 {
+				return fmt.Errorf("--tls-cert and --autocert-domain are mutually exclusive")
+			}
+
+			if isService, err := runAsService(serveOpts); isService || err != nil {
+				return err
+			}
+
 			// Exit CLI mode and start MCP server
-			StartMCPServer()
+			StartMCPServer(serveOpts)
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&serveOpts.Transport, "transport", TransportStdio, "Transport to serve MCP over: stdio, http, or sse")
+	cmd.Flags().StringVar(&serveOpts.Addr, "addr", ":8080", "Address to listen on for the http and sse transports")
+	cmd.Flags().StringVar(&serveOpts.TLSCertFile, "tls-cert", "", "Certificate file to serve the http and sse transports over TLS")
+	cmd.Flags().StringVar(&serveOpts.TLSKeyFile, "tls-key", "", "Private key file matching --tls-cert")
+	cmd.Flags().StringSliceVar(&serveOpts.AutocertDomains, "autocert-domain", nil, "Domain to obtain a Let's Encrypt certificate for, can be repeated")
+	cmd.Flags().StringVar(&serveOpts.AutocertCacheDir, "autocert-cache", "autocert-cache", "Directory to cache Let's Encrypt certificates in")
+	cmd.Flags().StringVar(&serveOpts.AutocertEmail, "autocert-email", "", "Contact email for the Let's Encrypt account")
+	cmd.Flags().StringVar(&serveOpts.GRPCAddr, "grpc-addr", "", "Address to serve the gRPC API on, disabled if empty")
 
-	rootCmd.AddCommand(searchCmd)
-	rootCmd.AddCommand(downloadCmd)
-	rootCmd.AddCommand(mcpCmd)
+	cmd.RegisterFlagCompletionFunc("transport", cobra.FixedCompletions(
+		[]string{TransportStdio, TransportHTTP, TransportSSE}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.MarkFlagFilename("tls-cert")
+	cmd.MarkFlagFilename("tls-key")
+	cmd.MarkFlagDirname("autocert-cache")
 
-	if err := fang.Execute(
-		context.Background(),
-		rootCmd,
-		fang.WithVersion(version.GetVersion()),
-	); err != nil {
-		os.Exit(1)
+	return cmd
+}
+
+func newStatusCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the configuration and the account status",
+		Long:  "Show the configured credentials, storage and search backend, and the remaining fast downloads of the account.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Status command called")
+
+			report := GetStatus()
+
+			l.Info("Status command completed successfully")
+
+			if jsonOutput {
+				return printJSON(report)
+			}
+
+			fmt.Println(report.String())
+
+			return nil
+		},
+	}
+}
+
+func newHistoryCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recent searches",
+		Long:  "Show recent searches, newest first, with the results that were downloaded afterwards.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("History command called", zap.Int("limit", limit))
+
+			searches, err := SearchHistory("", historyLimit(limit))
+			if err != nil {
+				l.Error("History command failed", zap.Error(err))
+				return fmt.Errorf("failed to read the search history: %w", err)
+			}
+
+			l.Info("History command completed successfully", zap.Int("resultsCount", len(searches)))
+
+			if jsonOutput {
+				return printJSON(searches)
+			}
+
+			fmt.Print(formatHistory(searches, ""))
+			if len(searches) == 0 {
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", defaultHistoryLimit, "Maximum number of searches to show")
+
+	return cmd
+}
+
+func newReindexCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "reindex",
+		Short: "Add downloaded books to the full-text index",
+		Long:  "Add the EPUB, PDF, and text files in the library index that are missing from the full-text index, such as those downloaded before it was enabled.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Reindex command called")
+
+			added, err := IndexLibrary("")
+			if err != nil {
+				l.Error("Reindex command failed", zap.Error(err))
+				return fmt.Errorf("failed to update the full-text index: %w", err)
+			}
+
+			l.Info("Reindex command completed successfully", zap.Int("added", added))
+
+			if jsonOutput {
+				return printJSON(map[string]int{"added": added})
+			}
+
+			fmt.Printf("Added %d books to the full-text index.\n", added)
+
+			return nil
+		},
+	}
+}
+
+func newReorganizeCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "reorganize-library",
+		Short: "Move downloaded books to the folders of the organization template",
+		Long:  "Move the books in the library index, and their sidecars, to the paths given by the organization template in ANNAS_ORGANIZE, or back to the download directory if it is not set.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Reorganize library command called", zap.Bool("dryRun", dryRun))
+
+			env, err := GetEnv()
+			if err != nil {
+				l.Error("Failed to get environment variables", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
+			}
+
+			relocations, err := ReorganizeLibrary(env, "", dryRun)
+			if err != nil {
+				l.Error("Reorganize library command failed", zap.Error(err))
+				return fmt.Errorf("failed to reorganize the library: %w", err)
+			}
+
+			l.Info("Reorganize library command completed successfully", zap.Int("relocations", len(relocations)))
+
+			if jsonOutput {
+				return printJSON(relocations)
+			}
+
+			fmt.Print(formatRelocations(relocations, dryRun))
+			if len(relocations) == 0 {
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the moves without making them")
+
+	return cmd
+}
+
+func newCleanupCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var (
+		maxSize string
+		maxAge  string
+		dryRun  bool
+		yes     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Remove the oldest downloads to stay within the retention policy",
+		Long:  "List the downloads older than ANNAS_RETENTION_MAX_AGE, then the oldest of the others until the download directory fits in ANNAS_RETENTION_MAX_SIZE, and remove them with their sidecars once confirmed. The library index keeps them, marked as deleted, and downloading them again brings them back.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Cleanup command called",
+				zap.String("maxSize", maxSize),
+				zap.String("maxAge", maxAge),
+				zap.Bool("dryRun", dryRun),
+			)
+
+			env, err := GetEnv()
+			if err != nil {
+				l.Error("Failed to get environment variables", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
+			}
+
+			// GetEnv rejects invalid policies already.
+			policy, _ := retentionPolicy()
+			policy, err = overridePolicy(policy, maxSize, maxAge)
+			if err != nil {
+				return withExitCode(ExitConfig, err)
+			}
+
+			candidates, err := Cleanup(env, "", policy, false)
+			if err != nil {
+				l.Error("Cleanup command failed", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to clean up the library: %w", err))
+			}
+
+			count, size := cleanupTotal(candidates)
+			remove := !dryRun && count > 0
+			if remove && !yes {
+				if !term.IsTerminal(int(os.Stdin.Fd())) {
+					return withExitCode(ExitConfig, errors.New("pass --yes to remove the downloads without a terminal to confirm in"))
+				}
+				fmt.Fprint(os.Stderr, formatCleanup(candidates, false))
+				fmt.Fprintf(os.Stderr, "Remove these %d files, %s in total? [y/N] ", count, formatByteSize(size))
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					fmt.Fprintln(os.Stderr, "Nothing was removed.")
+					return nil
+				}
+			}
+			if remove {
+				if candidates, err = Cleanup(env, "", policy, true); err != nil {
+					l.Error("Cleanup command failed", zap.Error(err))
+					return fmt.Errorf("failed to clean up the library: %w", err)
+				}
+			}
+
+			l.Info("Cleanup command completed successfully", zap.Int("candidates", len(candidates)))
+
+			if jsonOutput {
+				return printJSON(candidates)
+			}
+
+			fmt.Print(formatCleanup(candidates, remove))
+			if len(candidates) == 0 {
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&maxSize, "max-size", "", "Total size the downloads must fit in, such as 20GB, instead of ANNAS_RETENTION_MAX_SIZE")
+	cmd.Flags().StringVar(&maxAge, "max-age", "", "How long downloads are kept, such as 90d, instead of ANNAS_RETENTION_MAX_AGE")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the downloads without removing them")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Remove the downloads without asking")
+
+	return cmd
+}
+
+func newExportNotesCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "export-notes [folder]",
+		Short: "Write a Markdown note for every downloaded book",
+		Long:  "Write the metadata of the books in the library index as Markdown notes with YAML frontmatter, one per book, into the given folder or the one in ANNAS_NOTES_DIR, such as a folder of an Obsidian vault.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env, err := GetEnv()
+			if err != nil {
+				l.Error("Failed to get environment variables", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
+			}
+
+			dir := env.NotesDir
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			if dir == "" {
+				return withExitCode(ExitConfig, errors.New("a folder or ANNAS_NOTES_DIR is required"))
+			}
+
+			l.Info("Export notes command called", zap.String("folder", dir))
+
+			paths, err := ExportNotes(env, "", dir)
+			if err != nil {
+				l.Error("Export notes command failed", zap.Error(err))
+				return fmt.Errorf("failed to export notes: %w", err)
+			}
+
+			l.Info("Export notes command completed successfully", zap.Int("notesCount", len(paths)))
+
+			if jsonOutput {
+				return printJSON(paths)
+			}
+
+			fmt.Printf("Wrote %d notes to %s.\n", len(paths), dir)
+
+			return nil
+		},
+	}
+}
+
+func newVerifyCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var offline bool
+
+	cmd := &cobra.Command{
+		Use:   "verify-local",
+		Short: "Check downloaded files against their hashes",
+		Long:  "Hash the files of the download directory and compare them with the library index. Files that are not in the index are looked up on Anna's Archive, unless --offline is set. Exits with 1 if corrupted or missing files are found.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Verify local command called", zap.Bool("offline", offline))
+
+			env, err := GetEnv()
+			if err != nil {
+				l.Error("Failed to get environment variables", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
+			}
+
+			checks, err := VerifyLocal(cmd.Context(), env, "", offline, progressPrinter(os.Stderr))
+			if err != nil {
+				l.Error("Verify local command failed", zap.Error(err))
+				return fmt.Errorf("failed to verify the downloaded files: %w", err)
+			}
+
+			problems := verifyProblems(checks)
+			l.Info("Verify local command completed successfully",
+				zap.Int("files", len(checks)),
+				zap.Int("problems", problems),
+			)
+
+			if jsonOutput {
+				if err := printJSON(checks); err != nil {
+					return err
+				}
+			} else {
+				fmt.Print(formatFileChecks(checks))
+				if len(checks) == 0 {
+					fmt.Println()
+				}
+			}
+
+			if problems > 0 {
+				return &exitError{code: ExitFailure, err: fmt.Errorf("%d corrupted or missing files", problems), silent: true}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&offline, "offline", false, "Do not look up unknown files on Anna's Archive")
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "import [directory]",
+		Short: "Add an existing collection of books to the library index",
+		Long:  "Hash the files below a directory and look them up by their MD5 hash on Anna's Archive. The books that are found are added to the library index where they are, so that they are not downloaded again.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+
+			l.Info("Import command called", zap.String("directory", dir))
+
+			env, err := GetEnv()
+			if err != nil {
+				l.Error("Failed to get environment variables", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
+			}
+
+			imported, err := ImportLibrary(cmd.Context(), env, dir, progressPrinter(os.Stderr))
+			if err != nil {
+				l.Error("Import command failed",
+					zap.String("directory", dir),
+					zap.Error(err),
+				)
+				return fmt.Errorf("failed to import %s: %w", dir, err)
+			}
+
+			l.Info("Import command completed successfully", zap.Int("files", len(imported)))
+
+			if jsonOutput {
+				return printJSON(imported)
+			}
+
+			fmt.Print(formatImportedFiles(imported))
+			if len(imported) == 0 {
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+}
+
+func newBackupCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "backup [archive.tar.gz]",
+		Short: "Back up the library index, download queue, audit log, and configuration",
+		Long:  "Bundle the library index with the search history, the queued downloads, the audit log, and the .env configuration into a gzipped tarball, to move the server to another machine with restore. The downloaded books are not included, and neither are the secrets kept in the keychain. The archive holds the secrets of the .env file and is only readable by its owner. It is named after the current time if no name is given.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := fmt.Sprintf("annas-mcp-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			l.Info("Backup command called", zap.String("path", path))
+
+			// The backup is written aside, so that a failure does not leave
+			// a truncated archive behind.
+			tmp := path + ".tmp"
+			f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+			if err != nil {
+				return withExitCode(ExitConfig, err)
+			}
+			manifest, err := Backup(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil {
+				err = os.Rename(tmp, path)
+			}
+			if err != nil {
+				os.Remove(tmp)
+				l.Error("Backup command failed", zap.Error(err))
+				return fmt.Errorf("failed to back up to %s: %w", path, err)
+			}
+
+			l.Info("Backup command completed successfully",
+				zap.String("path", path),
+				zap.Int("files", len(manifest.Files)),
+			)
+
+			if jsonOutput {
+				return printJSON(manifest)
+			}
+
+			fmt.Print(formatBackup(manifest, "back up"))
+			fmt.Printf("Backed up to %s\n", path)
+
+			return nil
+		},
+	}
+}
+
+func newRestoreCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "restore [archive.tar.gz]",
+		Short: "Restore a backup made with backup",
+		Long:  "Restore the files of a backup where the server keeps them on this machine. The configuration is restored first, into the .env file of the working directory, and tells where the other files go, unless the environment sets their locations already. Files that exist already are skipped unless --force is set. If the download directory changed, the locations of the books in the library index are moved to the new one, where the books should be copied. The server must not be running during the restore.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			l.Info("Restore command called",
+				zap.String("path", path),
+				zap.Bool("force", overwrite),
+			)
+
+			f, err := os.Open(path)
+			if err != nil {
+				return withExitCode(ExitConfig, err)
+			}
+			defer f.Close()
+
+			// The index and queue of a running server would overwrite the
+			// restored ones.
+			lock, err := lockfile.TryAcquire(serverLockPath())
+			if err != nil {
+				return fmt.Errorf("stop the annas-mcp server using this library first: %w", err)
+			}
+			defer lock.Release()
+
+			manifest, err := Restore(f, overwrite)
+			if err != nil {
+				l.Error("Restore command failed", zap.Error(err))
+				return fmt.Errorf("failed to restore %s: %w", path, err)
+			}
+
+			l.Info("Restore command completed successfully", zap.Int("files", len(manifest.Files)))
+
+			if jsonOutput {
+				return printJSON(manifest)
+			}
+
+			fmt.Print(formatBackup(manifest, "restore"))
+			if manifest.DownloadPath != "" && manifest.DownloadPath != os.Getenv("ANNAS_DOWNLOAD_PATH") {
+				fmt.Printf("Copy the books of %s to %s, then run verify-local to check them.\n", manifest.DownloadPath, os.Getenv("ANNAS_DOWNLOAD_PATH"))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "force", false, "Overwrite the files that exist already")
+
+	return cmd
+}
+
+func newImportGoodreadsCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import-goodreads [export.csv]",
+		Short: "Queue the to-read shelf of a Goodreads or StoryGraph export",
+		Long:  "Read a Goodreads or StoryGraph library export, look up the books of the to-read shelf on Anna's Archive, and add the best match of each book that is not downloaded yet to the download queue. The queued downloads start the next time the server runs over HTTP, which must not be running during the import.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			l.Info("Import Goodreads command called",
+				zap.String("path", path),
+				zap.Bool("dryRun", dryRun),
+			)
+
+			env, err := GetEnv()
+			if err != nil {
+				l.Error("Failed to get environment variables", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to get environment: %w", err))
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return withExitCode(ExitConfig, err)
+			}
+			books, err := ReadGoodreadsExport(f)
+			f.Close()
+			if err != nil {
+				l.Error("Import Goodreads command failed", zap.Error(err))
+				return withExitCode(ExitConfig, fmt.Errorf("failed to read %s: %w", path, err))
+			}
+
+			// The server keeps the queue in memory and overwrites the plan
+			// file, so it must not run while downloads are added to it.
+			if !dryRun {
+				lock, err := lockfile.TryAcquire(serverLockPath())
+				if err != nil {
+					return fmt.Errorf("stop the annas-mcp server using this library first, or queue the downloads through its REST API: %w", err)
+				}
+				defer lock.Release()
+			}
+
+			wishes, err := ImportGoodreads(cmd.Context(), env, books, dryRun)
+			if err != nil {
+				l.Error("Import Goodreads command failed", zap.Error(err))
+				return fmt.Errorf("failed to import %s: %w", path, err)
+			}
+
+			l.Info("Import Goodreads command completed successfully", zap.Int("books", len(wishes)))
+
+			if jsonOutput {
+				return printJSON(wishes)
+			}
+
+			fmt.Print(formatImportedWishes(wishes))
+			if len(wishes) == 0 {
+				fmt.Println()
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Look up the books without queuing them")
+
+	return cmd
+}
+
+func newExportCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var (
+		format string
+		output string
+		search string
+		pages  int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [hash...]",
+		Short: "Export the metadata of books",
+		Long: `Fetch the metadata of the books with the given MD5 hashes and export it as JSON or CSV.
+
+With --search, export the results of a search instead, over as many result pages as --pages allows. Results are written as each page is scraped, so large exports do not have to fit in memory.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if search == "" && len(args) == 0 {
+				return errors.New("requires at least one hash, or a --search term")
+			}
+			if search != "" && len(args) > 0 {
+				return errors.New("hashes cannot be combined with --search")
+			}
+			if pages < 1 {
+				return errors.New("--pages must be at least 1")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Export command called",
+				zap.Strings("bookHashes", args),
+				zap.String("searchTerm", search),
+				zap.String("format", format),
+			)
+
+			var w io.Writer = os.Stdout
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("failed to create output file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			exporter, err := newBookExporter(w, format)
+			if err != nil {
+				return err
+			}
+
+			client := GetClient()
+			count, duplicates := 0, 0
+			// The hashes given as arguments are left out if they were
+			// exported already, like the repeats among the search results.
+			exported := make(map[string]bool)
+			if search != "" {
+				count, duplicates, err = client.SearchPages(cmd.Context(), search, pages, func(book *anna.Book) error {
+					exported[book.Hash] = true
+					return exporter.Write(book)
+				})
+				if err != nil {
+					l.Error("Export command failed", zap.Error(err))
+					return fmt.Errorf("failed to export search results: %w", err)
+				}
+			}
+			for _, hash := range args {
+				if exported[strings.ToLower(hash)] {
+					duplicates++
+					continue
+				}
+				details, err := client.GetBook(cmd.Context(), hash)
+				if err != nil {
+					l.Error("Export command failed",
+						zap.String("bookHash", hash),
+						zap.Error(err),
+					)
+					return fmt.Errorf("failed to get book %s: %w", hash, err)
+				}
+				if err := exporter.Write(details.Book); err != nil {
+					l.Error("Export command failed", zap.Error(err))
+					return fmt.Errorf("failed to export books: %w", err)
+				}
+				exported[details.Book.Hash] = true
+				count++
+			}
+
+			if err := exporter.Close(); err != nil {
+				l.Error("Export command failed", zap.Error(err))
+				return fmt.Errorf("failed to export books: %w", err)
+			}
+
+			l.Info("Export command completed successfully",
+				zap.Int("booksCount", count),
+				zap.Int("duplicatesCount", duplicates),
+			)
+			if duplicates > 0 {
+				fmt.Fprintf(os.Stderr, "Note: %d duplicate results were left out\n", duplicates)
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", ExportJSON, "Export format: json or csv")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the export to instead of the standard output")
+	cmd.Flags().StringVar(&search, "search", "", "Export the results of this search instead of the given hashes")
+	cmd.Flags().IntVar(&pages, "pages", 1, "Number of search result pages to export with --search")
+
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+		[]string{ExportJSON, ExportCSV}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.MarkFlagFilename("output")
+
+	return cmd
+}
+
+func newDoctorCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that the tool works against the live site",
+		Long:  "Run a known search, validate the secret key and check that the download path is writable, then print a pass/fail report.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Doctor command called")
+
+			checks := RunDoctor()
+
+			failed := 0
+			for _, check := range checks {
+				if !check.Passed {
+					failed++
+				}
+			}
+
+			if jsonOutput {
+				if err := printJSON(checks); err != nil {
+					return err
+				}
+			} else {
+				for _, check := range checks {
+					status := "PASS"
+					if !check.Passed {
+						status = "FAIL"
+					}
+					fmt.Printf("[%s] %s: %s\n", status, check.Name, check.Details)
+				}
+			}
+
+			if failed > 0 {
+				l.Error("Doctor command found failing checks", zap.Int("failedCount", failed))
+				return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+			}
+
+			l.Info("Doctor command completed successfully")
+
+			return nil
+		},
 	}
 }
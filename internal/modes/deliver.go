@@ -0,0 +1,114 @@
+package modes
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// deliveryTargets reads the directories of ANNAS_DELIVER_TO, which are
+// separated like the entries of PATH.
+func deliveryTargets() []string {
+	targets := make([]string, 0)
+	for _, target := range filepath.SplitList(os.Getenv("ANNAS_DELIVER_TO")) {
+		if target = strings.TrimSpace(target); target != "" {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+// deliver places a downloaded file and its sidecars in every delivery
+// target, under the path they have below the download directory, and returns
+// the locations of the delivered files. Hard links are used where possible,
+// so that deliveries take no space of their own, and copies otherwise, for
+// example on the mounted storage of an e-reader. Failures are logged, as the
+// download itself succeeded.
+func deliver(env *Env, location string) []string {
+	l := logger.GetLogger()
+
+	rel, err := filepath.Rel(env.DownloadPath, location)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(location)
+	}
+
+	delivered := make([]string, 0, len(env.Deliveries))
+	for _, target := range env.Deliveries {
+		dest := filepath.Join(target, rel)
+		if err := linkOrCopy(location, dest); err != nil {
+			l.Warn("Failed to deliver download",
+				zap.String("location", location),
+				zap.String("target", target),
+				zap.Error(err),
+			)
+			continue
+		}
+		delivered = append(delivered, dest)
+
+		base := strings.TrimSuffix(location, filepath.Ext(location))
+		destBase := strings.TrimSuffix(dest, filepath.Ext(dest))
+		for _, sidecar := range []string{anna.SidecarOPF, anna.SidecarJSON} {
+			if _, err := os.Stat(base + "." + sidecar); err != nil {
+				continue
+			}
+			if err := linkOrCopy(base+"."+sidecar, destBase+"."+sidecar); err != nil {
+				l.Warn("Failed to deliver sidecar",
+					zap.String("location", base+"."+sidecar),
+					zap.String("target", target),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	return delivered
+}
+
+// linkOrCopy replaces dest by a hard link to src, or by a copy of it if the
+// two are on different filesystems or the filesystem has no hard links.
+func linkOrCopy(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	if srcInfo, err := os.Stat(src); err != nil {
+		return err
+	} else if destInfo, err := os.Stat(dest); err == nil && os.SameFile(srcInfo, destInfo) {
+		return nil
+	}
+
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".annas-delivery-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dest)
+}
@@ -0,0 +1,160 @@
+package modes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// doctorQuery is a search that is expected to always return results, so an
+// empty or partially parsed result set points to a site layout change.
+const doctorQuery = "Pride and Prejudice Jane Austen"
+
+var md5Pattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+type DoctorCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Details string `json:"details"`
+}
+
+func RunDoctor() []DoctorCheck {
+	l := logger.GetLogger()
+
+	checks := []DoctorCheck{checkSearch()}
+
+	secretKey, err := secretKeySecret.lookup()
+	downloadPath := os.Getenv("ANNAS_DOWNLOAD_PATH")
+
+	switch {
+	case err != nil:
+		checks = append(checks, DoctorCheck{
+			Name:    "Secret key",
+			Details: err.Error(),
+		})
+	case secretKey == "" && accountCookieSecret.value() != "":
+		checks = append(checks, DoctorCheck{
+			Name:    "Secret key",
+			Passed:  true,
+			Details: "ANNAS_SECRET_KEY is not set, downloads use ANNAS_ACCOUNT_COOKIE",
+		})
+	case secretKey == "":
+		checks = append(checks, DoctorCheck{
+			Name:    "Secret key",
+			Details: "neither ANNAS_SECRET_KEY nor ANNAS_ACCOUNT_COOKIE is set",
+		})
+	default:
+		checks = append(checks, checkSecretKey(secretKey))
+	}
+
+	if downloadPath == "" {
+		checks = append(checks, DoctorCheck{
+			Name:    "Download path",
+			Details: "ANNAS_DOWNLOAD_PATH is not set",
+		})
+	} else {
+		checks = append(checks, checkDownloadPath(downloadPath))
+	}
+
+	for _, check := range checks {
+		l.Info("Doctor check finished",
+			zap.String("name", check.Name),
+			zap.Bool("passed", check.Passed),
+			zap.String("details", check.Details),
+		)
+	}
+
+	return checks
+}
+
+func checkSearch() DoctorCheck {
+	check := DoctorCheck{Name: "Search selectors"}
+
+	books, err := anna.New().Search(context.Background(), doctorQuery)
+	if err != nil {
+		check.Details = fmt.Sprintf("search failed: %v", err)
+		return check
+	}
+	if len(books) == 0 {
+		check.Details = fmt.Sprintf("no results for %q, the result selector may be outdated", doctorQuery)
+		return check
+	}
+
+	book := books[0]
+	missing := make([]string, 0)
+	if book.Title == "" {
+		missing = append(missing, "title")
+	}
+	if book.Authors == "" {
+		missing = append(missing, "authors")
+	}
+	if book.Language == "" {
+		missing = append(missing, "language")
+	}
+	if book.Format == "" {
+		missing = append(missing, "format")
+	}
+	if book.Size == "" {
+		missing = append(missing, "size")
+	}
+	if book.URL == "" {
+		missing = append(missing, "url")
+	}
+	if !md5Pattern.MatchString(book.Hash) {
+		missing = append(missing, "hash")
+	}
+
+	if len(missing) > 0 {
+		check.Details = fmt.Sprintf("%d results, but the first one is missing: %s", len(books), strings.Join(missing, ", "))
+		return check
+	}
+
+	check.Passed = true
+	check.Details = fmt.Sprintf("%d results, all fields populated", len(books))
+	return check
+}
+
+func checkSecretKey(secretKey string) DoctorCheck {
+	check := DoctorCheck{Name: "Secret key"}
+
+	if err := anna.New(anna.WithSecretKey(secretKey)).ValidateSecretKey(context.Background()); err != nil {
+		check.Details = fmt.Sprintf("key was not accepted: %v", err)
+		return check
+	}
+
+	check.Passed = true
+	check.Details = "key accepted by the fast download API"
+	return check
+}
+
+func checkDownloadPath(downloadPath string) DoctorCheck {
+	check := DoctorCheck{Name: "Download path"}
+
+	info, err := os.Stat(downloadPath)
+	if err != nil {
+		check.Details = fmt.Sprintf("cannot access %s: %v", downloadPath, err)
+		return check
+	}
+	if !info.IsDir() {
+		check.Details = fmt.Sprintf("%s is not a directory", downloadPath)
+		return check
+	}
+
+	probe, err := os.CreateTemp(downloadPath, ".annas-mcp-doctor-*")
+	if err != nil {
+		check.Details = fmt.Sprintf("%s is not writable: %v", downloadPath, err)
+		return check
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	check.Passed = true
+	check.Details = fmt.Sprintf("%s is writable", downloadPath)
+	return check
+}
@@ -2,27 +2,73 @@ package modes
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/iosifache/annas-mcp/internal/fixtures"
+	"github.com/iosifache/annas-mcp/internal/i18n"
 	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/notify"
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/pkg/anna"
 	"go.uber.org/zap"
 )
 
 type Env struct {
-	SecretKey    string `json:"secret"`
-	DownloadPath string `json:"download_path"`
+	SecretKey     string         `json:"secret"`
+	AccountCookie string         `json:"-"`
+	DownloadPath  string         `json:"download_path"`
+	Storage       storage.Config `json:"storage"`
+	HTTPScope     string         `json:"http_scope"`
+	UserQuota     int64          `json:"user_quota"`
+	MaxRate       int64          `json:"max_download_rate"`
+	Filenames     string         `json:"filenames"`
+	Sidecars      []string       `json:"sidecars"`
+	EmbedEPUB     bool           `json:"embed_epub_metadata"`
+	UnwrapZips    bool           `json:"unwrap_archives"`
+	Politeness    string         `json:"politeness"`
+	FullText      bool           `json:"fulltext_index"`
+	Organization  string         `json:"organization"`
+	Deliveries    []string       `json:"deliver_to"`
+	NotesDir      string         `json:"notes_dir"`
+	Fallbacks     int            `json:"download_fallbacks"`
+	// SessionDownloads and SessionBytes limit the downloads of each MCP
+	// session, zero means no limit.
+	SessionDownloads int   `json:"session_max_downloads"`
+	SessionBytes     int64 `json:"session_max_bytes"`
 }
 
 func GetEnv() (*Env, error) {
 	l := logger.GetLogger()
 
-	secretKey := os.Getenv("ANNAS_SECRET_KEY")
+	secretKey, err := secretKeySecret.lookup()
+	if err != nil {
+		l.Error("Invalid secret key", zap.Error(err))
+		return nil, err
+	}
+	accountCookie, err := accountCookieSecret.lookup()
+	if err != nil {
+		l.Error("Invalid account cookie", zap.Error(err))
+		return nil, err
+	}
 	downloadPath := os.Getenv("ANNAS_DOWNLOAD_PATH")
-	if secretKey == "" || downloadPath == "" {
-		err := errors.New("ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables must be set")
+	backend := os.Getenv("ANNAS_STORAGE")
+
+	// Only the local backend needs a download path, remote backends are
+	// configured through their own variables. Members without an API key can
+	// authenticate with their account cookie instead.
+	isLocal := backend == "" || backend == storage.BackendLocal
+	if (secretKey == "" && accountCookie == "") || (isLocal && downloadPath == "") {
+		err := errors.New("ANNAS_SECRET_KEY (or ANNAS_ACCOUNT_COOKIE) and ANNAS_DOWNLOAD_PATH environment variables must be set")
 
 		l.Error("Environment variables not set",
-			zap.String("ANNAS_SECRET_KEY", secretKey),
+			zap.Bool("secretKeySet", secretKey != ""),
 			zap.String("ANNAS_DOWNLOAD_PATH", downloadPath),
 			zap.Error(err),
 		)
@@ -30,8 +76,567 @@ func GetEnv() (*Env, error) {
 		return nil, err
 	}
 
+	httpScope := os.Getenv("ANNAS_HTTP_SCOPE")
+	switch httpScope {
+	case "", ScopeNone, ScopeSession, ScopeKey:
+	default:
+		err := fmt.Errorf("invalid ANNAS_HTTP_SCOPE: %s", httpScope)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	userQuota, err := parseByteSize(os.Getenv("ANNAS_USER_QUOTA"))
+	if err != nil {
+		err = fmt.Errorf("invalid ANNAS_USER_QUOTA: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	maxRate, err := parseByteSize(strings.TrimSuffix(strings.TrimSpace(os.Getenv("ANNAS_MAX_DOWNLOAD_RATE")), "/s"))
+	if err != nil {
+		err = fmt.Errorf("invalid ANNAS_MAX_DOWNLOAD_RATE: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	filenames := os.Getenv("ANNAS_FILENAMES")
+	switch filenames {
+	case "", anna.FilenameUnicode, anna.FilenameASCII:
+	default:
+		err := fmt.Errorf("invalid ANNAS_FILENAMES: %s", filenames)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	sidecars := make([]string, 0)
+	for _, sidecar := range strings.Split(os.Getenv("ANNAS_SIDECARS"), ",") {
+		switch sidecar = strings.ToLower(strings.TrimSpace(sidecar)); sidecar {
+		case "":
+		case anna.SidecarOPF, anna.SidecarJSON:
+			sidecars = append(sidecars, sidecar)
+		default:
+			err := fmt.Errorf("invalid ANNAS_SIDECARS entry: %s", sidecar)
+			l.Error("Invalid environment variable", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	politeness := os.Getenv("ANNAS_POLITENESS")
+	if politeness != "" {
+		if _, err := anna.PolitenessProfile(politeness); err != nil {
+			err = fmt.Errorf("invalid ANNAS_POLITENESS: %w", err)
+			l.Error("Invalid environment variable", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	if _, err := i18n.Parse(os.Getenv("ANNAS_LANGUAGE")); err != nil {
+		err = fmt.Errorf("invalid ANNAS_LANGUAGE: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, _, err := resultTemplate(); err != nil {
+		err = fmt.Errorf("invalid ANNAS_RESULT_TEMPLATE: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := notificationSinks(); err != nil {
+		err = fmt.Errorf("invalid ANNAS_NOTIFY: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := retentionPolicy(); err != nil {
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := transliterations(); err != nil {
+		err = fmt.Errorf("invalid ANNAS_TRANSLITERATION: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := headlessOperations(); err != nil {
+		err = fmt.Errorf("invalid ANNAS_HEADLESS: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := hostBudgets(); err != nil {
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := cacheTTL(); err != nil {
+		err = fmt.Errorf("invalid ANNAS_CACHE_TTL: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	organization := os.Getenv("ANNAS_ORGANIZE")
+	if organization != "" {
+		if organization, err = anna.OrganizationTemplate(organization); err != nil {
+			err = fmt.Errorf("invalid ANNAS_ORGANIZE: %w", err)
+			l.Error("Invalid environment variable", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	fallbacks := defaultDownloadFallbacks
+	if value := os.Getenv("ANNAS_DOWNLOAD_FALLBACKS"); value != "" {
+		if fallbacks, err = strconv.Atoi(value); err != nil || fallbacks < 0 {
+			err = fmt.Errorf("invalid ANNAS_DOWNLOAD_FALLBACKS: %s", value)
+			l.Error("Invalid environment variable", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	sessionDownloads := 0
+	if value := os.Getenv("ANNAS_SESSION_MAX_DOWNLOADS"); value != "" {
+		if sessionDownloads, err = strconv.Atoi(value); err != nil || sessionDownloads < 0 {
+			err = fmt.Errorf("invalid ANNAS_SESSION_MAX_DOWNLOADS: %s", value)
+			l.Error("Invalid environment variable", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	sessionBytes, err := parseByteSize(os.Getenv("ANNAS_SESSION_MAX_BYTES"))
+	if err != nil {
+		err = fmt.Errorf("invalid ANNAS_SESSION_MAX_BYTES: %w", err)
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
+	deliveries := deliveryTargets()
+	if len(deliveries) > 0 && !isLocal {
+		err := errors.New("ANNAS_DELIVER_TO requires the local storage backend")
+		l.Error("Invalid environment variable", zap.Error(err))
+		return nil, err
+	}
+
 	return &Env{
-		SecretKey:    secretKey,
-		DownloadPath: downloadPath,
+		SecretKey:     secretKey,
+		AccountCookie: accountCookie,
+		DownloadPath:  downloadPath,
+		Storage: storage.Config{
+			Backend:        backend,
+			LocalPath:      downloadPath,
+			S3Endpoint:     os.Getenv("ANNAS_S3_ENDPOINT"),
+			S3Region:       os.Getenv("ANNAS_S3_REGION"),
+			S3Bucket:       os.Getenv("ANNAS_S3_BUCKET"),
+			S3Prefix:       os.Getenv("ANNAS_S3_PREFIX"),
+			S3AccessKey:    os.Getenv("ANNAS_S3_ACCESS_KEY"),
+			S3SecretKey:    os.Getenv("ANNAS_S3_SECRET_KEY"),
+			S3UseSSL:       os.Getenv("ANNAS_S3_INSECURE") != "true",
+			WebDAVURL:      os.Getenv("ANNAS_WEBDAV_URL"),
+			WebDAVUsername: os.Getenv("ANNAS_WEBDAV_USERNAME"),
+			WebDAVPassword: os.Getenv("ANNAS_WEBDAV_PASSWORD"),
+		},
+		HTTPScope:    httpScope,
+		UserQuota:    userQuota,
+		MaxRate:      maxRate,
+		Filenames:    filenames,
+		Sidecars:     sidecars,
+		EmbedEPUB:    os.Getenv("ANNAS_EMBED_EPUB_METADATA") == "true",
+		UnwrapZips:   os.Getenv("ANNAS_UNWRAP_ARCHIVES") == "true",
+		Politeness:   politeness,
+		FullText:     os.Getenv("ANNAS_FULLTEXT_INDEX") == "true",
+		Organization: organization,
+		Deliveries:   deliveries,
+		NotesDir:     os.Getenv("ANNAS_NOTES_DIR"),
+		Fallbacks:    fallbacks,
+
+		SessionDownloads: sessionDownloads,
+		SessionBytes:     sessionBytes,
 	}, nil
 }
+
+// parseByteSize parses sizes such as "500MB", "1.5 GB" or "1024". Units are
+// powers of 1024 and an empty string yields zero.
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+
+	return int64(number * float64(multiplier)), nil
+}
+
+// formatByteSize renders a size in bytes with the units parseByteSize
+// accepts.
+func formatByteSize(size int64) string {
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+	} {
+		if size >= unit.multiplier {
+			return fmt.Sprintf("%.1f %s", float64(size)/float64(unit.multiplier), unit.suffix)
+		}
+	}
+
+	return fmt.Sprintf("%d B", size)
+}
+
+// DownloadConfig returns the download settings configured in the
+// environment.
+func (e *Env) DownloadConfig() anna.DownloadConfig {
+	return anna.DownloadConfig{
+		MaxRate:          e.MaxRate,
+		FilenameEncoding: e.Filenames,
+		Sidecars:         e.Sidecars,
+		EmbedMetadata:    e.EmbedEPUB,
+		UnwrapArchives:   e.UnwrapZips,
+		Organization:     e.Organization,
+	}
+}
+
+// Client returns a client with the credentials and download settings
+// configured in the environment.
+func (e *Env) Client() *anna.Client {
+	return anna.New(
+		anna.WithSecretKey(e.SecretKey),
+		anna.WithAccountCookie(e.AccountCookie),
+		anna.WithSearchAPI(os.Getenv("ANNAS_SEARCH_API") == "true"),
+		anna.WithDownloadConfig(e.DownloadConfig()),
+		politenessOption(e.Politeness),
+		budgetsOption(),
+		fetcherOption(),
+		anna.WithPreferences(preferencesFromEnv()),
+		anna.WithMirrors(searchMirrors()),
+		cacheOption(),
+		fixturesOption(),
+	)
+}
+
+// GetClient returns a client configured from the environment. It does not
+// require the download settings, so searching works without them.
+func GetClient() *anna.Client {
+	return anna.New(
+		anna.WithSecretKey(secretKeySecret.value()),
+		anna.WithAccountCookie(accountCookieSecret.value()),
+		anna.WithSearchAPI(os.Getenv("ANNAS_SEARCH_API") == "true"),
+		politenessOption(os.Getenv("ANNAS_POLITENESS")),
+		budgetsOption(),
+		fetcherOption(),
+		anna.WithPreferences(preferencesFromEnv()),
+		anna.WithMirrors(searchMirrors()),
+		cacheOption(),
+		fixturesOption(),
+	)
+}
+
+// preferencesFromEnv reads the comma-separated ANNAS_PREFERRED_FORMATS and
+// ANNAS_PREFERRED_LANGUAGES lists.
+func preferencesFromEnv() anna.Preferences {
+	return anna.Preferences{
+		Formats:   splitList(os.Getenv("ANNAS_PREFERRED_FORMATS")),
+		Languages: splitList(os.Getenv("ANNAS_PREFERRED_LANGUAGES")),
+	}
+}
+
+// searchMirrors reads the comma-separated ANNAS_SEARCH_MIRRORS list of
+// mirrors that searches race against the main site, defaulting to HTTPS for
+// bare host names.
+func searchMirrors() []string {
+	mirrors := make([]string, 0)
+	for _, mirror := range strings.Split(os.Getenv("ANNAS_SEARCH_MIRRORS"), ",") {
+		mirror = strings.TrimSpace(mirror)
+		if mirror == "" {
+			continue
+		}
+		if !strings.Contains(mirror, "://") {
+			mirror = "https://" + mirror
+		}
+		mirrors = append(mirrors, mirror)
+	}
+
+	return mirrors
+}
+
+// searchFilter returns the filter applied to search results, excluding the
+// given formats, or those of ANNAS_EXCLUDE_FORMATS if none are given, and
+// the results published outside the year range or sized outside the size
+// range, given as sizes such as "500KB" or "2GB".
+func searchFilter(excludeFormats []string, yearFrom, yearTo int, minSize, maxSize string) (*anna.ResultFilter, error) {
+	if len(excludeFormats) == 0 {
+		excludeFormats = splitList(os.Getenv("ANNAS_EXCLUDE_FORMATS"))
+	}
+
+	filter := &anna.ResultFilter{
+		ExcludeFormats: anna.NormalizeFormats(excludeFormats),
+		YearFrom:       yearFrom,
+		YearTo:         yearTo,
+	}
+
+	var err error
+	if filter.MinSize, err = parseByteSize(minSize); err != nil {
+		return nil, fmt.Errorf("invalid min_size: %w", err)
+	}
+	if filter.MaxSize, err = parseByteSize(maxSize); err != nil {
+		return nil, fmt.Errorf("invalid max_size: %w", err)
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// transliterations reads the comma-separated ANNAS_TRANSLITERATION list of
+// the schemes that searches across scripts romanize queries with.
+func transliterations() ([]string, error) {
+	return anna.ParseTransliterations(os.Getenv("ANNAS_TRANSLITERATION"))
+}
+
+// scriptVariants returns the queries of a search across scripts for q, whose
+// title and author are also searched as originalTitle and originalAuthor if
+// they are given. Invalid transliterations are rejected by GetEnv, and
+// replaced by the default with a warning here so that searches keep working.
+func scriptVariants(q anna.FieldQuery, originalTitle, originalAuthor string) []anna.FieldQuery {
+	schemes, err := transliterations()
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring invalid transliterations", zap.Error(err))
+		schemes = []string{anna.TransliterationDefault}
+	}
+
+	var original anna.FieldQuery
+	if originalTitle != "" || originalAuthor != "" {
+		original = q
+		if originalTitle != "" {
+			original.Title = originalTitle
+		}
+		if originalAuthor != "" {
+			original.Author = originalAuthor
+		}
+	}
+
+	return anna.ScriptVariants(q, original, schemes)
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// politenessOption applies the named politeness profile. Unknown names are
+// rejected by GetEnv, and ignored with a warning here so that searches keep
+// working.
+func politenessOption(name string) anna.Option {
+	if name == "" {
+		return func(*anna.Client) {}
+	}
+
+	p, err := anna.PolitenessProfile(name)
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring politeness profile", zap.Error(err))
+		return func(*anna.Client) {}
+	}
+
+	return anna.WithPoliteness(p)
+}
+
+// interfaceLanguage returns the language set in ANNAS_LANGUAGE for the tool
+// descriptions and messages. Unsupported languages are rejected by GetEnv,
+// and fall back to English with a warning here.
+func interfaceLanguage() string {
+	lang, err := i18n.Parse(os.Getenv("ANNAS_LANGUAGE"))
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring interface language", zap.Error(err))
+		return i18n.English
+	}
+
+	return lang
+}
+
+// tr formats a message shown to users in the interface language.
+func tr(format string, args ...any) string {
+	return i18n.Sprintf(interfaceLanguage(), format, args...)
+}
+
+// notificationSinks reads the comma-separated ANNAS_NOTIFY list of sinks that
+// are told when the queued downloads are done, such as
+// "ntfy:my-topic,discord:https://discord.com/api/webhooks/...".
+func notificationSinks() (notify.Sinks, error) {
+	specs := make([]string, 0)
+	for _, spec := range strings.Split(os.Getenv("ANNAS_NOTIFY"), ",") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	token, err := telegramTokenSecret.lookup()
+	if err != nil {
+		return nil, err
+	}
+
+	return notify.New(notify.Config{Sinks: specs, TelegramToken: token})
+}
+
+// defaultCacheTTL is how long scraped pages are cached when ANNAS_CACHE_DIR
+// is set without ANNAS_CACHE_TTL.
+const defaultCacheTTL = time.Hour
+
+// cacheTTL reads ANNAS_CACHE_TTL, where zero keeps cached pages forever.
+func cacheTTL() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("ANNAS_CACHE_TTL"))
+	if value == "" {
+		return defaultCacheTTL, nil
+	}
+
+	return parseDuration(value)
+}
+
+// hostBudgets reads the budgets of the classes of hosts from
+// ANNAS_ARCHIVE_BUDGET, ANNAS_PARTNER_BUDGET, and ANNAS_IPFS_BUDGET, keeping
+// the default budget of the classes that are not set.
+func hostBudgets() (anna.Budgets, error) {
+	budgets := anna.DefaultBudgets
+	for _, setting := range []struct {
+		name   string
+		budget *anna.Budget
+	}{
+		{"ANNAS_ARCHIVE_BUDGET", &budgets.Archive},
+		{"ANNAS_PARTNER_BUDGET", &budgets.Partner},
+		{"ANNAS_IPFS_BUDGET", &budgets.IPFS},
+	} {
+		name, budget := setting.name, setting.budget
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := anna.ParseBudget(value)
+		if err != nil {
+			return anna.DefaultBudgets, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		*budget = parsed
+	}
+
+	return budgets, nil
+}
+
+// budgetsOption applies the budgets of the classes of hosts. Invalid budgets
+// are rejected by GetEnv, and replaced by the defaults with a warning here so
+// that searches keep working.
+func budgetsOption() anna.Option {
+	budgets, err := hostBudgets()
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring invalid host budgets", zap.Error(err))
+	}
+
+	return anna.WithBudgets(budgets)
+}
+
+// browserFetcher renders the pages of the operations set in ANNAS_HEADLESS,
+// for all clients of the process, so that the browser is started only once.
+var browserFetcher = sync.OnceValue(func() *anna.BrowserFetcher {
+	return &anna.BrowserFetcher{ExecPath: os.Getenv("ANNAS_CHROME_PATH")}
+})
+
+// headlessOperations reads the comma-separated ANNAS_HEADLESS list of the
+// operations whose pages are rendered in a headless browser, where "all"
+// stands for all of them.
+func headlessOperations() ([]string, error) {
+	operations := splitList(os.Getenv("ANNAS_HEADLESS"))
+	if slices.Contains(operations, "all") {
+		return anna.Operations, nil
+	}
+	for _, operation := range operations {
+		if !slices.Contains(anna.Operations, operation) {
+			return nil, fmt.Errorf("unknown operation %q, expected %s, or all", operation, strings.Join(anna.Operations, ", "))
+		}
+	}
+
+	return operations, nil
+}
+
+// fetcherOption renders the pages of the operations set in ANNAS_HEADLESS in
+// a headless browser. Unknown operations are rejected by GetEnv, and ignored
+// with a warning here so that searches keep working.
+func fetcherOption() anna.Option {
+	operations, err := headlessOperations()
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring headless operations", zap.Error(err))
+	}
+	if len(operations) == 0 {
+		return func(*anna.Client) {}
+	}
+
+	fetcher := browserFetcher()
+	return func(c *anna.Client) {
+		for _, operation := range operations {
+			anna.WithFetcher(operation, fetcher)(c)
+		}
+	}
+}
+
+// cacheOption caches the scraped pages in ANNAS_CACHE_DIR. An invalid
+// ANNAS_CACHE_TTL is rejected by GetEnv, and replaced by the default with a
+// warning here so that searches keep working.
+func cacheOption() anna.Option {
+	dir := os.Getenv("ANNAS_CACHE_DIR")
+	if dir == "" {
+		return func(*anna.Client) {}
+	}
+
+	ttl, err := cacheTTL()
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring invalid cache TTL", zap.Error(err))
+		ttl = defaultCacheTTL
+	}
+
+	return anna.WithCache(dir, ttl)
+}
+
+// fixturesOption records the HTTP exchanges to the directory in
+// ANNAS_HTTP_RECORD, or answers requests from the fixtures in
+// ANNAS_HTTP_REPLAY without reaching the site.
+func fixturesOption() anna.Option {
+	if dir := os.Getenv("ANNAS_HTTP_REPLAY"); dir != "" {
+		return anna.WithHTTPClient(&http.Client{Transport: fixtures.NewReplayer(dir)})
+	}
+	if dir := os.Getenv("ANNAS_HTTP_RECORD"); dir != "" {
+		return anna.WithHTTPClient(&http.Client{Transport: fixtures.NewRecorder(dir, anna.SharedTransport)})
+	}
+
+	return func(*anna.Client) {}
+}
@@ -0,0 +1,87 @@
+package modes
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/charmbracelet/fang"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+)
+
+// Exit codes of the CLI, so that scripts can branch on the cause of a
+// failure.
+const (
+	ExitOK            = 0
+	ExitFailure       = 1
+	ExitNoResults     = 2
+	ExitQuotaExceeded = 3
+	ExitNetwork       = 4
+	ExitConfig        = 5
+	ExitThrottled     = 6
+)
+
+// exitError attaches an exit code to an error. Silent errors are not printed,
+// for outcomes that were already reported to the user, such as an empty
+// search.
+type exitError struct {
+	code   int
+	err    error
+	silent bool
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+var errNoResults = &exitError{code: ExitNoResults, err: errors.New("no results"), silent: true}
+
+// exitCode maps an error returned by a command to the exit code of the
+// process.
+func exitCode(err error) int {
+	var (
+		ee          *exitError
+		statusErr   *anna.StatusError
+		cooldownErr *anna.CooldownError
+		netErr      net.Error
+	)
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &ee):
+		return ee.code
+	case errors.Is(err, anna.ErrBookNotFound):
+		return ExitNoResults
+	case errors.Is(err, anna.ErrNoDownloadsLeft), errors.Is(err, ErrQuotaExceeded):
+		return ExitQuotaExceeded
+	case errors.As(err, &cooldownErr):
+		return ExitThrottled
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests:
+		return ExitThrottled
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= http.StatusInternalServerError:
+		return ExitNetwork
+	case errors.As(err, &netErr):
+		return ExitNetwork
+	default:
+		return ExitFailure
+	}
+}
+
+func handleError(w io.Writer, styles fang.Styles, err error) {
+	var ee *exitError
+	if errors.As(err, &ee) && ee.silent {
+		return
+	}
+
+	fang.DefaultErrorHandler(w, styles, err)
+}
@@ -0,0 +1,81 @@
+package modes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iosifache/annas-mcp/pkg/anna"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"other", errors.New("boom"), ExitFailure},
+		{"no results", errNoResults, ExitNoResults},
+		{"explicit code", withExitCode(ExitConfig, errors.New("bad config")), ExitConfig},
+		{"book not found", fmt.Errorf("lookup: %w", anna.ErrBookNotFound), ExitNoResults},
+		{"no downloads left", anna.ErrNoDownloadsLeft, ExitQuotaExceeded},
+		{"quota exceeded", ErrQuotaExceeded, ExitQuotaExceeded},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ExitNetwork},
+		{"server error", &anna.StatusError{Endpoint: "search page", StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, ExitNetwork},
+		{"unavailable", fmt.Errorf("download: %w", &anna.StatusError{Endpoint: "file download", StatusCode: http.StatusServiceUnavailable}), ExitNetwork},
+		{"too many requests", &anna.StatusError{Endpoint: "fast download page", StatusCode: http.StatusTooManyRequests}, ExitThrottled},
+		{"cooldown", &anna.CooldownError{Host: "annas-archive.org", Until: time.Now().Add(time.Hour)}, ExitThrottled},
+		{"client error", &anna.StatusError{Endpoint: "cover", StatusCode: http.StatusForbidden}, ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+type statusRoundTripper struct {
+	status int
+}
+
+func (rt statusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: rt.status,
+		Status:     fmt.Sprintf("%d %s", rt.status, http.StatusText(rt.status)),
+		Header:     http.Header{"Content-Type": []string{"text/html"}},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+func TestExitCodeOfScrapingErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{http.StatusBadGateway, ExitNetwork},
+		{http.StatusTooManyRequests, ExitThrottled},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			client := anna.New(
+				anna.WithHTTPClient(&http.Client{Transport: statusRoundTripper{status: tt.status}}),
+				anna.WithMirrors([]string{"annas-archive.test"}),
+			)
+			_, err := client.GetBook(context.Background(), "0123456789abcdef0123456789abcdef")
+			if err == nil {
+				t.Fatal("GetBook succeeded on an error status")
+			}
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package modes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/iosifache/annas-mcp/pkg/anna"
+)
+
+// Export formats.
+const (
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+)
+
+var exportColumns = []string{"hash", "title", "authors", "publisher", "language", "format", "size", "year", "url"}
+
+// ExportBooks writes the metadata of the given books in the given format.
+func ExportBooks(w io.Writer, books []*anna.Book, format string) error {
+	exporter, err := newBookExporter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, b := range books {
+		if err := exporter.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return exporter.Close()
+}
+
+// bookExporter writes books one at a time, so that exports of many search
+// pages do not have to hold all of them.
+type bookExporter struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+	count  int
+}
+
+func newBookExporter(w io.Writer, format string) (*bookExporter, error) {
+	e := &bookExporter{w: w, format: format}
+	switch format {
+	case ExportJSON:
+	case ExportCSV:
+		e.csv = csv.NewWriter(w)
+		if err := e.csv.Write(exportColumns); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown export format %q, expected json or csv", format)
+	}
+
+	return e, nil
+}
+
+// Write exports a book. JSON exports are written as an indented array, whose
+// elements are separated as they come.
+func (e *bookExporter) Write(b *anna.Book) error {
+	defer func() { e.count++ }()
+
+	if e.csv != nil {
+		record := []string{b.Hash, b.Title, b.Authors, b.Publisher, b.Language, b.Format, b.Size, b.Year, b.URL}
+		return e.csv.Write(record)
+	}
+
+	data, err := json.MarshalIndent(b, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if e.count == 0 {
+		separator = "[\n  "
+	}
+	_, err = fmt.Fprintf(e.w, "%s%s", separator, data)
+	return err
+}
+
+// Close ends the export, closing the JSON array or flushing the CSV records.
+func (e *bookExporter) Close() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
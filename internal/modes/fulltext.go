@@ -0,0 +1,173 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/fulltext"
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// errFullTextDisabled is returned when the full-text index is used without
+// being enabled.
+var errFullTextDisabled = errors.New("the full-text index is disabled, set ANNAS_FULLTEXT_INDEX=true to enable it")
+
+// defaultContentLimit is the number of books returned by a content search
+// when no limit is given.
+const defaultContentLimit = 10
+
+// fullTextPath returns the location of the full-text index, a directory next
+// to the library index.
+func fullTextPath(downloadPath string) string {
+	return filepath.Join(filepath.Dir(libraryPath(downloadPath)), ".annas-fulltext")
+}
+
+// indexContent adds the text of a downloaded book to the full-text index.
+// Only files on the local filesystem can be indexed. Failures are logged, as
+// the index is not worth failing a download over.
+func indexContent(entry library.Entry) {
+	l := logger.GetLogger()
+
+	if !fulltext.Supported(entry.Format) {
+		return
+	}
+	if _, err := os.Stat(entry.Location); err != nil {
+		return
+	}
+
+	if err := addToFullText(entry); err != nil {
+		l.Warn("Failed to add book to the full-text index",
+			zap.String("bookHash", entry.Hash),
+			zap.String("location", entry.Location),
+			zap.Error(err),
+		)
+	}
+}
+
+func addToFullText(entry library.Entry) error {
+	text, err := fulltext.ExtractText(entry.Location, entry.Format)
+	if err != nil {
+		return err
+	}
+
+	idx, err := fulltext.Open(fullTextPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return err
+	}
+
+	return idx.Add(fulltext.Document{
+		Hash:     entry.Hash,
+		Title:    entry.Title,
+		Authors:  entry.Authors,
+		Format:   entry.Format,
+		Location: entry.Location,
+		Scope:    entry.Scope,
+		Text:     text,
+	})
+}
+
+// IndexLibrary adds the downloaded books of the scope that are missing from
+// the full-text index, and returns how many were added.
+func IndexLibrary(scope string) (int, error) {
+	l := logger.GetLogger()
+
+	if os.Getenv("ANNAS_FULLTEXT_INDEX") != "true" {
+		return 0, errFullTextDisabled
+	}
+
+	books, err := library.Open(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return 0, err
+	}
+
+	idx, err := fulltext.Open(fullTextPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, entry := range books.Entries(scope) {
+		if !fulltext.Supported(entry.Format) || idx.Contains(scope, entry.Hash) {
+			continue
+		}
+		if _, err := os.Stat(entry.Location); err != nil {
+			continue
+		}
+
+		if err := addToFullText(entry); err != nil {
+			l.Warn("Failed to add book to the full-text index",
+				zap.String("bookHash", entry.Hash),
+				zap.String("location", entry.Location),
+				zap.Error(err),
+			)
+			continue
+		}
+		added++
+	}
+
+	return added, nil
+}
+
+// SearchLocalContent returns the downloaded books of the scope whose text
+// matches the query, best matches first.
+func SearchLocalContent(scope, query string, limit int) ([]fulltext.Hit, error) {
+	if os.Getenv("ANNAS_FULLTEXT_INDEX") != "true" {
+		return nil, errFullTextDisabled
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("query must not be empty")
+	}
+	if limit <= 0 {
+		limit = defaultContentLimit
+	}
+
+	idx, err := fulltext.Open(fullTextPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := idx.Search(scope, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The highlighter marks matches with HTML tags and escapes the rest of
+	// the text, which reads poorly in plain text.
+	for i := range hits {
+		for j, fragment := range hits[i].Fragments {
+			fragment = strings.NewReplacer("<mark>", "**", "</mark>", "**").Replace(fragment)
+			hits[i].Fragments[j] = strings.Join(strings.Fields(html.UnescapeString(fragment)), " ")
+		}
+	}
+
+	return hits, nil
+}
+
+func formatContentHits(hits []fulltext.Hit) string {
+	if len(hits) == 0 {
+		return tr("No downloaded book mentions this.")
+	}
+
+	var sb strings.Builder
+	for i, hit := range hits {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s", hit.Title)
+		if hit.Authors != "" {
+			fmt.Fprintf(&sb, " by %s", hit.Authors)
+		}
+		fmt.Fprintf(&sb, " (%s, hash %s)\nLocation: %s\n", hit.Format, hit.Hash, hit.Location)
+		for _, fragment := range hit.Fragments {
+			fmt.Fprintf(&sb, "  %s\n", fragment)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,287 @@
+package modes
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Outcomes of the import of a Goodreads shelf entry.
+const (
+	WishQueued     = "queued"
+	WishResolved   = "resolved"
+	WishDownloaded = "downloaded"
+	WishNotFound   = "not found"
+	WishFailed     = "failed"
+)
+
+// goodreadsShelf is the shelf of the books that are imported, which is also
+// the read status of the books StoryGraph exports.
+const goodreadsShelf = "to-read"
+
+// GoodreadsBook is a book of a Goodreads or StoryGraph library export.
+type GoodreadsBook struct {
+	Title  string `json:"title"`
+	Author string `json:"author,omitempty"`
+	ISBN   string `json:"isbn,omitempty"`
+}
+
+// ImportedWish is the outcome of the import of a book from the to-read shelf
+// of Goodreads.
+type ImportedWish struct {
+	GoodreadsBook
+	Status string `json:"status"`
+	// Candidates is the number of matching files found on Anna's Archive,
+	// Hash and Format are the ones of the best of them.
+	Candidates int    `json:"candidates,omitempty"`
+	Hash       string `json:"hash,omitempty"`
+	Format     string `json:"format,omitempty"`
+	JobID      string `json:"job_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// seriesSuffix matches the series Goodreads appends to titles, such as
+// "(The Expanse, #1)", which the records of Anna's Archive rarely have.
+var seriesSuffix = regexp.MustCompile(`\s*\([^()]*#\d+(\.\d+)?\)\s*$`)
+
+// ReadGoodreadsExport returns the books of the to-read shelf of a Goodreads
+// library export, or the books to read of a StoryGraph export, which is told
+// apart by its Read Status column.
+func ReadGoodreadsExport(r io.Reader) ([]GoodreadsBook, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the export is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	if _, ok := columns["Title"]; !ok {
+		return nil, errors.New("not a Goodreads or StoryGraph library export, the Title column is missing")
+	}
+	_, storyGraph := columns["Read Status"]
+	if !storyGraph {
+		_, exclusive := columns["Exclusive Shelf"]
+		_, shelves := columns["Bookshelves"]
+		if !exclusive && !shelves {
+			return nil, errors.New("not a Goodreads or StoryGraph library export, the Exclusive Shelf, Bookshelves, or Read Status column is missing")
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	books := make([]GoodreadsBook, 0)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var book GoodreadsBook
+		if storyGraph {
+			if field(record, "Read Status") != goodreadsShelf {
+				continue
+			}
+			book = GoodreadsBook{
+				Title:  seriesSuffix.ReplaceAllString(field(record, "Title"), ""),
+				Author: storyGraphAuthor(field(record, "Authors")),
+				ISBN:   storyGraphISBN(field(record, "ISBN/UID")),
+			}
+		} else {
+			if !onShelf(field(record, "Exclusive Shelf"), field(record, "Bookshelves")) {
+				continue
+			}
+			book = GoodreadsBook{
+				Title:  seriesSuffix.ReplaceAllString(field(record, "Title"), ""),
+				Author: field(record, "Author"),
+				ISBN:   goodreadsISBN(field(record, "ISBN13")),
+			}
+			if book.ISBN == "" {
+				book.ISBN = goodreadsISBN(field(record, "ISBN"))
+			}
+		}
+		if book.Title == "" && book.ISBN == "" {
+			continue
+		}
+		books = append(books, book)
+	}
+
+	return books, nil
+}
+
+// onShelf reports whether a book is on the to-read shelf. Older exports only
+// list the shelves of a book, as a comma-separated list.
+func onShelf(exclusive, shelves string) bool {
+	if exclusive != "" {
+		return exclusive == goodreadsShelf
+	}
+	for _, shelf := range strings.Split(shelves, ",") {
+		if strings.TrimSpace(shelf) == goodreadsShelf {
+			return true
+		}
+	}
+
+	return false
+}
+
+// goodreadsISBN unwraps the ISBNs of the export, which Goodreads writes as
+// spreadsheet formulas such as ="9780316129084" to keep their leading zeros.
+func goodreadsISBN(value string) string {
+	value = strings.TrimPrefix(value, "=")
+	return strings.Trim(value, `"`)
+}
+
+// storyGraphAuthor returns the first of the authors of a StoryGraph export,
+// which separates them with commas.
+func storyGraphAuthor(authors string) string {
+	author, _, _ := strings.Cut(authors, ",")
+	return strings.TrimSpace(author)
+}
+
+// isbnPattern matches ISBN-10 and ISBN-13 without separators.
+var isbnPattern = regexp.MustCompile(`^(\d{9}[\dX]|\d{13})$`)
+
+// storyGraphISBN returns the ISBN of a StoryGraph export, which holds an
+// identifier of StoryGraph instead for books without an ISBN.
+func storyGraphISBN(value string) string {
+	value = strings.ToUpper(strings.ReplaceAll(value, "-", ""))
+	if !isbnPattern.MatchString(value) {
+		return ""
+	}
+
+	return value
+}
+
+// ImportGoodreads resolves the books of the to-read shelf of a Goodreads
+// export to the best matching files on Anna's Archive, and adds the ones
+// that are not downloaded yet to the download plan, unless dryRun is set.
+func ImportGoodreads(ctx context.Context, env *Env, books []GoodreadsBook, dryRun bool) ([]ImportedWish, error) {
+	l := logger.GetLogger()
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	client := env.Client()
+	wishes := make([]ImportedWish, 0, len(books))
+	downloads := make([]DownloadParams, 0)
+	planned := make([]int, 0)
+	for _, book := range books {
+		if err := ctx.Err(); err != nil {
+			return wishes, err
+		}
+
+		wish := ImportedWish{GoodreadsBook: book}
+		if book.Title != "" && len(idx.SameWork("", book.Title, book.Author)) > 0 {
+			wish.Status = WishDownloaded
+			wishes = append(wishes, wish)
+			continue
+		}
+
+		candidates, _, err := client.DeepSearch(ctx, book.Title, book.Author, book.ISBN)
+		if err != nil {
+			l.Warn("Failed to resolve Goodreads book",
+				zap.String("title", book.Title),
+				zap.Error(err),
+			)
+			wish.Status = WishFailed
+			wish.Error = err.Error()
+			wishes = append(wishes, wish)
+			continue
+		}
+		if len(candidates) == 0 {
+			wish.Status = WishNotFound
+			wishes = append(wishes, wish)
+			continue
+		}
+
+		best := candidates[0]
+		wish.Candidates = len(candidates)
+		wish.Hash = best.Hash
+		wish.Format = best.Format
+		switch {
+		case hasEntry(idx, best.Hash):
+			wish.Status = WishDownloaded
+		case dryRun:
+			wish.Status = WishResolved
+		default:
+			title := best.Title
+			if title == "" {
+				title = book.Title
+			}
+			downloads = append(downloads, DownloadParams{BookHash: best.Hash, Title: title, Format: best.Format})
+			planned = append(planned, len(wishes))
+		}
+		wishes = append(wishes, wish)
+	}
+
+	if len(downloads) == 0 {
+		return wishes, nil
+	}
+
+	jobs, err := PlanDownloads(downloads, cliRequester())
+	if err != nil {
+		return wishes, err
+	}
+	for i, job := range jobs {
+		wishes[planned[i]].Status = WishQueued
+		wishes[planned[i]].JobID = job.ID
+	}
+
+	return wishes, nil
+}
+
+func hasEntry(idx *library.Index, hash string) bool {
+	_, ok := idx.Entry("", hash)
+	return ok
+}
+
+func formatImportedWishes(wishes []ImportedWish) string {
+	if len(wishes) == 0 {
+		return "No books found on the to-read shelf."
+	}
+
+	counts := make(map[string]int)
+	var sb strings.Builder
+	for _, wish := range wishes {
+		counts[wish.Status]++
+		fmt.Fprintf(&sb, "%s: %s", wish.Status, wish.Title)
+		if wish.Author != "" {
+			fmt.Fprintf(&sb, " by %s", wish.Author)
+		}
+		switch {
+		case wish.Error != "":
+			fmt.Fprintf(&sb, ": %s", wish.Error)
+		case wish.Hash != "":
+			fmt.Fprintf(&sb, " (%s, %s, %d candidates)", wish.Hash, wish.Format, wish.Candidates)
+		}
+		sb.WriteString("\n")
+	}
+
+	return fmt.Sprintf("Imported %d books: %s\n", len(wishes), strings.Join(countsByFrequency(counts), ", ")) + sb.String()
+}
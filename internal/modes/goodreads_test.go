@@ -0,0 +1,57 @@
+package modes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadGoodreadsExport(t *testing.T) {
+	export := "\ufeffBook Id,Title,Author,ISBN,ISBN13,Exclusive Shelf,Bookshelves\n" +
+		`1,"Leviathan Wakes (The Expanse, #1)",James S.A. Corey,"=""0316129089""","=""9780316129084""",to-read,to-read` + "\n" +
+		`2,Dune,Frank Herbert,"=""""","=""""",read,` + "\n" +
+		`3,Hyperion,Dan Simmons,"=""0553283685""","=""""",,"favorites, to-read"` + "\n"
+
+	books, err := ReadGoodreadsExport(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GoodreadsBook{
+		{Title: "Leviathan Wakes", Author: "James S.A. Corey", ISBN: "9780316129084"},
+		{Title: "Hyperion", Author: "Dan Simmons", ISBN: "0553283685"},
+	}
+	if !reflect.DeepEqual(books, want) {
+		t.Errorf("ReadGoodreadsExport returned %+v, want %+v", books, want)
+	}
+}
+
+func TestReadStoryGraphExport(t *testing.T) {
+	export := "Title,Authors,Contributors,ISBN/UID,Format,Read Status,Date Added,Star Rating\n" +
+		`Piranesi,Susanna Clarke,,9781635575637,hardcover,to-read,2024/01/02,` + "\n" +
+		`Good Omens,"Terry Pratchett, Neil Gaiman",,9780060853983,paperback,read,2023/05/06,4.5` + "\n" +
+		`The Dispossessed,Ursula K. Le Guin,,b7e0a6f2-0d8b-4b0e-9c3c-3c1a2f5e9d11,digital,to-read,2024/02/03,` + "\n"
+
+	books, err := ReadGoodreadsExport(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GoodreadsBook{
+		{Title: "Piranesi", Author: "Susanna Clarke", ISBN: "9781635575637"},
+		{Title: "The Dispossessed", Author: "Ursula K. Le Guin"},
+	}
+	if !reflect.DeepEqual(books, want) {
+		t.Errorf("ReadGoodreadsExport returned %+v, want %+v", books, want)
+	}
+}
+
+func TestReadUnknownExport(t *testing.T) {
+	for name, export := range map[string]string{
+		"no title":  "Name,Author\nDune,Frank Herbert\n",
+		"no shelf":  "Title,Author\nDune,Frank Herbert\n",
+		"empty csv": "",
+	} {
+		if _, err := ReadGoodreadsExport(strings.NewReader(export)); err == nil {
+			t.Errorf("ReadGoodreadsExport accepted an export with %s", name)
+		}
+	}
+}
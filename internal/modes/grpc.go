@@ -0,0 +1,327 @@
+package modes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	annasv1 "github.com/iosifache/annas-mcp/proto/annas/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements the AnnasService of proto/annas/v1 on top of the same
+// operations as the MCP tools.
+type grpcServer struct {
+	annasv1.UnimplementedAnnasServiceServer
+
+	queue *JobQueue
+}
+
+func toProtoBook(b *anna.Book) *annasv1.Book {
+	return &annasv1.Book{
+		Hash:         b.Hash,
+		Title:        b.Title,
+		Authors:      b.Authors,
+		AuthorList:   b.AuthorList,
+		Publisher:    b.Publisher,
+		Language:     b.Language,
+		LanguageCode: b.LanguageCode,
+		Format:       b.Format,
+		Size:         b.Size,
+		SizeBytes:    b.SizeBytes,
+		Year:         b.Year,
+		Url:          b.URL,
+		Description:  b.Description,
+		Toc:          b.TOC,
+		Sources:      b.Sources,
+	}
+}
+
+func toProtoQuota(q *anna.FastDownloadInfo) *annasv1.Quota {
+	if q == nil {
+		return nil
+	}
+
+	return &annasv1.Quota{
+		DownloadsLeft:   int32(q.DownloadsLeft),
+		DownloadsPerDay: int32(q.DownloadsPerDay),
+	}
+}
+
+func toProtoJob(job Job) *annasv1.Job {
+	pb := &annasv1.Job{
+		Id:            job.ID,
+		Status:        job.Status,
+		Hash:          job.Hash,
+		Title:         job.Title,
+		Format:        job.Format,
+		Error:         job.Error,
+		CreatedAtUnix: job.CreatedAt.Unix(),
+	}
+	if job.Result != nil {
+		pb.Location = job.Result.Location
+		pb.Quota = toProtoQuota(job.Result.Quota)
+	}
+	if job.FinishedAt != nil {
+		pb.FinishedAtUnix = job.FinishedAt.Unix()
+	}
+	if job.ScheduledFor != nil {
+		pb.ScheduledForUnix = job.ScheduledFor.Unix()
+	}
+
+	return pb
+}
+
+// grpcStatus maps errors of the anna package to gRPC status codes.
+func grpcStatus(err error) error {
+	var duplicate *library.DuplicateError
+
+	switch {
+	case errors.Is(err, anna.ErrBookNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, anna.ErrNoDownloadsLeft), errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrQueueFull), errors.Is(err, ErrSessionBudgetExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.As(err, &duplicate):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+// grpcScope returns the download scope of a gRPC call.
+func grpcScope(ctx context.Context) string {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	if !ok {
+		identity = grpcBearerToken(ctx)
+	}
+
+	return clientScope(keyScope(identity))
+}
+
+// grpcRequester identifies the client of a gRPC call in the audit log.
+func grpcRequester(ctx context.Context) Requester {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	if !ok {
+		identity = grpcBearerToken(ctx)
+	}
+
+	return Requester{Interface: "grpc", Client: keyScope(identity)}
+}
+
+func grpcBearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	return ""
+}
+
+// UnaryInterceptor rejects gRPC calls without a valid bearer token in their
+// metadata and attaches the authenticated identity to their context.
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	identity, ok := a.authenticate(ctx, grpcBearerToken(ctx))
+	if !ok {
+		logger.GetLogger().Warn("Rejected unauthenticated gRPC call", zap.String("method", info.FullMethod))
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	return handler(context.WithValue(ctx, identityKey{}, identity), req)
+}
+
+func (s *grpcServer) Search(ctx context.Context, req *annasv1.SearchRequest) (*annasv1.SearchResponse, error) {
+	l := logger.GetLogger()
+
+	l.Info("gRPC search called", zap.String("searchTerm", req.GetTerm()))
+
+	books, err := GetClient().Search(ctx, req.GetTerm())
+	if err != nil {
+		l.Error("gRPC search failed",
+			zap.String("searchTerm", req.GetTerm()),
+			zap.Error(err),
+		)
+		return nil, grpcStatus(err)
+	}
+
+	recordSearch(grpcScope(ctx), req.GetTerm(), books)
+
+	resp := &annasv1.SearchResponse{Books: make([]*annasv1.Book, 0, len(books))}
+	for _, book := range books {
+		resp.Books = append(resp.Books, toProtoBook(book))
+	}
+
+	return resp, nil
+}
+
+func (s *grpcServer) GetBook(ctx context.Context, req *annasv1.GetBookRequest) (*annasv1.GetBookResponse, error) {
+	if err := anna.CheckHash(req.GetHash()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	details, err := GetClient().GetBook(ctx, req.GetHash())
+	if err != nil {
+		logger.GetLogger().Error("gRPC get book failed",
+			zap.String("bookHash", req.GetHash()),
+			zap.Error(err),
+		)
+		return nil, grpcStatus(err)
+	}
+
+	return &annasv1.GetBookResponse{Book: toProtoBook(details.Book)}, nil
+}
+
+func (s *grpcServer) Download(ctx context.Context, req *annasv1.DownloadRequest) (*annasv1.DownloadResponse, error) {
+	l := logger.GetLogger()
+
+	l.Info("gRPC download called", zap.String("bookHash", req.GetHash()))
+
+	if err := anna.CheckHash(req.GetHash()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := runDownloadJob(ctx, &Job{
+		Hash:   req.GetHash(),
+		Title:  req.GetTitle(),
+		Format: req.GetFormat(),
+
+		allowDuplicateFormats: req.GetAllowDuplicateFormats(),
+		scope:                 grpcScope(ctx),
+		requester:             grpcRequester(ctx),
+	})
+	if err != nil {
+		l.Error("gRPC download failed",
+			zap.String("bookHash", req.GetHash()),
+			zap.Error(err),
+		)
+		return nil, grpcStatus(err)
+	}
+
+	return &annasv1.DownloadResponse{
+		Location: result.Location,
+		Quota:    toProtoQuota(result.Quota),
+	}, nil
+}
+
+func (s *grpcServer) EnqueueDownload(ctx context.Context, req *annasv1.EnqueueDownloadRequest) (*annasv1.EnqueueDownloadResponse, error) {
+	if err := anna.CheckHash(req.GetHash()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	job, err := s.queue.Enqueue(DownloadParams{
+		BookHash: req.GetHash(),
+		Title:    req.GetTitle(),
+		Format:   req.GetFormat(),
+
+		AllowDuplicateFormats: req.GetAllowDuplicateFormats(),
+	}, grpcScope(ctx), grpcRequester(ctx))
+	if err != nil {
+		return nil, grpcStatus(err)
+	}
+
+	return &annasv1.EnqueueDownloadResponse{Job: toProtoJob(job)}, nil
+}
+
+func (s *grpcServer) ListJobs(ctx context.Context, req *annasv1.ListJobsRequest) (*annasv1.ListJobsResponse, error) {
+	jobs := s.queue.List(grpcScope(ctx))
+
+	resp := &annasv1.ListJobsResponse{Jobs: make([]*annasv1.Job, 0, len(jobs))}
+	for _, job := range jobs {
+		resp.Jobs = append(resp.Jobs, toProtoJob(job))
+	}
+
+	return resp, nil
+}
+
+func (s *grpcServer) SearchHistory(ctx context.Context, req *annasv1.SearchHistoryRequest) (*annasv1.SearchHistoryResponse, error) {
+	searches, err := SearchHistory(grpcScope(ctx), historyLimit(int(req.GetLimit())))
+	if err != nil {
+		logger.GetLogger().Error("gRPC search history failed", zap.Error(err))
+		return nil, grpcStatus(err)
+	}
+
+	resp := &annasv1.SearchHistoryResponse{Searches: make([]*annasv1.Search, 0, len(searches))}
+	for _, search := range searches {
+		resp.Searches = append(resp.Searches, &annasv1.Search{
+			Query:          search.Query,
+			ResultCount:    int32(search.ResultCount),
+			Results:        search.Results,
+			Downloaded:     search.Downloaded,
+			SearchedAtUnix: search.SearchedAt.Unix(),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *grpcServer) LibraryStats(ctx context.Context, req *annasv1.LibraryStatsRequest) (*annasv1.LibraryStatsResponse, error) {
+	stats, err := LibraryStats(grpcScope(ctx))
+	if err != nil {
+		logger.GetLogger().Error("gRPC library stats failed", zap.Error(err))
+		return nil, grpcStatus(err)
+	}
+
+	return &annasv1.LibraryStatsResponse{
+		Books:      int32(stats.Books),
+		TotalSize:  stats.TotalSize,
+		ByFormat:   toProtoCounts(stats.ByFormat),
+		ByLanguage: toProtoCounts(stats.ByLanguage),
+		ByAuthor:   toProtoCounts(stats.ByAuthor),
+		ByMonth:    toProtoCounts(stats.ByMonth),
+	}, nil
+}
+
+func toProtoCounts(counts map[string]int) map[string]int32 {
+	pb := make(map[string]int32, len(counts))
+	for key, count := range counts {
+		pb[key] = int32(count)
+	}
+
+	return pb
+}
+
+// serveGRPC serves the gRPC API on its own listener, over TLS if a
+// certificate is configured.
+func serveGRPC(opts ServeOptions, queue *JobQueue, auth *Authenticator) error {
+	l := logger.GetLogger()
+
+	serverOpts := make([]grpc.ServerOption, 0)
+	if opts.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+	if auth != nil {
+		serverOpts = append(serverOpts, grpc.UnaryInterceptor(auth.UnaryInterceptor))
+	} else {
+		l.Warn("gRPC API is not protected by authentication, set ANNAS_HTTP_TOKENS or ANNAS_OIDC_ISSUER")
+	}
+
+	listener, err := net.Listen("tcp", opts.GRPCAddr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(serverOpts...)
+	annasv1.RegisterAnnasServiceServer(server, &grpcServer{queue: queue})
+
+	l.Info("gRPC server started successfully",
+		zap.String("addr", opts.GRPCAddr),
+		zap.Bool("authenticated", auth != nil),
+		zap.Bool("tls", opts.TLSCertFile != ""),
+	)
+
+	return server.Serve(listener)
+}
@@ -0,0 +1,65 @@
+package modes
+
+import (
+	"context"
+	"testing"
+
+	annasv1 "github.com/iosifache/annas-mcp/proto/annas/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCRejectsInvalidHash(t *testing.T) {
+	q := &JobQueue{jobs: make(map[string]*Job), pending: make(chan *Job, 1)}
+	s := &grpcServer{queue: q}
+	ctx := context.Background()
+	hash := "0123456789abcdef0123456789abcdef&domain_index=1"
+
+	calls := map[string]func() error{
+		"GetBook": func() error {
+			_, err := s.GetBook(ctx, &annasv1.GetBookRequest{Hash: hash})
+			return err
+		},
+		"Download": func() error {
+			_, err := s.Download(ctx, &annasv1.DownloadRequest{Hash: hash, Format: "epub"})
+			return err
+		},
+		"EnqueueDownload": func() error {
+			_, err := s.EnqueueDownload(ctx, &annasv1.EnqueueDownloadRequest{Hash: hash, Format: "epub"})
+			return err
+		},
+	}
+	for name, call := range calls {
+		if code := status.Code(call()); code != codes.InvalidArgument {
+			t.Errorf("%s with an invalid hash returned %s, want %s", name, code, codes.InvalidArgument)
+		}
+	}
+	if len(q.pending) != 0 {
+		t.Error("a download with an invalid hash was queued")
+	}
+}
+
+func TestGRPCLibrary(t *testing.T) {
+	t.Setenv("ANNAS_SECRET_KEY", "key")
+	t.Setenv("ANNAS_DOWNLOAD_PATH", t.TempDir())
+	s := &grpcServer{}
+	ctx := context.Background()
+
+	recordSearch("", "dune", nil)
+
+	history, err := s.SearchHistory(ctx, &annasv1.SearchHistoryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.GetSearches()) != 1 || history.GetSearches()[0].GetQuery() != "dune" {
+		t.Errorf("SearchHistory returned %v, want the search for dune", history.GetSearches())
+	}
+
+	stats, err := s.LibraryStats(ctx, &annasv1.LibraryStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.GetBooks() != 0 {
+		t.Errorf("LibraryStats counted %d books in an empty library", stats.GetBooks())
+	}
+}
@@ -0,0 +1,96 @@
+package modes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// maxHashWorkers bounds the files hashed at once. Hashing is mostly bound by
+// the disk, which more workers would only make seek.
+const maxHashWorkers = 8
+
+// HashProgress is told how many of the files have been hashed so far.
+type HashProgress func(done, total int)
+
+// hashedFile is the MD5 hash of a file, or the error hashing it.
+type hashedFile struct {
+	path string
+	hash string
+	err  error
+}
+
+// hashFiles hashes the files with a bounded pool of workers and returns their
+// hashes in the order of paths. Progress, if set, is called from a single
+// goroutine after each file. Hashing stops early if the context is done.
+func hashFiles(ctx context.Context, paths []string, progress HashProgress) ([]hashedFile, error) {
+	hashed := make([]hashedFile, len(paths))
+	workers := min(max(runtime.NumCPU(), 2), maxHashWorkers, max(len(paths), 1))
+
+	indexes := make(chan int)
+	done := make(chan struct{}, len(paths))
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				hash, err := md5File(paths[i])
+				hashed[i] = hashedFile{path: paths[i], hash: hash, err: err}
+				done <- struct{}{}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indexes)
+		for i := range paths {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	count := 0
+	for range done {
+		count++
+		if progress != nil {
+			progress(count, len(paths))
+		}
+	}
+
+	return hashed, ctx.Err()
+}
+
+// progressPrinter returns a HashProgress writing the count of hashed files
+// to w every second, on a single line, or nil if w is not a terminal, so
+// that logs and pipes are not cluttered.
+func progressPrinter(w *os.File) HashProgress {
+	if !term.IsTerminal(int(w.Fd())) {
+		return nil
+	}
+
+	var last time.Time
+	return func(done, total int) {
+		if done < total && time.Since(last) < time.Second {
+			return
+		}
+		last = time.Now()
+		fmt.Fprintf(w, "\rHashed %d of %d files", done, total)
+		if done == total {
+			fmt.Fprintln(w)
+		}
+	}
+}
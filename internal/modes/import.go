@@ -0,0 +1,179 @@
+package modes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// Outcomes of the import of a file.
+const (
+	ImportAdded   = "added"
+	ImportIndexed = "indexed"
+	ImportUnknown = "unknown"
+	ImportFailed  = "failed"
+)
+
+// ImportedFile is the outcome of the import of a file into the library index.
+type ImportedFile struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Hash   string `json:"hash,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportLibrary adds the books found below dir to the library index, so that
+// they count as downloaded. Each file is identified by looking up its MD5
+// hash on Anna's Archive, and stays where it is. Files that are unknown there
+// are left out of the index. The files are hashed in parallel, and progress,
+// if set, is told how many are done.
+func ImportLibrary(ctx context.Context, env *Env, dir string, progress HashProgress) ([]ImportedFile, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0)
+	err = walkBooks(root, false, func(path string) error {
+		paths = append(paths, path)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := hashFiles(ctx, paths, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	client := env.Client()
+	imported := make([]ImportedFile, 0, len(hashed))
+	for _, hashedFile := range hashed {
+		if err := ctx.Err(); err != nil {
+			return imported, err
+		}
+		path, hash := hashedFile.path, hashedFile.hash
+		if hashedFile.err != nil {
+			// One unreadable file does not stop the import of the others.
+			logger.GetLogger().Warn("Failed to hash file",
+				zap.String("path", path),
+				zap.Error(hashedFile.err),
+			)
+			imported = append(imported, ImportedFile{Path: path, Status: ImportFailed, Error: hashedFile.err.Error()})
+			continue
+		}
+
+		file := ImportedFile{Path: path, Hash: hash}
+		err := importFile(ctx, env, idx, client, &file)
+		imported = append(imported, file)
+		if err != nil {
+			return imported, err
+		}
+	}
+
+	return imported, nil
+}
+
+// importFile adds a hashed file to the library index if Anna's Archive knows
+// it, recording the outcome in file.
+func importFile(ctx context.Context, env *Env, idx *library.Index, client *anna.Client, file *ImportedFile) error {
+	l := logger.GetLogger()
+	path, hash := file.Path, file.Hash
+
+	if entry, ok := idx.Entry("", hash); ok && filepath.Clean(entry.Location) == path {
+		file.Status = ImportIndexed
+		file.Title = entry.Title
+		return nil
+	}
+
+	details, err := client.GetBook(ctx, hash)
+	if errors.Is(err, anna.ErrBookNotFound) {
+		file.Status = ImportUnknown
+		return nil
+	}
+	if err != nil {
+		l.Warn("Failed to look up file on Anna's Archive",
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		file.Status = ImportFailed
+		file.Error = err.Error()
+		return nil
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		file.Status = ImportFailed
+		file.Error = err.Error()
+		return nil
+	}
+
+	format := details.Book.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	entry := library.Entry{
+		Hash:         hash,
+		Title:        details.Book.Title,
+		Authors:      details.Book.Authors,
+		Format:       format,
+		Language:     details.Book.Language,
+		Year:         details.Book.Year,
+		Location:     path,
+		Size:         stat.Size(),
+		DownloadedAt: stat.ModTime(),
+	}
+	if err := idx.Add(entry); err != nil {
+		return err
+	}
+	if env.FullText {
+		indexContent(entry)
+	}
+
+	file.Status = ImportAdded
+	file.Title = entry.Title
+	return nil
+}
+
+func formatImportedFiles(imported []ImportedFile) string {
+	if len(imported) == 0 {
+		return "No files found."
+	}
+
+	counts := make(map[string]int)
+	var sb strings.Builder
+	for _, file := range imported {
+		counts[file.Status]++
+		switch file.Status {
+		case ImportAdded:
+			fmt.Fprintf(&sb, "added: %s (%s)\n", file.Path, file.Title)
+		case ImportUnknown:
+			fmt.Fprintf(&sb, "unknown: %s\n", file.Path)
+		case ImportFailed:
+			fmt.Fprintf(&sb, "failed: %s: %s\n", file.Path, file.Error)
+		}
+	}
+
+	return fmt.Sprintf("Scanned %d files: %s\n", len(imported), strings.Join(countsByFrequency(counts), ", ")) + sb.String()
+}
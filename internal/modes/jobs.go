@@ -0,0 +1,567 @@
+package modes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/notify"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// Statuses of a download job.
+const (
+	JobQueued    = "queued"
+	JobScheduled = "scheduled"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+)
+
+// Job is a download queued through the REST API.
+type Job struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Hash       string               `json:"hash"`
+	Title      string               `json:"title"`
+	Format     string               `json:"format"`
+	Result     *anna.DownloadResult `json:"result,omitempty"`
+	Error      string               `json:"error,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	// ScheduledFor is set for jobs waiting for the fast download quota to
+	// reset.
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+
+	allowDuplicateFormats bool
+	supplements           bool
+	scope                 string
+	requester             Requester
+}
+
+// plannedJob is a job that has not finished yet, as saved in the plan file.
+type plannedJob struct {
+	Job
+	AllowDuplicateFormats bool      `json:"allow_duplicate_formats,omitempty"`
+	Supplements           bool      `json:"supplements,omitempty"`
+	Scope                 string    `json:"scope,omitempty"`
+	Requester             Requester `json:"requester"`
+}
+
+// jobPlan is the on-disk layout of the plan file.
+type jobPlan struct {
+	QuotaResetAt *time.Time    `json:"quota_reset_at,omitempty"`
+	Jobs         []*plannedJob `json:"jobs"`
+}
+
+var ErrQueueFull = errors.New("download queue is full")
+
+// maxFinishedJobs is how many finished jobs are kept for polling, so that a
+// long-running server does not hold on to every download it ever ran.
+const maxFinishedJobs = 1000
+
+// JobQueue runs queued downloads one after the other, so that concurrent
+// clients do not race each other for the fast download quota. Once the quota
+// is exhausted, the remaining jobs are scheduled for after it resets. The
+// jobs that have not finished yet are saved to a plan file, so that they are
+// resumed when the server restarts.
+type JobQueue struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	order   []string
+	pending chan *Job
+	run     func(context.Context, *Job) (*anna.DownloadResult, error)
+
+	planPath     string
+	quotaResetAt time.Time
+
+	// sinks are told how a batch of jobs went once the queue is empty. The
+	// batch is only touched by the worker.
+	sinks notify.Sinks
+	batch jobBatch
+}
+
+// jobBatch holds the names of the jobs finished since the queue was last
+// empty.
+type jobBatch struct {
+	done   []string
+	failed []string
+}
+
+func NewJobQueue() *JobQueue {
+	q := &JobQueue{
+		jobs:     make(map[string]*Job),
+		pending:  make(chan *Job, 256),
+		run:      runDownloadJob,
+		planPath: jobPlanPath(os.Getenv("ANNAS_DOWNLOAD_PATH")),
+	}
+	sinks, err := notificationSinks()
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring notification sinks", zap.Error(err))
+	}
+	q.sinks = sinks
+	q.resume()
+	go q.work()
+
+	return q
+}
+
+// jobPlanPath returns the location of the plan file, next to the library
+// index.
+func jobPlanPath(downloadPath string) string {
+	return filepath.Join(filepath.Dir(libraryPath(downloadPath)), ".annas-jobs.json")
+}
+
+// nextQuotaReset returns when the fast download quota is renewed. The API
+// reports the downloads left for the day but not when the day ends, and the
+// quota is counted per UTC day.
+func nextQuotaReset(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// resume queues the jobs of the plan file left by a previous run.
+func (q *JobQueue) resume() {
+	l := logger.GetLogger()
+
+	plan, err := readPlan(q.planPath)
+	if err != nil {
+		l.Warn("Failed to read the download plan", zap.String("path", q.planPath), zap.Error(err))
+		return
+	}
+	if len(plan.Jobs) == 0 {
+		return
+	}
+
+	if plan.QuotaResetAt != nil && plan.QuotaResetAt.After(time.Now()) {
+		q.quotaResetAt = *plan.QuotaResetAt
+	}
+
+	for _, planned := range plan.Jobs {
+		job := planned.Job
+		job.allowDuplicateFormats = planned.AllowDuplicateFormats
+		job.supplements = planned.Supplements
+		job.scope = planned.Scope
+		job.requester = planned.Requester
+		// Jobs that were running when the server stopped start over.
+		job.Status = JobQueued
+		job.ScheduledFor = nil
+		if !q.quotaResetAt.IsZero() {
+			resetAt := q.quotaResetAt
+			job.Status = JobScheduled
+			job.ScheduledFor = &resetAt
+		}
+
+		select {
+		case q.pending <- &job:
+		default:
+			l.Warn("Dropping planned download, the queue is full", zap.String("jobID", job.ID))
+			continue
+		}
+		q.jobs[job.ID] = &job
+		q.order = append(q.order, job.ID)
+	}
+
+	l.Info("Resumed planned downloads", zap.Int("jobs", len(q.order)))
+}
+
+// savePlan writes the jobs that have not finished yet to the plan file. The
+// caller must hold the lock.
+func (q *JobQueue) savePlan() {
+	l := logger.GetLogger()
+
+	plan := jobPlan{Jobs: make([]*plannedJob, 0)}
+	if !q.quotaResetAt.IsZero() {
+		plan.QuotaResetAt = &q.quotaResetAt
+	}
+	for _, id := range q.order {
+		job := q.jobs[id]
+		if job.Status == JobDone || job.Status == JobFailed {
+			continue
+		}
+		plan.Jobs = append(plan.Jobs, &plannedJob{
+			Job:                   *job,
+			AllowDuplicateFormats: job.allowDuplicateFormats,
+			Supplements:           job.supplements,
+			Scope:                 job.scope,
+			Requester:             job.requester,
+		})
+	}
+
+	if err := writePlan(q.planPath, plan); err != nil {
+		l.Warn("Failed to save the download plan", zap.String("path", q.planPath), zap.Error(err))
+	}
+}
+
+// readPlan reads the plan file at path, which may not exist.
+func readPlan(path string) (jobPlan, error) {
+	var plan jobPlan
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return plan, nil
+	}
+	if err != nil {
+		return plan, err
+	}
+
+	return plan, json.Unmarshal(data, &plan)
+}
+
+// writePlan replaces the plan file at path, or removes it if no jobs are
+// left.
+func writePlan(path string, plan jobPlan) error {
+	if len(plan.Jobs) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// PlanDownloads adds downloads to the plan file of the configured library
+// without running them, so that the next server using the library picks them
+// up. The caller must make sure that no server is using the library.
+func PlanDownloads(params []DownloadParams, requester Requester) ([]Job, error) {
+	path := jobPlanPath(os.Getenv("ANNAS_DOWNLOAD_PATH"))
+	plan, err := readPlan(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the download plan: %w", err)
+	}
+
+	jobs := make([]Job, 0, len(params))
+	for _, p := range params {
+		job := newJob(p)
+		if plan.QuotaResetAt != nil && plan.QuotaResetAt.After(time.Now()) {
+			resetAt := *plan.QuotaResetAt
+			job.Status = JobScheduled
+			job.ScheduledFor = &resetAt
+		}
+		plan.Jobs = append(plan.Jobs, &plannedJob{
+			Job:                   *job,
+			AllowDuplicateFormats: p.AllowDuplicateFormats,
+			Supplements:           p.Supplements,
+			Requester:             requester,
+		})
+		jobs = append(jobs, *job)
+	}
+
+	if err := writePlan(path, plan); err != nil {
+		return nil, fmt.Errorf("failed to save the download plan: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// newJob returns a queued job for the download.
+func newJob(params DownloadParams) *Job {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+
+	return &Job{
+		ID:        hex.EncodeToString(buf),
+		Status:    JobQueued,
+		Hash:      params.BookHash,
+		Title:     params.Title,
+		Format:    params.Format,
+		CreatedAt: time.Now(),
+
+		allowDuplicateFormats: params.AllowDuplicateFormats,
+		supplements:           params.Supplements,
+	}
+}
+
+// scheduleAfterReset postpones the jobs that have not started yet until the
+// quota resets. The caller must hold the lock.
+func (q *JobQueue) scheduleAfterReset(resetAt time.Time) {
+	q.quotaResetAt = resetAt
+	for _, job := range q.jobs {
+		if job.Status == JobQueued || job.Status == JobRunning {
+			job.Status = JobScheduled
+			job.ScheduledFor = &resetAt
+		}
+	}
+	q.savePlan()
+}
+
+// waitForQuota blocks until the quota has reset, if it is exhausted.
+func (q *JobQueue) waitForQuota() {
+	q.mu.Lock()
+	resetAt := q.quotaResetAt
+	q.mu.Unlock()
+
+	if wait := time.Until(resetAt); wait > 0 {
+		logger.GetLogger().Info("Waiting for the fast download quota to reset", zap.Time("resetAt", resetAt))
+		time.Sleep(wait)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.quotaResetAt.IsZero() {
+		return
+	}
+	q.quotaResetAt = time.Time{}
+	for _, job := range q.jobs {
+		if job.Status == JobScheduled {
+			job.Status = JobQueued
+			job.ScheduledFor = nil
+		}
+	}
+	q.savePlan()
+}
+
+// Enqueue queues a download in the given scope and returns a snapshot of the
+// new job.
+func (q *JobQueue) Enqueue(params DownloadParams, scope string, requester Requester) (Job, error) {
+	job := newJob(params)
+	job.scope = scope
+	job.requester = requester
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.quotaResetAt.IsZero() {
+		resetAt := q.quotaResetAt
+		job.Status = JobScheduled
+		job.ScheduledFor = &resetAt
+	}
+
+	select {
+	case q.pending <- job:
+	default:
+		return Job{}, ErrQueueFull
+	}
+
+	q.jobs[job.ID] = job
+	q.order = append(q.order, job.ID)
+	q.savePlan()
+
+	return *job, nil
+}
+
+// List returns snapshots of the jobs of the given scope, oldest first.
+func (q *JobQueue) List(scope string) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, 0)
+	for _, id := range q.order {
+		if job := q.jobs[id]; job.scope == scope {
+			jobs = append(jobs, *job)
+		}
+	}
+
+	return jobs
+}
+
+// Get returns a snapshot of the job with the given ID if it belongs to the
+// given scope.
+func (q *JobQueue) Get(id, scope string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok || job.scope != scope {
+		return Job{}, false
+	}
+
+	return *job, true
+}
+
+func (q *JobQueue) work() {
+	l := logger.GetLogger()
+
+	for job := range q.pending {
+		var (
+			result *anna.DownloadResult
+			err    error
+		)
+		for {
+			q.waitForQuota()
+
+			q.mu.Lock()
+			job.Status = JobRunning
+			job.ScheduledFor = nil
+			q.mu.Unlock()
+
+			l.Info("Download job started",
+				zap.String("jobID", job.ID),
+				zap.String("bookHash", job.Hash),
+			)
+
+			result, err = q.run(context.Background(), job)
+			if !errors.Is(err, anna.ErrNoDownloadsLeft) {
+				break
+			}
+
+			// The job is retried, together with the rest of the queue, once
+			// the quota is renewed.
+			resetAt := nextQuotaReset(time.Now())
+			l.Info("Fast download quota exhausted, scheduling the remaining downloads",
+				zap.String("jobID", job.ID),
+				zap.Time("resetAt", resetAt),
+			)
+			q.mu.Lock()
+			q.scheduleAfterReset(resetAt)
+			q.mu.Unlock()
+		}
+		finished := time.Now()
+
+		q.mu.Lock()
+		job.FinishedAt = &finished
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = JobDone
+			job.Result = result
+		}
+		// The last fast download of the day is known from its quota, so the
+		// following jobs do not spend a request to find out.
+		if err == nil && result.Quota != nil && result.Quota.DownloadsLeft <= 0 {
+			q.scheduleAfterReset(nextQuotaReset(finished))
+		} else {
+			q.savePlan()
+		}
+		q.pruneFinished()
+		q.mu.Unlock()
+
+		if err != nil {
+			l.Error("Download job failed",
+				zap.String("jobID", job.ID),
+				zap.String("bookHash", job.Hash),
+				zap.Error(err),
+			)
+		} else {
+			l.Info("Download job completed successfully",
+				zap.String("jobID", job.ID),
+				zap.String("bookHash", job.Hash),
+				zap.String("location", result.Location),
+			)
+		}
+		q.notifyOutcome(job, err)
+	}
+}
+
+// pruneFinished forgets the oldest finished jobs beyond maxFinishedJobs. The
+// caller must hold the lock.
+func (q *JobQueue) pruneFinished() {
+	finished := 0
+	for _, id := range q.order {
+		if status := q.jobs[id].Status; status == JobDone || status == JobFailed {
+			finished++
+		}
+	}
+
+	order := q.order[:0]
+	for _, id := range q.order {
+		if status := q.jobs[id].Status; finished > maxFinishedJobs && (status == JobDone || status == JobFailed) {
+			delete(q.jobs, id)
+			finished--
+			continue
+		}
+		order = append(order, id)
+	}
+	q.order = order
+}
+
+// notifyTimeout bounds the delivery of a notification, so that a sink that
+// does not answer does not hold up the queue.
+const notifyTimeout = 10 * time.Second
+
+// notifyOutcome adds a finished job to the batch and, once the queue is
+// empty, tells the notification sinks how the batch went.
+func (q *JobQueue) notifyOutcome(job *Job, err error) {
+	if len(q.sinks) == 0 {
+		return
+	}
+
+	name := job.Title
+	if name == "" {
+		name = job.Hash
+	}
+	if err != nil {
+		q.batch.failed = append(q.batch.failed, fmt.Sprintf("%s: %v", name, err))
+	} else {
+		q.batch.done = append(q.batch.done, name)
+	}
+	if len(q.pending) > 0 {
+		return
+	}
+
+	batch := q.batch
+	q.batch = jobBatch{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := q.sinks.Notify(ctx, batch.notification()); err != nil {
+		logger.GetLogger().Warn("Failed to send notification", zap.Error(err))
+	}
+}
+
+func (b jobBatch) notification() notify.Notification {
+	total := len(b.done) + len(b.failed)
+	switch {
+	case total == 1 && len(b.failed) == 0:
+		return notify.Notification{Title: "Download finished", Message: "Downloaded " + b.done[0]}
+	case total == 1:
+		return notify.Notification{Title: "Download failed", Message: b.failed[0], Failed: true}
+	}
+
+	n := notify.Notification{
+		Title:   "Downloads finished",
+		Message: fmt.Sprintf("Downloaded %d of %d books", len(b.done), total),
+		Failed:  len(b.failed) > 0,
+	}
+	if n.Failed {
+		n.Message += ", failed:\n- " + strings.Join(b.failed, "\n- ")
+	}
+
+	return n
+}
+
+func runDownloadJob(ctx context.Context, job *Job) (*anna.DownloadResult, error) {
+	env, err := GetEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := scopedStorage(env, job.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &anna.Book{
+		Hash:   job.Hash,
+		Title:  job.Title,
+		Format: job.Format,
+	}
+
+	return downloadToLibrary(withRequester(ctx, job.requester), env, store, book, job.scope, downloadOptions{
+		allowDuplicateFormats: job.allowDuplicateFormats,
+		supplements:           job.supplements,
+	})
+}
@@ -0,0 +1,61 @@
+package modes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPruneFinished(t *testing.T) {
+	q := &JobQueue{jobs: make(map[string]*Job)}
+	add := func(id, status string) {
+		q.jobs[id] = &Job{ID: id, Status: status}
+		q.order = append(q.order, id)
+	}
+	for i := 0; i < maxFinishedJobs+10; i++ {
+		status := JobDone
+		if i%2 == 1 {
+			status = JobFailed
+		}
+		add(fmt.Sprintf("finished-%d", i), status)
+	}
+	add("queued", JobQueued)
+	add("running", JobRunning)
+
+	q.pruneFinished()
+
+	if len(q.order) != maxFinishedJobs+2 || len(q.jobs) != len(q.order) {
+		t.Fatalf("kept %d jobs in order and %d in the map, want %d", len(q.order), len(q.jobs), maxFinishedJobs+2)
+	}
+	if _, ok := q.jobs["finished-9"]; ok {
+		t.Error("the oldest finished jobs were kept")
+	}
+	if _, ok := q.jobs[fmt.Sprintf("finished-%d", maxFinishedJobs+9)]; !ok {
+		t.Error("the newest finished job was dropped")
+	}
+	for _, id := range []string{"queued", "running"} {
+		if _, ok := q.jobs[id]; !ok {
+			t.Errorf("the unfinished job %s was dropped", id)
+		}
+	}
+}
+
+func TestRESTDownloadRejectsInvalidHash(t *testing.T) {
+	q := &JobQueue{jobs: make(map[string]*Job), pending: make(chan *Job, 1)}
+	mux := http.NewServeMux()
+	registerREST(mux, q)
+
+	for _, hash := range []string{"abc", "0123456789abcdef0123456789abcdef&domain_index=1", "../0123456789abcdef0123456789abcd"} {
+		body := fmt.Sprintf(`{"hash": %q, "format": "epub"}`, hash)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/download", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /download with hash %q returned %d, want %d", hash, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if len(q.pending) != 0 {
+		t.Error("a download with an invalid hash was queued")
+	}
+}
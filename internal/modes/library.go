@@ -0,0 +1,528 @@
+package modes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/lockfile"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// LibraryPath returns the location of the library index. It defaults to a
+// hidden file in the download directory, or to the user configuration
+// directory for remote storage backends.
+func (e *Env) LibraryPath() string {
+	return libraryPath(e.DownloadPath)
+}
+
+func libraryPath(downloadPath string) string {
+	if path := os.Getenv("ANNAS_LIBRARY_INDEX"); path != "" {
+		return path
+	}
+	if downloadPath != "" {
+		return filepath.Join(downloadPath, ".annas-library.json")
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "annas-mcp", "library.json")
+}
+
+// serverLockPath returns the lock held by the server using the configured
+// library, next to the library index.
+func serverLockPath() string {
+	return filepath.Join(filepath.Dir(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH"))), ".annas-mcp.lock")
+}
+
+// downloadOptions are the choices of the caller of a download.
+type downloadOptions struct {
+	// allowDuplicateFormats downloads the book even if the library already
+	// holds the same work in another format.
+	allowDuplicateFormats bool
+	// supplements also downloads the supplementary files listed on the
+	// detail page, into a folder next to the book.
+	supplements bool
+	// root is the directory of a filesystem root of the client that the
+	// book is saved in, instead of the download directory.
+	root string
+}
+
+// downloadToLibrary downloads a book to the storage of the given scope and
+// records it in the library index. Unless allowDuplicateFormats is set, it
+// returns a *library.DuplicateError instead if the library already holds the
+// same work in another format. Every outcome is recorded in the audit log, if
+// one is configured, with the requester attached to the context.
+func downloadToLibrary(ctx context.Context, env *Env, store storage.Storage, book *anna.Book, scope string, opts downloadOptions) (result *anna.DownloadResult, err error) {
+	l := logger.GetLogger()
+
+	defer func() {
+		auditDownload(ctx, env, book, scope, result, err)
+	}()
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	client := env.Client()
+
+	// Downloads only carry the hash, title, and format, so the authors and
+	// the size the download is checked against come from the detail page. The
+	// download works without them, but the fingerprint then falls back to the
+	// title alone.
+	metadata := *book
+	var details *anna.BookDetails
+	if metadata.Authors == "" || opts.supplements {
+		if fetched, err := client.GetBook(ctx, book.Hash); err != nil {
+			l.Warn("Failed to fetch book details for the library index",
+				zap.String("bookHash", book.Hash),
+				zap.Error(err),
+			)
+		} else {
+			details = fetched
+		}
+	}
+	if details != nil && metadata.Authors == "" {
+		metadata.Authors = details.Book.Authors
+		metadata.AuthorList = details.Book.AuthorList
+		metadata.Language = details.Book.Language
+		metadata.Year = details.Book.Year
+		metadata.Size = details.Book.Size
+		metadata.SizeBytes = details.Book.SizeBytes
+		if metadata.Title == "" {
+			metadata.Title = details.Book.Title
+		}
+	}
+
+	if !opts.allowDuplicateFormats {
+		for _, existing := range idx.SameWork(scope, metadata.Title, metadata.Authors) {
+			if existing.Hash != book.Hash && !strings.EqualFold(existing.Format, book.Format) {
+				l.Info("Skipping download of a book already in the library",
+					zap.String("bookHash", book.Hash),
+					zap.String("existingHash", existing.Hash),
+					zap.String("existingFormat", existing.Format),
+				)
+				return nil, &library.DuplicateError{Existing: existing}
+			}
+		}
+	}
+
+	// Downloads to the roots of the client are always stored locally.
+	switch {
+	case opts.root != "":
+		err = checkFreeSpace(opts.root, metadata.Bytes())
+	case env.Storage.Backend == "" || env.Storage.Backend == storage.BackendLocal:
+		err = checkFreeSpace(scopedDownloadDir(env, scope), metadata.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request or instance may be downloading the same book, in
+	// which case its download is reused once it is done.
+	requested := time.Now()
+	lock, err := lockfile.Acquire(filepath.Join(filepath.Dir(env.LibraryPath()), ".annas-locks", book.Hash+".lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if err := idx.Refresh(); err != nil {
+		l.Warn("Failed to refresh the library index", zap.Error(err))
+	} else if entry, ok := idx.Entry(scope, book.Hash); ok && entry.DownloadedAt.After(requested) {
+		l.Info("Reusing a concurrent download of the book",
+			zap.String("bookHash", book.Hash),
+			zap.String("location", entry.Location),
+		)
+		return &anna.DownloadResult{
+			Location:        entry.Location,
+			Size:            entry.Size,
+			Format:          entry.Format,
+			Transform:       entry.Transform,
+			ArchiveMember:   entry.ArchiveMember,
+			Archive:         entry.Archive,
+			SizeMismatch:    entry.SizeMismatch,
+			Pages:           entry.Pages,
+			TextLayer:       entry.TextLayer,
+			QualityWarnings: entry.QualityWarnings,
+			Copies:          entry.Copies,
+		}, nil
+	}
+
+	result, err = downloadWithFallback(ctx, env, client, &metadata, store)
+	if err != nil {
+		return nil, err
+	}
+	fetchedHash := book.Hash
+	if result.FetchedHash != "" {
+		fetchedHash = result.FetchedHash
+	}
+	if len(env.Deliveries) > 0 {
+		result.Copies = deliver(env, result.Location)
+	}
+	if opts.supplements && details != nil && len(details.Supplements) > 0 {
+		// The book is stored already, so failed supplements are only
+		// reported.
+		supplements, err := client.DownloadSupplements(ctx, details.Supplements, anna.SupplementsFolder(result.Name), store)
+		if err != nil {
+			l.Warn("Failed to download supplementary files",
+				zap.String("bookHash", book.Hash),
+				zap.Error(err),
+			)
+		}
+		result.Supplements = supplements
+		// The supplements count against the quota too, so the latest quota
+		// is theirs.
+		for _, supplement := range supplements {
+			if supplement.Quota != nil {
+				result.Quota = supplement.Quota
+			}
+		}
+	}
+
+	inspectDownload(env, result)
+
+	entry := library.Entry{
+		Hash:            fetchedHash,
+		Title:           metadata.Title,
+		Authors:         metadata.Authors,
+		Format:          result.Format,
+		Language:        metadata.Language,
+		Year:            metadata.Year,
+		Location:        result.Location,
+		Size:            result.Size,
+		Transform:       result.Transform,
+		ArchiveMember:   result.ArchiveMember,
+		Archive:         result.Archive,
+		SizeMismatch:    result.SizeMismatch,
+		Pages:           result.Pages,
+		TextLayer:       result.TextLayer,
+		QualityWarnings: result.QualityWarnings,
+		Copies:          result.Copies,
+		Scope:           scope,
+		DownloadedAt:    time.Now(),
+	}
+	if err := idx.Add(entry); err != nil {
+		// The file is stored already, so a stale index is not worth failing
+		// the download over.
+		l.Warn("Failed to update the library index",
+			zap.String("bookHash", book.Hash),
+			zap.Error(err),
+		)
+	}
+
+	if env.FullText {
+		indexContent(entry)
+	}
+	if env.NotesDir != "" {
+		// Descriptions are only known when the details were fetched, notes
+		// are written without them otherwise.
+		noteBook := &metadata
+		if details != nil {
+			noteBook = details.Book
+		}
+		if path, err := writeBookNote(env.NotesDir, noteBook, entry, env.Filenames); err != nil {
+			l.Warn("Failed to write the note of the book",
+				zap.String("bookHash", book.Hash),
+				zap.Error(err),
+			)
+		} else {
+			l.Info("Book note written", zap.String("path", path))
+		}
+	}
+
+	return result, nil
+}
+
+// ErrInsufficientSpace is returned for downloads that do not fit on the
+// filesystem of the download directory.
+var ErrInsufficientSpace = errors.New("not enough free disk space")
+
+// scopedDownloadDir returns the local download directory of the scope.
+func scopedDownloadDir(env *Env, scope string) string {
+	if scope != "" {
+		return env.Storage.Sub(scope).LocalPath
+	}
+
+	return env.Storage.LocalPath
+}
+
+// checkFreeSpace fails if a download of the given size would not fit in the
+// local directory it goes to. Downloads of unknown size, or to filesystems
+// that cannot be queried, are let through.
+func checkFreeSpace(dir string, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+
+	// The download directory may not exist before the first download.
+	for !dirExists(dir) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		logger.GetLogger().Warn("Failed to check the free disk space", zap.String("path", dir), zap.Error(err))
+		return nil
+	}
+	if free < size {
+		return fmt.Errorf("%w: %s needed, %s free in %s", ErrInsufficientSpace, formatByteSize(size), formatByteSize(free), dir)
+	}
+
+	return nil
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// defaultDownloadFallbacks is the number of other copies tried when
+// ANNAS_DOWNLOAD_FALLBACKS is not set.
+const defaultDownloadFallbacks = 2
+
+// downloadWithFallback downloads the book, and if its file cannot be reached
+// tries up to env.Fallbacks other copies of the same work in the same format,
+// taken from a search for its title. The result names the copy that was
+// fetched if it is not the requested one.
+func downloadWithFallback(ctx context.Context, env *Env, client *anna.Client, book *anna.Book, store storage.Storage) (*anna.DownloadResult, error) {
+	l := logger.GetLogger()
+
+	result, err := client.Download(ctx, book, store)
+	if err == nil || env.Fallbacks == 0 || book.Title == "" || !anna.IsDeadLink(err) || ctx.Err() != nil {
+		return result, err
+	}
+
+	l.Warn("Download link is dead, looking for another copy",
+		zap.String("bookHash", book.Hash),
+		zap.Error(err),
+	)
+
+	candidates, searchErr := client.Search(ctx, book.Title)
+	if searchErr != nil {
+		l.Warn("Failed to search for another copy",
+			zap.String("bookHash", book.Hash),
+			zap.Error(searchErr),
+		)
+		return nil, err
+	}
+
+	tried := 0
+	for _, candidate := range candidates {
+		if tried == env.Fallbacks || ctx.Err() != nil {
+			break
+		}
+		if candidate.Hash == book.Hash || !strings.EqualFold(candidate.Format, book.Format) ||
+			!library.IsSameWork(book.Title, book.Authors, candidate.Title, candidate.Authors) {
+			continue
+		}
+		tried++
+
+		// The file keeps the requested title and format, but is checked
+		// against the size of the copy.
+		alternate := *book
+		alternate.Hash = candidate.Hash
+		alternate.Size = candidate.Size
+		alternate.SizeBytes = candidate.SizeBytes
+
+		result, altErr := client.Download(ctx, &alternate, store)
+		if altErr == nil {
+			l.Info("Downloaded another copy of the book",
+				zap.String("bookHash", book.Hash),
+				zap.String("fetchedHash", candidate.Hash),
+			)
+			result.FetchedHash = candidate.Hash
+			return result, nil
+		}
+
+		l.Warn("Failed to download another copy of the book",
+			zap.String("bookHash", book.Hash),
+			zap.String("candidateHash", candidate.Hash),
+			zap.Error(altErr),
+		)
+		if !anna.IsDeadLink(altErr) {
+			return nil, altErr
+		}
+	}
+
+	return nil, err
+}
+
+// libraryScope returns the scope of the library index that searches are
+// recorded in and statistics are computed for. It follows the download
+// scope, but does not need the download settings.
+func libraryScope(ss *mcp.ServerSession, keyScope string) string {
+	return resolveScope(&Env{HTTPScope: os.Getenv("ANNAS_HTTP_SCOPE")}, ss, keyScope)
+}
+
+// recordSearch adds a search to the history. Failures are logged, as the
+// history is not worth failing a search over.
+func recordSearch(scope, query string, books []*anna.Book) {
+	l := logger.GetLogger()
+
+	idx, err := library.Open(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err == nil {
+		hashes := make([]string, 0, len(books))
+		for _, book := range books {
+			hashes = append(hashes, book.Hash)
+		}
+
+		err = idx.AddSearch(library.Search{
+			Query:       query,
+			Scope:       scope,
+			Results:     hashes,
+			ResultCount: len(books),
+			SearchedAt:  time.Now(),
+		})
+	}
+	if err != nil {
+		l.Warn("Failed to record search in the history",
+			zap.String("searchTerm", query),
+			zap.Error(err),
+		)
+	}
+}
+
+// SearchHistory returns the most recent searches of the scope, newest first.
+func SearchHistory(scope string, limit int) ([]library.Search, error) {
+	idx, err := library.Open(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.Searches(scope, limit), nil
+}
+
+// formatHistory renders searches with the titles of the downloaded results.
+func formatHistory(searches []library.Search, scope string) string {
+	if len(searches) == 0 {
+		return "No searches recorded."
+	}
+
+	idx, _ := library.Open(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+
+	var sb strings.Builder
+	for i, search := range searches {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s: %q, %d results\n", search.SearchedAt.Local().Format("2006-01-02 15:04"), search.Query, search.ResultCount)
+		if len(search.Downloaded) == 0 {
+			sb.WriteString("  No result downloaded\n")
+		}
+		for _, hash := range search.Downloaded {
+			title := ""
+			if idx != nil {
+				if entry, ok := idx.Entry(scope, hash); ok {
+					title = fmt.Sprintf("%s (%s) ", entry.Title, entry.Format)
+				}
+			}
+			fmt.Fprintf(&sb, "  Downloaded %shash %s\n", title, hash)
+		}
+	}
+
+	return sb.String()
+}
+
+// defaultHistoryLimit is the number of searches listed when no limit is
+// given.
+const defaultHistoryLimit = 20
+
+func historyLimit(limit int) int {
+	if limit <= 0 {
+		return defaultHistoryLimit
+	}
+
+	return limit
+}
+
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+// LibraryStats returns the statistics of the downloaded books of the scope.
+func LibraryStats(scope string) (library.Stats, error) {
+	idx, err := library.Open(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return library.Stats{}, err
+	}
+
+	return idx.Stats(scope), nil
+}
+
+// maxStatsAuthors bounds the authors listed in the statistics text, the
+// structured content has all of them.
+const maxStatsAuthors = 10
+
+func formatStats(stats library.Stats) string {
+	if stats.Books == 0 {
+		return "The library is empty."
+	}
+
+	months := make([]string, 0, len(stats.ByMonth))
+	for month := range stats.ByMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	perMonth := make([]string, 0, len(months))
+	for _, month := range months {
+		perMonth = append(perMonth, fmt.Sprintf("%s %d", month, stats.ByMonth[month]))
+	}
+
+	authors := countsByFrequency(stats.ByAuthor)
+	if len(authors) > maxStatsAuthors {
+		authors = authors[:maxStatsAuthors]
+	}
+
+	return fmt.Sprintf("Books: %d\nSize on disk: %s\nFormats: %s\nLanguages: %s\nTop authors: %s\nDownloads per month: %s\n",
+		stats.Books,
+		formatByteSize(stats.TotalSize),
+		strings.Join(countsByFrequency(stats.ByFormat), ", "),
+		strings.Join(countsByFrequency(stats.ByLanguage), ", "),
+		strings.Join(authors, ", "),
+		strings.Join(perMonth, ", "),
+	)
+}
+
+// countsByFrequency renders counts as "key count", most frequent first.
+func countsByFrequency(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	rendered := make([]string, 0, len(keys))
+	for _, key := range keys {
+		rendered = append(rendered, fmt.Sprintf("%s %d", key, counts[key]))
+	}
+
+	return rendered
+}
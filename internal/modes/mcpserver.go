@@ -2,47 +2,537 @@ package modes
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
-	"github.com/iosifache/annas-mcp/internal/anna"
+	"github.com/iosifache/annas-mcp/internal/i18n"
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/lockfile"
 	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
 	"github.com/iosifache/annas-mcp/internal/version"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"go.uber.org/zap"
 )
 
 func SearchTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[SearchParams]) (*mcp.CallToolResultFor[any], error) {
+	return searchBooks(ctx, cc, params, "")
+}
+
+// scopedSearchTool returns a search handler that records the searches in the
+// history of the scope derived from the credentials of an HTTP client.
+func scopedSearchTool(keyScope string) mcp.ToolHandlerFor[SearchParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[SearchParams]) (*mcp.CallToolResultFor[any], error) {
+		return searchBooks(ctx, cc, params, keyScope)
+	}
+}
+
+func searchBooks(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[SearchParams], keyScope string) (*mcp.CallToolResultFor[any], error) {
 	l := logger.GetLogger()
 
+	args := params.Arguments
+	searchTerm := anna.FieldQuery{
+		Term:      args.SearchTerm,
+		Title:     args.Title,
+		Author:    args.Author,
+		Publisher: args.Publisher,
+	}.String()
+
 	l.Info("Search command called",
-		zap.String("searchTerm", params.Arguments.SearchTerm),
+		zap.String("searchTerm", searchTerm),
 	)
 
-	books, err := anna.FindBook(params.Arguments.SearchTerm)
+	if searchTerm == "" && args.OriginalTitle == "" && args.OriginalAuthor == "" {
+		err := errors.New("a term, title, author, or publisher is required")
+		l.Error("Search command failed", zap.Error(err))
+		return nil, err
+	}
+	filter, err := searchFilter(args.ExcludeFormats, args.YearFrom, args.YearTo, args.MinSize, args.MaxSize)
+	if err != nil {
+		l.Error("Search command failed", zap.Error(err))
+		return nil, err
+	}
+	if args.Romanize || args.OriginalTitle != "" || args.OriginalAuthor != "" {
+		return scriptSearchBooks(ctx, cc, params, keyScope, filter)
+	}
+
+	// Clients asking for progress get every hit as a notification as soon as
+	// it is parsed, before the full result list.
+	onBook := func(*anna.Book) {}
+	if token := params.GetProgressToken(); token != nil {
+		found := 0
+		onBook = func(book *anna.Book) {
+			if ok, _ := filter.Matches(book); !ok {
+				return
+			}
+			found++
+			err := cc.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+				ProgressToken: token,
+				Progress:      float64(found),
+				Message:       tr("Found %s by %s (%s, %s), hash %s", book.Title, book.Authors, book.Format, book.Size, book.Hash),
+			})
+			if err != nil {
+				l.Warn("Failed to send search progress", zap.Error(err))
+			}
+		}
+	}
+
+	books, err := GetClient().SearchStream(ctx, searchTerm, onBook)
 	if err != nil {
 		l.Error("Search command failed",
-			zap.String("searchTerm", params.Arguments.SearchTerm),
+			zap.String("searchTerm", searchTerm),
 			zap.Error(err),
 		)
 		return nil, err
 	}
+	books, excluded := filter.Apply(books)
+
+	recordSearch(libraryScope(cc, keyScope), searchTerm, books)
+
+	if len(books) == 0 {
+		l.Info("Search command completed successfully",
+			zap.String("searchTerm", searchTerm),
+			zap.Int("resultsCount", 0),
+		)
+		suggestions := searchSuggestions(anna.FieldQuery{Term: args.SearchTerm, Title: args.Title, Author: args.Author, Publisher: args.Publisher}, excluded)
+		return noResultsResult(suggestions), nil
+	}
+
+	render := resultRenderer()
+	full := make([]string, 0, len(books))
+	compact := make([]string, 0, len(books))
+	for i, book := range books {
+		full = append(full, render(resultItem{Book: book, Index: i + 1}, book.String()+"\n\n"))
+		compact = append(compact, compactBook(book))
+	}
+
+	budget := responseBudget(params.Arguments.MaxResponseTokens)
+	bookList, shown, compacted := fitToBudget(full, compact, budget)
+	bookList += truncationNote(shown, len(books), compacted, budget)
+	bookList += exclusionNote(excluded)
+
+	l.Info("Search command completed successfully",
+		zap.String("searchTerm", searchTerm),
+		zap.Int("resultsCount", len(books)),
+		zap.Int("shownCount", shown),
+	)
+
+	structured := books[:shown]
+	if compacted {
+		structured = make([]*anna.Book, 0, shown)
+		for _, book := range books[:shown] {
+			structured = append(structured, compactCopy(book))
+		}
+	}
+
+	content := []mcp.Content{&mcp.TextContent{Text: bookList}}
+	if !compacted {
+		// Covers would take more of the budget than the results left out.
+		content = append(content, searchThumbnails(ctx, books)...)
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Meta:              truncationMeta(shown, len(books), compacted),
+		Content:           content,
+		StructuredContent: structured,
+	}, nil
+}
+
+// exclusionNote tells how many results the search filter left out, and why,
+// or returns an empty string if it left out none.
+func exclusionNote(excluded map[string]int) string {
+	if len(excluded) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, count := range excluded {
+		total += count
+	}
+
+	return "\n" + tr("%d results were excluded by the filters: %s.", total, strings.Join(countsByFrequency(excluded), ", ")) + "\n"
+}
+
+// noResults is the structured content of a search without results, which
+// tells agents how they could find the book instead of leaving them with an
+// empty list.
+type noResults struct {
+	Results     []*anna.Book      `json:"results"`
+	Suggestions []anna.Suggestion `json:"suggestions"`
+}
+
+// searchSuggestions returns the ways to recover from a search for q without
+// results, in the interface language. Relaxing the filters comes first when
+// they left out results.
+func searchSuggestions(q anna.FieldQuery, excluded map[string]int) []anna.Suggestion {
+	suggestions := make([]anna.Suggestion, 0)
+	if len(excluded) > 0 {
+		total := 0
+		for _, count := range excluded {
+			total += count
+		}
+		suggestions = append(suggestions, anna.Suggestion{
+			Kind:   anna.SuggestRelaxFilters,
+			Advice: tr("The filters left out %d results (%s), loosen or remove them.", total, strings.Join(countsByFrequency(excluded), ", ")),
+		})
+	}
+
+	lang := interfaceLanguage()
+	for _, suggestion := range anna.Suggest(q) {
+		suggestion.Advice = i18n.T(lang, suggestion.Advice)
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions
+}
+
+func noResultsResult(suggestions []anna.Suggestion) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: formatSuggestions(suggestions)}},
+		StructuredContent: noResults{Results: make([]*anna.Book, 0), Suggestions: suggestions},
+	}
+}
+
+func formatSuggestions(suggestions []anna.Suggestion) string {
+	var sb strings.Builder
+	sb.WriteString(tr("No books found."))
+	if len(suggestions) > 0 {
+		sb.WriteString(" " + tr("To find the book:"))
+	}
+	sb.WriteString("\n")
+	for _, suggestion := range suggestions {
+		sb.WriteString("- " + suggestion.Advice)
+		if suggestion.Query != "" {
+			sb.WriteString(" " + tr("Query: %s", suggestion.Query))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// maxSearchThumbnails bounds ANNAS_SEARCH_THUMBNAILS, so a search result
+// stays small enough for clients to display.
+const maxSearchThumbnails = 10
+
+// searchThumbnails returns cover thumbnails of the first books as image
+// content, as many as ANNAS_SEARCH_THUMBNAILS asks for. Covers that cannot be
+// fetched in time are left out.
+func searchThumbnails(ctx context.Context, books []*anna.Book) []mcp.Content {
+	l := logger.GetLogger()
+
+	count, err := strconv.Atoi(os.Getenv("ANNAS_SEARCH_THUMBNAILS"))
+	if err != nil || count <= 0 {
+		return nil
+	}
+	count = min(count, maxSearchThumbnails, len(books))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client := GetClient()
+	thumbnails := make([][]byte, count)
+
+	var wg sync.WaitGroup
+	for i, book := range books[:count] {
+		wg.Add(1)
+		go func(i int, book *anna.Book) {
+			defer wg.Done()
+
+			thumbnail, err := client.Thumbnail(ctx, book)
+			if err != nil {
+				l.Info("Skipping thumbnail",
+					zap.String("bookHash", book.Hash),
+					zap.Error(err),
+				)
+				return
+			}
+			thumbnails[i] = thumbnail
+		}(i, book)
+	}
+	wg.Wait()
+
+	// Each image follows a caption, as image content carries no reference to
+	// the book it belongs to.
+	content := make([]mcp.Content, 0, 2*count)
+	for i, thumbnail := range thumbnails {
+		if thumbnail == nil {
+			continue
+		}
+		content = append(content,
+			&mcp.TextContent{Text: tr("Cover of %s (hash %s):", books[i].Title, books[i].Hash)},
+			&mcp.ImageContent{Data: thumbnail, MIMEType: "image/jpeg"},
+		)
+	}
+
+	return content
+}
+
+func DeepSearchTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[DeepSearchParams]) (*mcp.CallToolResultFor[any], error) {
+	return deepSearchBooks(ctx, cc, params, "")
+}
+
+func scopedDeepSearchTool(keyScope string) mcp.ToolHandlerFor[DeepSearchParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[DeepSearchParams]) (*mcp.CallToolResultFor[any], error) {
+		return deepSearchBooks(ctx, cc, params, keyScope)
+	}
+}
+
+func deepSearchBooks(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[DeepSearchParams], keyScope string) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	args := params.Arguments
+	l.Info("Deep search command called",
+		zap.String("title", args.Title),
+		zap.String("author", args.Author),
+		zap.String("isbn", args.ISBN),
+	)
 
-	bookList := ""
+	books, duplicates, err := GetClient().DeepSearch(ctx, args.Title, args.Author, args.ISBN)
+	if err != nil {
+		l.Error("Deep search command failed",
+			zap.String("title", args.Title),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	found := make([]*anna.Book, 0, len(books))
 	for _, book := range books {
-		bookList += book.String() + "\n\n"
+		found = append(found, book.Book)
+	}
+	recordSearch(libraryScope(cc, keyScope), strings.Join(nonEmpty(args.Title, args.Author, args.ISBN), " "), found)
+
+	if len(books) == 0 {
+		l.Info("Deep search command completed successfully",
+			zap.String("title", args.Title),
+			zap.Int("resultsCount", 0),
+		)
+		return noResultsResult(searchSuggestions(anna.FieldQuery{Title: args.Title, Author: args.Author}, nil)), nil
+	}
+
+	result, shown := rankedResult(books, duplicates, nil, args.MaxResponseTokens)
+
+	l.Info("Deep search command completed successfully",
+		zap.String("title", args.Title),
+		zap.Int("resultsCount", len(books)),
+		zap.Int("shownCount", shown),
+		zap.Int("duplicatesCount", duplicates),
+	)
+
+	return result, nil
+}
+
+// scriptSearchBooks runs a search across scripts: the query as given, in the
+// original script if given, and romanized, with the results merged and
+// ranked like those of deep searches.
+func scriptSearchBooks(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[SearchParams], keyScope string, filter *anna.ResultFilter) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	args := params.Arguments
+	fields := anna.FieldQuery{Term: args.SearchTerm, Title: args.Title, Author: args.Author, Publisher: args.Publisher}
+	variants := scriptVariants(fields, args.OriginalTitle, args.OriginalAuthor)
+	searchTerm := variants[0].String()
+
+	ranked, duplicates, err := GetClient().ScriptSearch(ctx, variants)
+	if err != nil {
+		l.Error("Search command failed",
+			zap.String("searchTerm", searchTerm),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	books := make([]*anna.RankedBook, 0, len(ranked))
+	found := make([]*anna.Book, 0, len(ranked))
+	excluded := make(map[string]int)
+	for _, book := range ranked {
+		if ok, reason := filter.Matches(book.Book); !ok {
+			excluded[reason]++
+			continue
+		}
+		books = append(books, book)
+		found = append(found, book.Book)
+	}
+
+	recordSearch(libraryScope(cc, keyScope), searchTerm, found)
+
+	if len(books) == 0 {
+		l.Info("Search command completed successfully",
+			zap.String("searchTerm", searchTerm),
+			zap.Int("variantsCount", len(variants)),
+			zap.Int("resultsCount", 0),
+		)
+		return noResultsResult(searchSuggestions(fields, excluded)), nil
 	}
 
+	result, shown := rankedResult(books, duplicates, excluded, args.MaxResponseTokens)
+
 	l.Info("Search command completed successfully",
-		zap.String("searchTerm", params.Arguments.SearchTerm),
+		zap.String("searchTerm", searchTerm),
+		zap.Int("variantsCount", len(variants)),
 		zap.Int("resultsCount", len(books)),
+		zap.Int("shownCount", shown),
+		zap.Int("duplicatesCount", duplicates),
 	)
 
+	return result, nil
+}
+
+// rankedResult lists merged search results within the response budget, with
+// their scores and the query variants that found them, and returns the
+// number of books shown.
+func rankedResult(books []*anna.RankedBook, duplicates int, excluded map[string]int, maxTokens int) (*mcp.CallToolResultFor[any], int) {
+	render := resultRenderer()
+	full := make([]string, 0, len(books))
+	compact := make([]string, 0, len(books))
+	for i, book := range books {
+		verbose := fmt.Sprintf("%s\nScore: %.2f\nMatched queries: %s\n\n", book.String(), book.Score, strings.Join(book.MatchedQueries, "; "))
+		full = append(full, render(resultItem{Book: book.Book, Index: i + 1, Score: book.Score, MatchedQueries: book.MatchedQueries}, verbose))
+		compact = append(compact, compactBook(book.Book))
+	}
+
+	budget := responseBudget(maxTokens)
+	bookList, shown, compacted := fitToBudget(full, compact, budget)
+	bookList += truncationNote(shown, len(books), compacted, budget)
+	if duplicates > 0 {
+		bookList += "\n" + tr("%d results found by several query variants were merged.", duplicates) + "\n"
+	}
+	bookList += exclusionNote(excluded)
+
+	structured := books[:shown]
+	if compacted {
+		structured = make([]*anna.RankedBook, 0, shown)
+		for _, book := range books[:shown] {
+			structured = append(structured, &anna.RankedBook{Book: compactCopy(book.Book), Score: book.Score, MatchedQueries: book.MatchedQueries})
+		}
+	}
+
 	return &mcp.CallToolResultFor[any]{
+		Meta:              truncationMeta(shown, len(books), compacted),
 		Content:           []mcp.Content{&mcp.TextContent{Text: bookList}},
-		StructuredContent: books,
-	}, nil
+		StructuredContent: structured,
+	}, shown
+}
+
+// builtQueryResult is the structured content of the build_query tool.
+type builtQueryResult struct {
+	*anna.BuiltQuery
+	Results []*anna.Book `json:"results"`
+	// Excluded counts the results that did not pass the filters, by reason.
+	Excluded map[string]int `json:"excluded,omitempty"`
+	// Suggestions are ways to find the book if no result was kept.
+	Suggestions []anna.Suggestion `json:"suggestions,omitempty"`
+}
+
+// scopedBuildQueryTool returns a handler translating structured search
+// criteria into the query sent to Anna's Archive, and running it. The search
+// is recorded in the scope derived from the credentials of an HTTP client.
+func scopedBuildQueryTool(keyScope string) mcp.ToolHandlerFor[BuildQueryParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[BuildQueryParams]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+
+		args := params.Arguments
+		l.Info("Build query command called",
+			zap.String("title", args.Title),
+			zap.String("author", args.Author),
+		)
+
+		query, err := anna.BuildQuery(anna.QueryIntent{
+			Title:    args.Title,
+			Author:   args.Author,
+			YearFrom: args.YearFrom,
+			YearTo:   args.YearTo,
+			Format:   args.Format,
+		})
+		if err != nil {
+			l.Error("Build query command failed", zap.Error(err))
+			return nil, err
+		}
+
+		books, err := GetClient().Search(ctx, query.Query)
+		if err != nil {
+			l.Error("Build query command failed",
+				zap.String("searchTerm", query.Query),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+
+		result := builtQueryResult{
+			BuiltQuery: query,
+			Results:    make([]*anna.Book, 0, len(books)),
+			Excluded:   make(map[string]int),
+		}
+		for _, book := range books {
+			if ok, reason := query.Matches(book); ok {
+				result.Results = append(result.Results, book)
+			} else {
+				result.Excluded[reason]++
+			}
+		}
+		recordSearch(libraryScope(cc, keyScope), query.Query, result.Results)
+		if len(result.Results) == 0 {
+			result.Suggestions = searchSuggestions(anna.FieldQuery{Title: args.Title, Author: args.Author}, result.Excluded)
+		}
+
+		l.Info("Build query command completed successfully",
+			zap.String("searchTerm", query.Query),
+			zap.Int("resultsCount", len(result.Results)),
+			zap.Int("excludedCount", len(books)-len(result.Results)),
+		)
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatBuiltQuery(result, len(books))}},
+			StructuredContent: result,
+		}, nil
+	}
+}
+
+func formatBuiltQuery(result builtQueryResult, total int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Query: %s\nURL: %s\n", result.Query, result.URL)
+	if len(result.Filters) > 0 {
+		fmt.Fprintf(&sb, "Filtered locally by: %s\n", strings.Join(result.Filters, ", "))
+	}
+	fmt.Fprintf(&sb, "%d of %d results kept\n", len(result.Results), total)
+	if len(result.Excluded) > 0 {
+		fmt.Fprintf(&sb, "Excluded: %s\n", strings.Join(countsByFrequency(result.Excluded), ", "))
+	}
+
+	render := resultRenderer()
+	for i, book := range result.Results {
+		sb.WriteString("\n" + render(resultItem{Book: book, Index: i + 1}, book.String()+"\n"))
+	}
+	if len(result.Suggestions) > 0 {
+		sb.WriteString("\n" + formatSuggestions(result.Suggestions))
+	}
+
+	return sb.String()
 }
 
 func DownloadTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[DownloadParams]) (*mcp.CallToolResultFor[any], error) {
+	return downloadBook(ctx, cc, params, "")
+}
+
+// scopedDownloadTool returns a download handler bound to the scope derived
+// from the credentials of an HTTP client.
+func scopedDownloadTool(keyScope string) mcp.ToolHandlerFor[DownloadParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[DownloadParams]) (*mcp.CallToolResultFor[any], error) {
+		return downloadBook(ctx, cc, params, keyScope)
+	}
+}
+
+func downloadBook(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[DownloadParams], keyScope string) (*mcp.CallToolResultFor[any], error) {
 	l := logger.GetLogger()
 
 	l.Info("Download command called",
@@ -56,9 +546,48 @@ func DownloadTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallTo
 		l.Error("Failed to get environment variables", zap.Error(err))
 		return nil, err
 	}
-	secretKey := env.SecretKey
 	downloadPath := env.DownloadPath
 
+	scope := resolveScope(env, cc, keyScope)
+	opts := downloadOptions{
+		allowDuplicateFormats: params.Arguments.AllowDuplicateFormats,
+		supplements:           params.Arguments.Supplements,
+	}
+
+	var store storage.Storage
+	if root := params.Arguments.Root; root != "" {
+		// Roots of the client are its own directories, so the quota of the
+		// scope does not apply to them.
+		if opts.root, err = downloadRoot(ctx, cc, root); err != nil {
+			l.Error("Download command failed",
+				zap.String("bookHash", params.Arguments.BookHash),
+				zap.String("root", root),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+		downloadPath = opts.root
+		store, err = storage.NewLocal(opts.root)
+	} else {
+		store, err = scopedStorage(env, scope)
+	}
+	if err != nil {
+		l.Error("Failed to initialize storage",
+			zap.String("scope", scope),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	settle, err := sessionBudgets.reserve(env, cc)
+	if err != nil {
+		l.Error("Download command failed",
+			zap.String("bookHash", params.Arguments.BookHash),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
 	title := params.Arguments.Title
 	format := params.Arguments.Format
 	book := &anna.Book{
@@ -67,11 +596,26 @@ func DownloadTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallTo
 		Format: format,
 	}
 
-	err = book.Download(secretKey, downloadPath)
+	result, err := downloadToLibrary(withRequester(ctx, mcpRequester(cc, keyScope)), env, store, book, scope, opts)
+	if err != nil {
+		settle(0, err)
+	} else {
+		settle(result.Size, nil)
+		toolStats.recordQuota(result.Quota)
+	}
+	var duplicate *library.DuplicateError
+	if errors.As(err, &duplicate) {
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{&mcp.TextContent{
+				Text: tr("Skipped download: %s. Set allow_duplicate_formats to download this format anyway.", duplicate.Error()),
+			}},
+		}, nil
+	}
 	if err != nil {
 		l.Error("Download command failed",
 			zap.String("bookHash", params.Arguments.BookHash),
 			zap.String("downloadPath", downloadPath),
+			zap.String("scope", scope),
 			zap.Error(err),
 		)
 		return nil, err
@@ -79,17 +623,567 @@ func DownloadTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallTo
 
 	l.Info("Download command completed successfully",
 		zap.String("bookHash", params.Arguments.BookHash),
-		zap.String("downloadPath", downloadPath),
+		zap.String("location", result.Location),
+		zap.String("scope", scope),
 	)
 
+	text := tr("Book downloaded successfully to path: %s", result.Location)
+	if result.FetchedHash != "" {
+		text += "\n" + tr("Note: the requested copy could not be reached, so the copy with hash %s was downloaded instead", result.FetchedHash)
+	}
+	if result.Transform != "" {
+		text += "\n" + tr("Note: %s", result.Transform)
+	}
+	if result.Archive != "" {
+		text += "\n" + tr("Archive kept at path: %s", result.Archive)
+	}
+	if result.SizeMismatch != "" {
+		text += "\n" + tr("Warning: the file may be truncated, %s", result.SizeMismatch)
+	}
+	if result.Pages > 0 {
+		text += "\n" + tr("Pages: %d", result.Pages)
+		if result.TextLayer != "" {
+			text += ", " + tr("text layer: %s", result.TextLayer)
+		}
+	}
+	for _, warning := range result.QualityWarnings {
+		text += "\n" + tr("Warning: possibly a bad copy, %s", warning)
+	}
+	if len(result.Copies) > 0 {
+		text += "\n" + tr("Also delivered to: %s", strings.Join(result.Copies, ", "))
+	}
+	for _, supplement := range result.Supplements {
+		text += "\n" + tr("Supplementary file downloaded to: %s", supplement.Location)
+	}
+	if _, err := os.Stat(result.Location); err == nil && strings.EqualFold(result.Format, "epub") {
+		text += "\n" + tr("Chapters can be read one at a time from the resource %s", chaptersURI(params.Arguments.BookHash))
+	}
+	if summary := result.QuotaSummary(); summary != "" {
+		text += "\n" + summary
+	}
+
 	return &mcp.CallToolResultFor[any]{
 		Content: []mcp.Content{&mcp.TextContent{
-			Text: "Book downloaded successfully to path: " + downloadPath,
+			Text: text,
 		}},
 	}, nil
 }
 
-func StartMCPServer() {
+// listRootsTool lists the filesystem roots of the client that downloads can
+// be saved in.
+func listRootsTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	l.Info("List roots command called")
+
+	roots := make([]Root, 0)
+	if rootsAllowed.Load() {
+		var err error
+		if roots, err = clientRoots.list(ctx, cc); err != nil {
+			l.Error("List roots command failed", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	l.Info("List roots command completed successfully", zap.Int("rootsCount", len(roots)))
+
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: formatRoots(roots)}},
+		StructuredContent: roots,
+	}, nil
+}
+
+// scopedSearchHistoryTool returns a handler listing the searches of the
+// scope derived from the credentials of an HTTP client.
+func scopedSearchHistoryTool(keyScope string) mcp.ToolHandlerFor[HistoryParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[HistoryParams]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+
+		l.Info("Search history command called", zap.Int("limit", params.Arguments.Limit))
+
+		scope := libraryScope(cc, keyScope)
+		searches, err := SearchHistory(scope, historyLimit(params.Arguments.Limit))
+		if err != nil {
+			l.Error("Search history command failed", zap.Error(err))
+			return nil, err
+		}
+
+		l.Info("Search history command completed successfully", zap.Int("resultsCount", len(searches)))
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatHistory(searches, scope)}},
+			StructuredContent: searches,
+		}, nil
+	}
+}
+
+// scopedLibraryStatsTool returns a handler summarizing the library of the
+// scope derived from the credentials of an HTTP client.
+func scopedLibraryStatsTool(keyScope string) mcp.ToolHandlerFor[struct{}, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+
+		l.Info("Library stats command called")
+
+		stats, err := LibraryStats(libraryScope(cc, keyScope))
+		if err != nil {
+			l.Error("Library stats command failed", zap.Error(err))
+			return nil, err
+		}
+
+		l.Info("Library stats command completed successfully", zap.Int("books", stats.Books))
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatStats(stats)}},
+			StructuredContent: stats,
+		}, nil
+	}
+}
+
+// scopedReorganizeTool returns a handler moving the books downloaded in the
+// scope derived from the credentials of an HTTP client to the paths of the
+// organization template.
+func scopedReorganizeTool(keyScope string) mcp.ToolHandlerFor[ReorganizeParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[ReorganizeParams]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+
+		l.Info("Reorganize library command called", zap.Bool("dryRun", params.Arguments.DryRun))
+
+		env, err := GetEnv()
+		if err != nil {
+			l.Error("Failed to get environment variables", zap.Error(err))
+			return nil, err
+		}
+
+		relocations, err := ReorganizeLibrary(env, resolveScope(env, cc, keyScope), params.Arguments.DryRun)
+		if err != nil {
+			l.Error("Reorganize library command failed", zap.Error(err))
+			return nil, err
+		}
+
+		l.Info("Reorganize library command completed successfully", zap.Int("relocations", len(relocations)))
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatRelocations(relocations, params.Arguments.DryRun)}},
+			StructuredContent: relocations,
+		}, nil
+	}
+}
+
+// scopedCleanupTool returns a handler listing, or removing once confirmed, the
+// books downloaded in the scope derived from the credentials of an HTTP
+// client that the retention policy removes.
+func scopedCleanupTool(keyScope string) mcp.ToolHandlerFor[CleanupParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[CleanupParams]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+		args := params.Arguments
+
+		l.Info("Cleanup command called",
+			zap.String("maxSize", args.MaxSize),
+			zap.String("maxAge", args.MaxAge),
+			zap.Bool("confirm", args.Confirm),
+		)
+
+		env, err := GetEnv()
+		if err != nil {
+			l.Error("Failed to get environment variables", zap.Error(err))
+			return nil, err
+		}
+
+		policy, err := retentionPolicy()
+		if err == nil {
+			policy, err = overridePolicy(policy, args.MaxSize, args.MaxAge)
+		}
+		if err != nil {
+			l.Error("Cleanup command failed", zap.Error(err))
+			return nil, err
+		}
+
+		candidates, err := Cleanup(env, resolveScope(env, cc, keyScope), policy, args.Confirm)
+		if err != nil {
+			l.Error("Cleanup command failed", zap.Error(err))
+			return nil, err
+		}
+
+		l.Info("Cleanup command completed successfully", zap.Int("candidates", len(candidates)))
+
+		text := formatCleanup(candidates, args.Confirm)
+		if count, _ := cleanupTotal(candidates); !args.Confirm && count > 0 {
+			text += i18n.T(interfaceLanguage(), "Nothing was removed yet. Ask the user to confirm, then call cleanup again with confirm set.") + "\n"
+		}
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: text}},
+			StructuredContent: candidates,
+		}, nil
+	}
+}
+
+// scopedVerifyTool returns a handler checking the files downloaded in the
+// scope derived from the credentials of an HTTP client against their hashes.
+func scopedVerifyTool(keyScope string) mcp.ToolHandlerFor[VerifyParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[VerifyParams]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+
+		l.Info("Verify local command called", zap.Bool("offline", params.Arguments.Offline))
+
+		env, err := GetEnv()
+		if err != nil {
+			l.Error("Failed to get environment variables", zap.Error(err))
+			return nil, err
+		}
+
+		// Clients asking for progress are told how many files were hashed,
+		// which takes a while for large libraries, once every percent.
+		var progress HashProgress
+		if token := params.GetProgressToken(); token != nil {
+			progress = func(done, total int) {
+				if done < total && done%max(total/100, 1) != 0 {
+					return
+				}
+				err := cc.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+					ProgressToken: token,
+					Progress:      float64(done),
+					Total:         float64(total),
+					Message:       tr("Hashed %d of %d files", done, total),
+				})
+				if err != nil {
+					l.Warn("Failed to send verification progress", zap.Error(err))
+				}
+			}
+		}
+
+		checks, err := VerifyLocal(ctx, env, resolveScope(env, cc, keyScope), params.Arguments.Offline, progress)
+		if err != nil {
+			l.Error("Verify local command failed", zap.Error(err))
+			return nil, err
+		}
+
+		l.Info("Verify local command completed successfully",
+			zap.Int("files", len(checks)),
+			zap.Int("problems", verifyProblems(checks)),
+		)
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatFileChecks(checks)}},
+			StructuredContent: checks,
+		}, nil
+	}
+}
+
+// scopedSearchContentTool returns a handler searching the text of the books
+// downloaded in the scope derived from the credentials of an HTTP client.
+func scopedSearchContentTool(keyScope string) mcp.ToolHandlerFor[ContentSearchParams, any] {
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[ContentSearchParams]) (*mcp.CallToolResultFor[any], error) {
+		l := logger.GetLogger()
+
+		l.Info("Search local content command called", zap.String("query", params.Arguments.Query))
+
+		hits, err := SearchLocalContent(libraryScope(cc, keyScope), params.Arguments.Query, params.Arguments.Limit)
+		if err != nil {
+			l.Error("Search local content command failed",
+				zap.String("query", params.Arguments.Query),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+
+		l.Info("Search local content command completed successfully", zap.Int("resultsCount", len(hits)))
+
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatContentHits(hits)}},
+			StructuredContent: hits,
+		}, nil
+	}
+}
+
+func GetTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[GetParams]) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	l.Info("Get command called", zap.String("bookHash", params.Arguments.BookHash))
+
+	details, err := GetClient().GetBook(ctx, params.Arguments.BookHash)
+	if err != nil {
+		l.Error("Get command failed",
+			zap.String("bookHash", params.Arguments.BookHash),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	l.Info("Get command completed successfully", zap.String("bookHash", params.Arguments.BookHash))
+
+	text := details.Book.String()
+	if len(details.Supplements) > 0 {
+		text += "\n" + tr("Supplementary files, downloaded along with the book when supplements is set:")
+		for _, supplement := range details.Supplements {
+			text += "\n- " + tr("%s, hash %s", supplement.Label, supplement.Hash)
+		}
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: text}},
+		StructuredContent: details.Book,
+	}, nil
+}
+
+func DownloadOptionsTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[GetParams]) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	l.Info("Download options command called", zap.String("bookHash", params.Arguments.BookHash))
+
+	details, err := GetClient().GetBook(ctx, params.Arguments.BookHash)
+	if err != nil {
+		l.Error("Download options command failed",
+			zap.String("bookHash", params.Arguments.BookHash),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	optionList := ""
+	for i, option := range details.DownloadOptions {
+		optionList += fmt.Sprintf("%d. [%s] %s\n   URL: %s\n   Typical wait: %s\n", i+1, option.Kind, option.Label, option.URL, option.TypicalWait)
+		if option.Note != "" {
+			optionList += fmt.Sprintf("   Note: %s\n", option.Note)
+		}
+	}
+	if optionList == "" {
+		optionList = "No download options found."
+	}
+
+	l.Info("Download options command completed successfully",
+		zap.String("bookHash", params.Arguments.BookHash),
+		zap.Int("resultsCount", len(details.DownloadOptions)),
+	)
+
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: optionList}},
+		StructuredContent: details.DownloadOptions,
+	}, nil
+}
+
+func CompareTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[CompareParams]) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	hashes := params.Arguments.Hashes
+	l.Info("Compare command called", zap.Strings("bookHashes", hashes))
+
+	if len(hashes) < 2 {
+		err := errors.New("at least two hashes are needed for a comparison")
+		l.Error("Compare command failed", zap.Strings("bookHashes", hashes), zap.Error(err))
+		return nil, err
+	}
+
+	comparisons := GetClient().CompareBooks(ctx, hashes)
+
+	l.Info("Compare command completed successfully",
+		zap.Strings("bookHashes", hashes),
+		zap.Int("resultsCount", len(comparisons)),
+	)
+
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: formatComparison(comparisons)}},
+		StructuredContent: comparisons,
+	}, nil
+}
+
+// formatComparison renders the comparisons as a table with one column per
+// book.
+func formatComparison(comparisons []*anna.BookComparison) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	rows := []struct {
+		name  string
+		value func(*anna.BookComparison) string
+	}{
+		{"Hash", func(c *anna.BookComparison) string { return c.Hash }},
+		{"Title", func(c *anna.BookComparison) string { return c.Title }},
+		{"Authors", func(c *anna.BookComparison) string { return c.Authors }},
+		{"Language", func(c *anna.BookComparison) string { return c.Language }},
+		{"Format", func(c *anna.BookComparison) string { return c.Format }},
+		{"Size", func(c *anna.BookComparison) string { return c.Size }},
+		{"Year", func(c *anna.BookComparison) string { return c.Year }},
+		{"Sources", func(c *anna.BookComparison) string { return strings.Join(c.Sources, ", ") }},
+		{"Quality hints", func(c *anna.BookComparison) string { return strings.Join(c.QualityHints, ", ") }},
+		{"Error", func(c *anna.BookComparison) string { return c.Error }},
+	}
+
+	for _, row := range rows {
+		cells := []string{row.name}
+		for _, c := range comparisons {
+			value := row.value(c)
+			if value == "" {
+				value = "-"
+			}
+			cells = append(cells, value)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+
+	return sb.String()
+}
+
+func newMCPServer(keyScope string) *mcp.Server {
+	server := mcp.NewServer("annas-mcp", version.GetVersion(), &mcp.ServerOptions{
+		RootsListChangedHandler: clientRoots.invalidate,
+	})
+	server.AddReceivingMiddleware(sessions.middleware, toolStats.middleware)
+
+	tools := []*mcp.ServerTool{
+		annotate(mcp.NewServerTool("search", "Search books", withTimeout(opSearch, scopedSearchTool(keyScope)), mcp.Input(
+			mcp.Property("term", stringProperty("Term to search for, matched against all the metadata")),
+			mcp.Property("title", stringProperty("Words to find in the title only, combined with the term if both are given")),
+			mcp.Property("author", stringProperty("Words to find in the authors only")),
+			mcp.Property("publisher", stringProperty("Words to find in the publisher only")),
+			mcp.Property("exclude_formats", formatListProperty("Formats to leave out of the results, for example djvu or cbr. Defaults to ANNAS_EXCLUDE_FORMATS.")),
+			mcp.Property("year_from", mcp.Description("Earliest publication year, results without a year are left out")),
+			mcp.Property("year_to", mcp.Description("Latest publication year, results without a year are left out")),
+			mcp.Property("min_size", mcp.Description("Smallest file size, for example 500KB, to skip broken files. Results of unknown size are left out.")),
+			mcp.Property("max_size", mcp.Description("Largest file size, for example 200MB, to skip large scans. Results of unknown size are left out.")),
+			mcp.Property("romanize", mcp.Description("Also search the title and author romanized if they are in another script than Latin, such as Cyrillic or Chinese, and merge the results. Many uploads are indexed in a single script.")),
+			mcp.Property("original_title", stringProperty("Title in its original script, searched along with the given one and its romanization, and merged")),
+			mcp.Property("original_author", stringProperty("Author in the original script, searched along with the given one and its romanization, and merged")),
+			mcp.Property("max_response_tokens", mcp.Description("Approximate number of tokens the results may take, shortened to fit")),
+		)), readOnlyTool("Search books")),
+		annotate(mcp.NewServerTool("deep_search", "Search with several query variants (title, title and author, ISBN) at once and return the merged, ranked results. Slower than search, but with better recall for ambiguous requests.", withTimeout(opSearch, scopedDeepSearchTool(keyScope)), mcp.Input(
+			mcp.Property("title", stringProperty("Title of the book")),
+			mcp.Property("author", mcp.Description("Author of the book")),
+			mcp.Property("isbn", mcp.Description("ISBN of the book, resolved from the title and author if omitted")),
+			mcp.Property("max_response_tokens", mcp.Description("Approximate number of tokens the results may take, shortened to fit")),
+		)), readOnlyTool("Deep search")),
+		annotate(mcp.NewServerTool("build_query", "Translate a title, author, year range, and format into the exact query and search URL sent to Anna's Archive, and run it. The year and format are checked on the results, which are returned with the reasons the others were excluded. Useful to understand why a search missed a book.", withTimeout(opSearch, scopedBuildQueryTool(keyScope)), mcp.Input(
+			mcp.Property("title", mcp.Description("Title of the book")),
+			mcp.Property("author", mcp.Description("Author of the book")),
+			mcp.Property("year_from", mcp.Description("Earliest publication year")),
+			mcp.Property("year_to", mcp.Description("Latest publication year")),
+			mcp.Property("format", formatProperty("File format, for example pdf or epub")),
+		)), readOnlyTool("Build query")),
+		// Downloads overwrite files with the same name, so they are not
+		// marked as additive only.
+		annotate(mcp.NewServerTool("download", "Download a book by its MD5 hash. Requires ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables.", withTimeout(opDownload, scopedDownloadTool(keyScope)), mcp.Input(
+			mcp.Property("hash", hashProperty("MD5 hash of the book to download")),
+			mcp.Property("title", stringProperty("Book title, used for filename")),
+			mcp.Property("format", formatProperty("Book format, for example pdf or epub")),
+			mcp.Property("allow_duplicate_formats", mcp.Description("Download even if the library already holds the same book in another format")),
+			mcp.Property("supplements", mcp.Description("Also download the supplementary files listed on the detail page, such as solutions or companion archives, into a folder next to the book. Each of them counts as a download.")),
+			mcp.Property("root", stringProperty("Filesystem root of the client to save the book in instead of the download directory of the server, given by its name, URI, or path, or a directory inside a root. See list_roots.")),
+		)), &mcp.ToolAnnotations{
+			Title:           "Download book",
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(true),
+		}),
+		annotate(mcp.NewServerTool("list_roots", "List the filesystem roots of the client that downloads can be saved in, instead of the download directory of the server", listRootsTool), &mcp.ToolAnnotations{
+			Title:         "List download roots",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("search_history", "List recent searches, newest first, with the results that were downloaded afterwards", scopedSearchHistoryTool(keyScope), mcp.Input(
+			mcp.Property("limit", mcp.Description("Maximum number of searches to return, 20 by default")),
+		)), &mcp.ToolAnnotations{
+			Title:         "Search history",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("library_stats", "Summarize the downloaded books by format, language, author, size on disk, and downloads per month", scopedLibraryStatsTool(keyScope)), &mcp.ToolAnnotations{
+			Title:         "Library statistics",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("server_stats", "Report the uptime of the server, the calls and failures of every tool, the errors by cause, the cache hit rate, and the current rate limits, to find out why calls such as downloads fail", ServerStatsTool), &mcp.ToolAnnotations{
+			Title:         "Server statistics",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("reorganize_library", "Move the downloaded books to the folders of the organization template set in ANNAS_ORGANIZE, for example after changing it", scopedReorganizeTool(keyScope), mcp.Input(
+			mcp.Property("dry_run", mcp.Description("List the moves without making them")),
+		)), &mcp.ToolAnnotations{
+			Title:           "Reorganize library",
+			DestructiveHint: boolPtr(false),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("cleanup", "List the downloads that the retention policy removes to free disk space, the oldest first, and remove them once the user confirms. The library index keeps them, marked as deleted.", scopedCleanupTool(keyScope), mcp.Input(
+			mcp.Property("max_size", mcp.Description("Total size the downloads must fit in, such as 20GB, instead of ANNAS_RETENTION_MAX_SIZE")),
+			mcp.Property("max_age", mcp.Description("How long downloads are kept, such as 90d, instead of ANNAS_RETENTION_MAX_AGE")),
+			mcp.Property("confirm", mcp.Description("Remove the listed downloads, after the user agreed to it. Without it, they are only listed.")),
+		)), &mcp.ToolAnnotations{
+			Title:           "Clean up old downloads",
+			DestructiveHint: boolPtr(true),
+			IdempotentHint:  true,
+			OpenWorldHint:   boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("verify_local", "Hash the files of the download directory and report those that are corrupted, missing, or unknown to the library index and Anna's Archive", scopedVerifyTool(keyScope), mcp.Input(
+			mcp.Property("offline", mcp.Description("Do not look up the files missing from the library index on Anna's Archive")),
+		)), readOnlyTool("Verify downloaded files")),
+		annotate(mcp.NewServerTool("search_local_content", "Search the text of the downloaded EPUB and PDF books offline, to find which of them mention something. Requires ANNAS_FULLTEXT_INDEX=true.", scopedSearchContentTool(keyScope), mcp.Input(
+			mcp.Property("query", stringProperty("Words or quoted phrases to look for in the text of the downloaded books")),
+			mcp.Property("limit", mcp.Description("Maximum number of books to return, 10 by default")),
+		)), &mcp.ToolAnnotations{
+			Title:         "Search downloaded books",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		}),
+		annotate(mcp.NewServerTool("get_book", "Get the full metadata of a book, including its description and table of contents", withTimeout(opMetadata, GetTool), mcp.Input(
+			mcp.Property("hash", hashProperty("MD5 hash of the book")),
+		)), readOnlyTool("Get book details")),
+		annotate(mcp.NewServerTool("list_download_options", "List all download links of a book (partner servers, mirrors, IPFS, torrents) with their typical wait times, for when the download tool fails", withTimeout(opMetadata, DownloadOptionsTool), mcp.Input(
+			mcp.Property("hash", hashProperty("MD5 hash of the book")),
+		)), readOnlyTool("List download options")),
+		annotate(mcp.NewServerTool("compare_books", "Compare two or more books side by side to choose the best copy", withTimeout(opMetadata, CompareTool), mcp.Input(
+			mcp.Property("hashes", mcp.Schema(&jsonschema.Schema{
+				Type:        "array",
+				Description: "MD5 hashes of the books to compare",
+				MinItems:    minimum(2),
+				Items:       &jsonschema.Schema{Type: "string", Pattern: md5SchemaPattern},
+			})),
+		)), readOnlyTool("Compare books")),
+	}
+	localizeTools(tools, interfaceLanguage())
+	server.AddTools(tools...)
+
+	server.AddResources(&mcp.ServerResource{
+		Resource: &mcp.Resource{
+			Name:        "book-schema",
+			Title:       "Book schema",
+			Description: fmt.Sprintf("JSON schema of the books returned by the tools, version %d", anna.BookSchemaVersion),
+			MIMEType:    "application/schema+json",
+			URI:         bookSchemaURI,
+		},
+		Handler: BookSchemaResource,
+	})
+
+	server.AddResourceTemplates(
+		&mcp.ServerResourceTemplate{
+			ResourceTemplate: &mcp.ResourceTemplate{
+				Name:        "chapters",
+				Title:       "Chapters of a downloaded book",
+				Description: "Table of contents of a downloaded EPUB, with the URI of each chapter",
+				MIMEType:    "text/plain",
+				URITemplate: chaptersURITemplate,
+			},
+			Handler: scopedChaptersResource(keyScope),
+		},
+		&mcp.ServerResourceTemplate{
+			ResourceTemplate: &mcp.ResourceTemplate{
+				Name:        "chapter",
+				Title:       "Chapter of a downloaded book",
+				Description: "A single chapter of a downloaded EPUB as plain text, numbered from 1 in reading order",
+				MIMEType:    "text/plain",
+				URITemplate: chapterURITemplate,
+			},
+			Handler: scopedChapterResource(keyScope),
+		},
+	)
+
+	return server
+}
+
+// StartMCPServer serves MCP over stdio, or over HTTP when the transport is
+// "http" (streamable HTTP) or "sse".
+func StartMCPServer(opts ServeOptions) {
 	l := logger.GetLogger()
 	defer l.Sync()
 
@@ -97,24 +1191,83 @@ func StartMCPServer() {
 	l.Info("Starting MCP server",
 		zap.String("name", "annas-mcp"),
 		zap.String("version", serverVersion),
+		zap.String("transport", opts.Transport),
 	)
 
-	server := mcp.NewServer("annas-mcp", serverVersion, nil)
-
-	server.AddTools(
-		mcp.NewServerTool("search", "Search books", SearchTool, mcp.Input(
-			mcp.Property("term", mcp.Description("Term to search for")),
-		)),
-		mcp.NewServerTool("download", "Download a book by its MD5 hash. Requires ANNAS_SECRET_KEY and ANNAS_DOWNLOAD_PATH environment variables.", DownloadTool, mcp.Input(
-			mcp.Property("hash", mcp.Description("MD5 hash of the book to download")),
-			mcp.Property("title", mcp.Description("Book title, used for filename")),
-			mcp.Property("format", mcp.Description("Book format, for example pdf or epub")),
-		)),
-	)
+	auth, err := NewAuthenticatorFromEnv(context.Background())
+	if err != nil {
+		l.Fatal("Failed to configure HTTP authentication", zap.Error(err))
+	}
+
+	// Servers run a download queue with a plan file of their own, so two of
+	// them must not share a library. Instances serving over stdio have no
+	// queue and only coordinate through the library index.
+	var queue *JobQueue
+	if opts.Transport != TransportStdio || opts.GRPCAddr != "" {
+		lock, err := lockfile.TryAcquire(serverLockPath())
+		if err != nil {
+			l.Fatal("Another annas-mcp server is already using this library, stop it or set ANNAS_LIBRARY_INDEX to use another one", zap.Error(err))
+		}
+		defer lock.Release()
+
+		queue = NewJobQueue()
+	}
+	if opts.GRPCAddr != "" {
+		go func() {
+			if err := serveGRPC(opts, queue, auth); err != nil {
+				l.Fatal("gRPC server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	// Clients over stdio run on the machine of the server, while those over
+	// HTTP may expose the roots of another machine.
+	rootsAllowed.Store(opts.Transport == TransportStdio || os.Getenv("ANNAS_HTTP_ROOTS") == "true")
+
+	if opts.Transport == TransportStdio {
+		server := newMCPServer("")
+
+		l.Info("MCP server started successfully")
+
+		if err := server.Run(context.Background(), mcp.NewStdioTransport()); err != nil {
+			l.Fatal("MCP server failed", zap.Error(err))
+		}
+		return
+	}
+
+	// HTTP clients sharing a bearer token share a server instance, so their
+	// tool calls resolve to the same download scope.
+	getServer := newServerCache(maxServers, newMCPServer).get
+
+	var mcpHandler http.Handler
+	switch opts.Transport {
+	case TransportHTTP:
+		mcpHandler = mcp.NewStreamableHTTPHandler(getServer, nil)
+	case TransportSSE:
+		mcpHandler = mcp.NewSSEHandler(getServer)
+	default:
+		l.Fatal("Unknown MCP transport", zap.String("transport", opts.Transport))
+	}
+
+	// The REST API shares the listener, so scripts can reuse the running
+	// instance. Its routes are more specific than the MCP catch-all.
+	mux := http.NewServeMux()
+	mux.Handle("/", mcpHandler)
+	registerREST(mux, queue)
+
+	var handler http.Handler = mux
+	if auth != nil {
+		handler = auth.Middleware(handler)
+	} else {
+		l.Warn("HTTP transport is not protected by authentication, set ANNAS_HTTP_TOKENS or ANNAS_OIDC_ISSUER")
+	}
 
-	l.Info("MCP server started successfully")
+	root := http.NewServeMux()
+	root.HandleFunc("GET /ui", serveWebUI)
+	root.Handle("/", handler)
+	handler = root
 
-	if err := server.Run(context.Background(), mcp.NewStdioTransport()); err != nil {
+	if err := serveHTTP(opts, handler, auth != nil); err != nil {
 		l.Fatal("MCP server failed", zap.Error(err))
 	}
 }
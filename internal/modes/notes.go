@@ -0,0 +1,144 @@
+package modes
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+)
+
+// writeBookNote writes the metadata of a downloaded book as a Markdown note
+// with YAML frontmatter into the notes folder, such as a folder of an
+// Obsidian vault, and returns its path. Notes are named after the title of
+// the book, and a note of another book with the same title gets the start of
+// the hash appended.
+func writeBookNote(dir string, book *anna.Book, entry library.Entry, encoding string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, anna.SanitizeFilename(entry.Title, "md", encoding))
+	if hash, err := noteHash(path); err == nil && hash != entry.Hash {
+		suffix := entry.Hash
+		if len(suffix) > 8 {
+			suffix = suffix[:8]
+		}
+		path = filepath.Join(dir, anna.SanitizeFilename(entry.Title+" "+suffix, "md", encoding))
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, []byte(bookNote(book, entry)), 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// bookNote renders the note of a book. The book holds the metadata scraped
+// from Anna's Archive, and the entry where and when it was downloaded.
+func bookNote(book *anna.Book, entry library.Entry) string {
+	var sb strings.Builder
+
+	sb.WriteString("---\n")
+	frontmatter := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", key, yamlString(value))
+		}
+	}
+	frontmatter("title", entry.Title)
+	if authors := anna.SplitAuthors(entry.Authors, false); len(authors) > 0 {
+		sb.WriteString("authors:\n")
+		for _, author := range authors {
+			fmt.Fprintf(&sb, "  - %s\n", yamlString(anna.DisplayName(author)))
+		}
+	}
+	frontmatter("publisher", book.Publisher)
+	frontmatter("year", entry.Year)
+	frontmatter("language", entry.Language)
+	frontmatter("format", entry.Format)
+	frontmatter("md5", entry.Hash)
+	frontmatter("url", book.URL)
+	frontmatter("file", entry.Location)
+	// Dates are left unquoted, so that Obsidian shows them as dates.
+	if !entry.DownloadedAt.IsZero() {
+		fmt.Fprintf(&sb, "downloaded: %s\n", entry.DownloadedAt.Format("2006-01-02"))
+	}
+	sb.WriteString("tags:\n  - book\n")
+	sb.WriteString("---\n\n")
+
+	fmt.Fprintf(&sb, "# %s\n", entry.Title)
+	if book.Description != "" {
+		sb.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimSpace(book.Description), "\n") {
+			fmt.Fprintf(&sb, "> %s\n", strings.TrimSpace(line))
+		}
+	}
+	if len(book.TOC) > 0 {
+		sb.WriteString("\n## Contents\n\n")
+		for _, chapter := range book.TOC {
+			fmt.Fprintf(&sb, "- %s\n", chapter)
+		}
+	}
+
+	return sb.String()
+}
+
+// yamlString quotes a YAML string. JSON strings are valid YAML, and escape
+// everything that YAML would read as syntax.
+func yamlString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// noteHash returns the MD5 hash in the frontmatter of an existing note.
+func noteHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "md5: "); ok {
+			var hash string
+			if err := json.Unmarshal([]byte(value), &hash); err != nil {
+				return value, nil
+			}
+			return hash, nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// ExportNotes writes a note for every book of the library index of the
+// scope into the notes folder, and returns their paths. Only the metadata of
+// the index is used, as fetching the descriptions of a large library would
+// take long.
+func ExportNotes(env *Env, scope, dir string) ([]string, error) {
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0)
+	for _, entry := range idx.Entries(scope) {
+		book := &anna.Book{Hash: entry.Hash, URL: fmt.Sprintf(anna.AnnasBookEndpoint, entry.Hash)}
+		path, err := writeBookNote(dir, book, entry, env.Filenames)
+		if err != nil {
+			return paths, fmt.Errorf("failed to write the note of %s: %w", entry.Title, err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
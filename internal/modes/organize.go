@@ -0,0 +1,175 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// Relocation describes the move of a downloaded book to the path given by the
+// organization template.
+type Relocation struct {
+	Hash    string `json:"hash"`
+	Title   string `json:"title"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// ReorganizeLibrary moves the downloads of the scope, along with their
+// sidecars, to the paths the configured organization template gives them and
+// updates the library index. Books that are already in place are left out,
+// and nothing is moved with dryRun set. Only the local storage backend can be
+// reorganized.
+func ReorganizeLibrary(env *Env, scope string, dryRun bool) ([]Relocation, error) {
+	l := logger.GetLogger()
+
+	if env.Storage.Backend != "" && env.Storage.Backend != storage.BackendLocal {
+		return nil, errors.New("only downloads to the local filesystem can be reorganized")
+	}
+
+	root := env.Storage.LocalPath
+	if scope != "" {
+		root = env.Storage.Sub(scope).LocalPath
+	}
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	relocations := make([]Relocation, 0)
+	for _, entry := range idx.Entries(scope) {
+		target := filepath.Join(root, filepath.FromSlash(organizedPath(env, entry)))
+		if filepath.Clean(entry.Location) == target {
+			continue
+		}
+
+		relocation := Relocation{
+			Hash:  entry.Hash,
+			Title: entry.Title,
+			From:  entry.Location,
+			To:    target,
+		}
+		if rel, err := filepath.Rel(root, entry.Location); err != nil || strings.HasPrefix(rel, "..") {
+			relocation.Skipped = "outside the download directory"
+		} else if _, err := os.Stat(entry.Location); err != nil {
+			relocation.Skipped = "file is missing"
+		} else if _, err := os.Stat(target); err == nil {
+			relocation.Skipped = "another file is in the way"
+		}
+		if relocation.Skipped != "" || dryRun {
+			relocations = append(relocations, relocation)
+			continue
+		}
+
+		if err := moveDownload(entry.Location, target); err != nil {
+			l.Warn("Failed to move download",
+				zap.String("bookHash", entry.Hash),
+				zap.String("from", entry.Location),
+				zap.String("to", target),
+				zap.Error(err),
+			)
+			relocation.Skipped = err.Error()
+			relocations = append(relocations, relocation)
+			continue
+		}
+		removeEmptyDirs(filepath.Dir(entry.Location), root)
+
+		entry.Location = target
+		if err := idx.Add(entry); err != nil {
+			return relocations, err
+		}
+		if env.FullText {
+			indexContent(entry)
+		}
+
+		relocations = append(relocations, relocation)
+	}
+
+	return relocations, nil
+}
+
+// organizedPath returns the path of a library entry relative to the download
+// directory, under the flat layout if no organization is configured.
+func organizedPath(env *Env, entry library.Entry) string {
+	book := &anna.Book{
+		Hash:     entry.Hash,
+		Title:    entry.Title,
+		Authors:  entry.Authors,
+		Language: entry.Language,
+		Format:   entry.Format,
+		Year:     entry.Year,
+	}
+	if env.Organization == "" {
+		return anna.SanitizeFilename(book.Title, entry.Format, env.Filenames)
+	}
+
+	return anna.OrganizedPath(env.Organization, book, entry.Format, env.Filenames)
+}
+
+// moveDownload moves a downloaded file and the sidecars stored next to it.
+func moveDownload(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+
+	fromBase := strings.TrimSuffix(from, filepath.Ext(from))
+	toBase := strings.TrimSuffix(to, filepath.Ext(to))
+	for _, sidecar := range []string{anna.SidecarOPF, anna.SidecarJSON} {
+		if _, err := os.Stat(fromBase + "." + sidecar); err == nil {
+			if err := os.Rename(fromBase+"."+sidecar, toBase+"."+sidecar); err != nil {
+				logger.GetLogger().Warn("Failed to move sidecar",
+					zap.String("location", fromBase+"."+sidecar),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents up to root as long as they are
+// empty.
+func removeEmptyDirs(dir, root string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+func formatRelocations(relocations []Relocation, dryRun bool) string {
+	if len(relocations) == 0 {
+		return "The library already follows the organization template."
+	}
+
+	verb := "Moved"
+	if dryRun {
+		verb = "Would move"
+	}
+
+	var sb strings.Builder
+	for _, relocation := range relocations {
+		if relocation.Skipped != "" {
+			fmt.Fprintf(&sb, "Skipped %q (%s): %s\n", relocation.Title, relocation.From, relocation.Skipped)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %q\n  from %s\n  to %s\n", verb, relocation.Title, relocation.From, relocation.To)
+	}
+
+	return sb.String()
+}
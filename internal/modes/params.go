@@ -1,11 +1,97 @@
 package modes
 
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+	TransportSSE   = "sse"
+)
+
+type ServeOptions struct {
+	Transport string
+	Addr      string
+
+	TLSCertFile      string
+	TLSKeyFile       string
+	AutocertDomains  []string
+	AutocertCacheDir string
+	AutocertEmail    string
+
+	// GRPCAddr enables the gRPC API on the given address.
+	GRPCAddr string
+}
+
 type SearchParams struct {
-	SearchTerm string `json:"term" mcp:"Term to search for"`
+	SearchTerm     string   `json:"term,omitempty" mcp:"Term to search for"`
+	Title          string   `json:"title,omitempty" mcp:"Words to find in the title"`
+	Author         string   `json:"author,omitempty" mcp:"Words to find in the authors"`
+	Publisher      string   `json:"publisher,omitempty" mcp:"Words to find in the publisher"`
+	ExcludeFormats []string `json:"exclude_formats,omitempty" mcp:"Formats to leave out of the results, for example djvu or cbr"`
+	YearFrom       int      `json:"year_from,omitempty" mcp:"Earliest publication year"`
+	YearTo         int      `json:"year_to,omitempty" mcp:"Latest publication year"`
+	MinSize        string   `json:"min_size,omitempty" mcp:"Smallest file size, for example 500KB"`
+	MaxSize        string   `json:"max_size,omitempty" mcp:"Largest file size, for example 200MB"`
+
+	Romanize       bool   `json:"romanize,omitempty" mcp:"Also search the romanized spelling of a title or author in another script, and merge the results"`
+	OriginalTitle  string `json:"original_title,omitempty" mcp:"Title in its original script, searched along with the given one"`
+	OriginalAuthor string `json:"original_author,omitempty" mcp:"Author in the original script, searched along with the given one"`
+
+	MaxResponseTokens int `json:"max_response_tokens,omitempty" mcp:"Approximate number of tokens the results may take, shortened to fit"`
+}
+
+type BuildQueryParams struct {
+	Title    string `json:"title,omitempty" mcp:"Title of the book"`
+	Author   string `json:"author,omitempty" mcp:"Author of the book"`
+	YearFrom int    `json:"year_from,omitempty" mcp:"Earliest publication year"`
+	YearTo   int    `json:"year_to,omitempty" mcp:"Latest publication year"`
+	Format   string `json:"format,omitempty" mcp:"File format, for example pdf or epub"`
 }
 
 type DownloadParams struct {
 	BookHash string `json:"hash" mcp:"MD5 hash of the book to download"`
 	Title    string `json:"title" mcp:"Book title, used for filename"`
 	Format   string `json:"format" mcp:"Book format, for example pdf or epub"`
+
+	AllowDuplicateFormats bool `json:"allow_duplicate_formats,omitempty" mcp:"Download even if the library already holds the same book in another format"`
+	Supplements           bool `json:"supplements,omitempty" mcp:"Also download the supplementary files listed on the detail page into a folder next to the book"`
+
+	Root string `json:"root,omitempty" mcp:"Filesystem root of the client to save the book in, instead of the download directory of the server"`
+}
+
+type DeepSearchParams struct {
+	Title  string `json:"title" mcp:"Title of the book"`
+	Author string `json:"author,omitempty" mcp:"Author of the book"`
+	ISBN   string `json:"isbn,omitempty" mcp:"ISBN of the book, resolved from the title and author if omitted"`
+
+	MaxResponseTokens int `json:"max_response_tokens,omitempty" mcp:"Approximate number of tokens the results may take, shortened to fit"`
+}
+
+type GetParams struct {
+	BookHash string `json:"hash" mcp:"MD5 hash of the book"`
+}
+
+type CompareParams struct {
+	Hashes []string `json:"hashes" mcp:"MD5 hashes of the books to compare"`
+}
+
+type HistoryParams struct {
+	Limit int `json:"limit,omitempty" mcp:"Maximum number of searches to return, 20 by default"`
+}
+
+type ContentSearchParams struct {
+	Query string `json:"query" mcp:"Words or quoted phrases to look for in the text of the downloaded books"`
+	Limit int    `json:"limit,omitempty" mcp:"Maximum number of books to return, 10 by default"`
+}
+
+type ReorganizeParams struct {
+	DryRun bool `json:"dry_run,omitempty" mcp:"List the moves without making them"`
+}
+
+type CleanupParams struct {
+	MaxSize string `json:"max_size,omitempty" mcp:"Total size the downloads must fit in, such as 20GB, instead of ANNAS_RETENTION_MAX_SIZE"`
+	MaxAge  string `json:"max_age,omitempty" mcp:"How long downloads are kept, such as 90d, instead of ANNAS_RETENTION_MAX_AGE"`
+	Confirm bool   `json:"confirm,omitempty" mcp:"Remove the listed downloads, after the user agreed to it. Without it, they are only listed."`
+}
+
+type VerifyParams struct {
+	Offline bool `json:"offline,omitempty" mcp:"Do not look up the files missing from the library index on Anna's Archive"`
 }
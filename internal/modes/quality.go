@@ -0,0 +1,145 @@
+package modes
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/fulltext"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// Text layers of downloaded PDFs.
+const (
+	TextLayerText = "text"
+	TextLayerOCR  = "ocr"
+	TextLayerNone = "none"
+)
+
+const (
+	// minPlausiblePages is the page count below which a PDF is most likely
+	// a sample or an excerpt.
+	minPlausiblePages = 10
+	// minScanPageSize is the size per page below which a scan is too blurry
+	// to read comfortably.
+	minScanPageSize = 15 << 10
+)
+
+// inspectDownload looks for signs of a bad copy in a downloaded file, and
+// records the page count and text layer of PDFs. Only files on the local
+// filesystem can be inspected, others are only checked for their size.
+func inspectDownload(env *Env, result *anna.DownloadResult) {
+	l := logger.GetLogger()
+
+	if result.Size > 0 && result.Size < anna.MinPlausibleSize {
+		result.QualityWarnings = append(result.QualityWarnings,
+			fmt.Sprintf("suspiciously small file (%s), possibly an error page or a fragment", formatByteSize(result.Size)))
+	}
+	if env.Storage.Backend != "" && env.Storage.Backend != storage.BackendLocal {
+		return
+	}
+
+	switch strings.ToLower(result.Format) {
+	case "pdf":
+		info, err := fulltext.InspectPDF(result.Location)
+		if errors.Is(err, fulltext.ErrEncryptedPDF) {
+			result.QualityWarnings = append(result.QualityWarnings, "encrypted with a password, it may not open")
+			return
+		}
+		if err != nil {
+			l.Warn("Failed to inspect the downloaded PDF",
+				zap.String("location", result.Location),
+				zap.Error(err),
+			)
+			return
+		}
+		result.Pages = info.Pages
+		result.TextLayer = textLayer(info)
+		result.QualityWarnings = append(result.QualityWarnings, pdfWarnings(info, result.TextLayer, result.Size)...)
+	case "epub":
+		if drm, err := epubDRM(result.Location); err != nil {
+			l.Warn("Failed to inspect the downloaded EPUB",
+				zap.String("location", result.Location),
+				zap.Error(err),
+			)
+		} else if drm {
+			result.QualityWarnings = append(result.QualityWarnings, "DRM-protected, it will not open in most readers")
+		}
+	}
+}
+
+// textLayer tells born-digital PDFs from scans, with or without recognized
+// text. Scans have an image on nearly every page.
+func textLayer(info fulltext.PDFInfo) string {
+	if info.Sampled == 0 {
+		return ""
+	}
+	switch {
+	case info.TextPages*2 < info.Sampled:
+		return TextLayerNone
+	case info.ImagePages*5 >= info.Sampled*4:
+		return TextLayerOCR
+	default:
+		return TextLayerText
+	}
+}
+
+func pdfWarnings(info fulltext.PDFInfo, layer string, size int64) []string {
+	warnings := make([]string, 0)
+	switch {
+	case info.Pages == 1:
+		warnings = append(warnings, "a single page, possibly a sample or an excerpt")
+	case info.Pages > 0 && info.Pages < minPlausiblePages:
+		warnings = append(warnings, fmt.Sprintf("only %d pages, possibly a sample or an excerpt", info.Pages))
+	}
+	if layer == TextLayerNone {
+		warnings = append(warnings, "scanned images without a text layer, the text cannot be searched or copied")
+	}
+	if (layer == TextLayerNone || layer == TextLayerOCR) && info.Pages > 0 && size/int64(info.Pages) < minScanPageSize {
+		warnings = append(warnings, fmt.Sprintf("low-resolution scan, %s per page", formatByteSize(size/int64(info.Pages))))
+	}
+	if info.Encrypted {
+		warnings = append(warnings, "encrypted, printing or copying may be restricted")
+	}
+
+	return warnings
+}
+
+// epubDRM reports whether an EPUB is locked with DRM, such as Adobe ADEPT or
+// Apple FairPlay. The font obfuscation allowed by the EPUB standard is not
+// DRM, since readers undo it without a key.
+func epubDRM(filePath string) (bool, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		switch f.Name {
+		case "META-INF/rights.xml", "META-INF/sinf.xml":
+			return true, nil
+		case "META-INF/encryption.xml":
+			rc, err := f.Open()
+			if err != nil {
+				return false, err
+			}
+			data, err := io.ReadAll(io.LimitReader(rc, 1<<20))
+			rc.Close()
+			if err != nil {
+				return false, err
+			}
+			// Obfuscated fonts are listed here too, under another algorithm.
+			if text := string(data); strings.Contains(text, "xmlenc#aes") || strings.Contains(text, "xmlenc#kw-aes") {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,172 @@
+package modes
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iosifache/annas-mcp/internal/fulltext"
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// URI templates of the resources exposing downloaded EPUBs chapter by
+// chapter. Chapters are numbered from 1, in reading order.
+const (
+	chaptersURITemplate = "book://{hash}/chapters"
+	chapterURITemplate  = "book://{hash}/chapter/{n}"
+)
+
+// bookSchemaURI is the resource holding the JSON schema of the books returned
+// by the tools.
+const bookSchemaURI = "schema://book"
+
+// BookSchemaResource returns the JSON schema of the books returned by the
+// tools, so that clients can check the version they were written against.
+func BookSchemaResource(ctx context.Context, ss *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{URI: params.URI, MIMEType: "application/schema+json", Text: anna.BookSchema}},
+	}, nil
+}
+
+func chaptersURI(hash string) string {
+	return "book://" + hash + "/chapters"
+}
+
+func chapterURI(hash string, n int) string {
+	return fmt.Sprintf("book://%s/chapter/%d", hash, n)
+}
+
+// parseBookURI splits a book resource URI into the hash of the book and the
+// path below it, such as "chapters" or "chapter/3".
+func parseBookURI(uri string) (string, string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "book" || parsed.Host == "" {
+		return "", "", mcp.ResourceNotFoundError(uri)
+	}
+
+	return strings.ToLower(parsed.Host), strings.Trim(parsed.Path, "/"), nil
+}
+
+// bookChapters returns the chapters of a downloaded EPUB of the scope.
+func bookChapters(uri, scope, hash string) (library.Entry, []fulltext.Chapter, error) {
+	idx, err := library.Open(libraryPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err != nil {
+		return library.Entry{}, nil, err
+	}
+
+	// Only EPUBs on the local filesystem can be split into chapters.
+	entry, ok := idx.Entry(scope, hash)
+	if !ok || !strings.EqualFold(entry.Format, "epub") {
+		return library.Entry{}, nil, mcp.ResourceNotFoundError(uri)
+	}
+	if _, err := os.Stat(entry.Location); err != nil {
+		return library.Entry{}, nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	chapters, err := fulltext.EPUBChapters(entry.Location)
+	if err != nil {
+		return library.Entry{}, nil, fmt.Errorf("failed to read the chapters of %s: %w", entry.Location, err)
+	}
+
+	return entry, chapters, nil
+}
+
+// scopedChaptersResource returns a handler listing the chapters of a book
+// downloaded in the scope derived from the credentials of an HTTP client.
+func scopedChaptersResource(keyScope string) mcp.ResourceHandler {
+	return func(ctx context.Context, ss *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+		l := logger.GetLogger()
+
+		l.Info("Chapters resource read", zap.String("uri", params.URI))
+
+		hash, rest, err := parseBookURI(params.URI)
+		if err != nil {
+			return nil, err
+		}
+		if rest != "chapters" {
+			return nil, mcp.ResourceNotFoundError(params.URI)
+		}
+
+		entry, chapters, err := bookChapters(params.URI, libraryScope(ss, keyScope), hash)
+		if err != nil {
+			l.Error("Chapters resource read failed",
+				zap.String("uri", params.URI),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s has %d chapters:\n", entry.Title, len(chapters))
+		for i, chapter := range chapters {
+			title := chapter.Title
+			if title == "" {
+				title = "Untitled"
+			}
+			fmt.Fprintf(&sb, "%d. %s (%d words): %s\n", i+1, title, len(strings.Fields(chapter.Text)), chapterURI(hash, i+1))
+		}
+
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{{URI: params.URI, MIMEType: "text/plain", Text: sb.String()}},
+		}, nil
+	}
+}
+
+// scopedChapterResource returns a handler rendering a single chapter of a
+// book downloaded in the scope derived from the credentials of an HTTP
+// client as plain text.
+func scopedChapterResource(keyScope string) mcp.ResourceHandler {
+	return func(ctx context.Context, ss *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+		l := logger.GetLogger()
+
+		l.Info("Chapter resource read", zap.String("uri", params.URI))
+
+		hash, rest, err := parseBookURI(params.URI)
+		if err != nil {
+			return nil, err
+		}
+		number, ok := strings.CutPrefix(rest, "chapter/")
+		if !ok {
+			return nil, mcp.ResourceNotFoundError(params.URI)
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 1 {
+			return nil, mcp.ResourceNotFoundError(params.URI)
+		}
+
+		_, chapters, err := bookChapters(params.URI, libraryScope(ss, keyScope), hash)
+		if err != nil {
+			l.Error("Chapter resource read failed",
+				zap.String("uri", params.URI),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+		if n > len(chapters) {
+			return nil, mcp.ResourceNotFoundError(params.URI)
+		}
+
+		chapter := chapters[n-1]
+		text := chapter.Text
+		if chapter.Title != "" && !strings.HasPrefix(text, chapter.Title) {
+			text = chapter.Title + "\n\n" + text
+		}
+
+		l.Info("Chapter resource read successfully",
+			zap.String("uri", params.URI),
+			zap.Int("size", utf8.RuneCountInString(text)),
+		)
+
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{{URI: params.URI, MIMEType: "text/plain", Text: text}},
+		}, nil
+	}
+}
@@ -0,0 +1,114 @@
+package modes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// restError is the body of failed REST responses.
+type restError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, restError{Error: err.Error()})
+}
+
+// restScope returns the download scope of a REST request.
+func restScope(r *http.Request) string {
+	return clientScope(keyScopeFromRequest(r))
+}
+
+// clientScope returns the download scope of a client without an MCP session,
+// so both session and key scoping fall back to the key scope.
+func clientScope(keyScope string) string {
+	if env, err := GetEnv(); err != nil || env.HTTPScope == ScopeNone || env.HTTPScope == "" {
+		return ""
+	}
+
+	return keyScope
+}
+
+// registerREST adds the REST API for non-MCP clients to the mux. Downloads go
+// through the job queue and are polled with GET /jobs.
+func registerREST(mux *http.ServeMux, queue *JobQueue) {
+	l := logger.GetLogger()
+
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		searchTerm := r.URL.Query().Get("q")
+		if searchTerm == "" {
+			writeError(w, http.StatusBadRequest, errors.New("missing query parameter q"))
+			return
+		}
+
+		l.Info("REST search called", zap.String("searchTerm", searchTerm))
+
+		books, err := GetClient().Search(r.Context(), searchTerm)
+		if err != nil {
+			l.Error("REST search failed",
+				zap.String("searchTerm", searchTerm),
+				zap.Error(err),
+			)
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+
+		recordSearch(restScope(r), searchTerm, books)
+
+		writeJSON(w, http.StatusOK, books)
+	})
+
+	mux.HandleFunc("POST /download", func(w http.ResponseWriter, r *http.Request) {
+		var params DownloadParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if params.BookHash == "" || params.Format == "" {
+			writeError(w, http.StatusBadRequest, errors.New("hash and format are required"))
+			return
+		}
+		if err := anna.CheckHash(params.BookHash); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		job, err := queue.Enqueue(params, restScope(r), Requester{Interface: "rest", Client: keyScopeFromRequest(r)})
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		l.Info("REST download queued",
+			zap.String("jobID", job.ID),
+			zap.String("bookHash", job.Hash),
+		)
+
+		writeJSON(w, http.StatusAccepted, job)
+	})
+
+	mux.HandleFunc("GET /jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, queue.List(restScope(r)))
+	})
+
+	mux.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, ok := queue.Get(r.PathValue("id"), restScope(r))
+		if !ok {
+			writeError(w, http.StatusNotFound, errors.New("job not found"))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, job)
+	})
+}
@@ -0,0 +1,281 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/fulltext"
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// errNoRetentionPolicy is returned by cleanups without a maximum size or age.
+var errNoRetentionPolicy = errors.New("no retention policy is set, set ANNAS_RETENTION_MAX_SIZE or ANNAS_RETENTION_MAX_AGE, or pass a maximum size or age")
+
+// RetentionPolicy bounds the downloads kept in the library. Zero fields
+// leave the library unbounded.
+type RetentionPolicy struct {
+	// MaxSize is the total size of the downloads above which the oldest
+	// ones are removed.
+	MaxSize int64 `json:"max_size,omitempty"`
+	// MaxAge is how long downloads are kept.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+}
+
+// Reasons for removing downloads.
+const (
+	cleanupTooOld   = "older than the maximum age"
+	cleanupOverSize = "over the maximum library size"
+)
+
+// CleanupCandidate is a download the retention policy removes.
+type CleanupCandidate struct {
+	Hash         string    `json:"hash"`
+	Title        string    `json:"title"`
+	Location     string    `json:"location"`
+	Size         int64     `json:"size"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	Reason       string    `json:"reason"`
+	Removed      bool      `json:"removed"`
+	// Skipped tells why the download could not be removed.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// retentionPolicy reads the policy from ANNAS_RETENTION_MAX_SIZE, a size such
+// as "20GB", and ANNAS_RETENTION_MAX_AGE, a duration such as "90d" or
+// "720h".
+func retentionPolicy() (RetentionPolicy, error) {
+	var policy RetentionPolicy
+
+	var err error
+	if policy.MaxSize, err = parseByteSize(os.Getenv("ANNAS_RETENTION_MAX_SIZE")); err != nil {
+		return RetentionPolicy{}, fmt.Errorf("invalid ANNAS_RETENTION_MAX_SIZE: %w", err)
+	}
+	if policy.MaxAge, err = parseAge(os.Getenv("ANNAS_RETENTION_MAX_AGE")); err != nil {
+		return RetentionPolicy{}, fmt.Errorf("invalid ANNAS_RETENTION_MAX_AGE: %w", err)
+	}
+
+	return policy, nil
+}
+
+// parseAge parses durations like parseDuration, and also counts of days
+// such as "90d". An empty string yields zero.
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("expected a number of days such as 90d, got %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return parseDuration(value)
+}
+
+// overridePolicy returns the policy with the maximum size and age replaced by
+// those given, if they are.
+func overridePolicy(policy RetentionPolicy, maxSize, maxAge string) (RetentionPolicy, error) {
+	if maxSize != "" {
+		size, err := parseByteSize(maxSize)
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("invalid max_size: %w", err)
+		}
+		policy.MaxSize = size
+	}
+	if maxAge != "" {
+		age, err := parseAge(maxAge)
+		if err != nil {
+			return RetentionPolicy{}, fmt.Errorf("invalid max_age: %w", err)
+		}
+		policy.MaxAge = age
+	}
+
+	return policy, nil
+}
+
+// Cleanup returns the downloads of the scope that the policy removes: those
+// older than the maximum age, then the oldest of the others until the library
+// fits in the maximum size. With remove set, their files and sidecars are
+// deleted, and their entries are kept in the library index but marked as
+// deleted. Downloads whose file is missing, and books outside the download
+// directory, are left out. Only the local storage backend can be cleaned up.
+func Cleanup(env *Env, scope string, policy RetentionPolicy, remove bool) ([]CleanupCandidate, error) {
+	l := logger.GetLogger()
+
+	if policy.MaxSize <= 0 && policy.MaxAge <= 0 {
+		return nil, errNoRetentionPolicy
+	}
+	if env.Storage.Backend != "" && env.Storage.Backend != storage.BackendLocal {
+		return nil, errors.New("only downloads to the local filesystem can be cleaned up")
+	}
+
+	root := scopedDownloadDir(env, scope)
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	// Books imported from elsewhere are the user's own files, and neither
+	// count towards the size of the library nor are removed.
+	entries := make([]library.Entry, 0)
+	var total int64
+	for _, entry := range idx.Entries(scope) {
+		if rel, err := filepath.Rel(root, entry.Location); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		info, err := os.Stat(entry.Location)
+		if err != nil {
+			continue
+		}
+		entry.Size = info.Size()
+		entries = append(entries, entry)
+		total += entry.Size
+	}
+	slices.SortStableFunc(entries, func(a, b library.Entry) int {
+		return a.DownloadedAt.Compare(b.DownloadedAt)
+	})
+
+	candidates := make([]CleanupCandidate, 0)
+	cutoff := time.Now().Add(-policy.MaxAge)
+	for _, entry := range entries {
+		reason := ""
+		switch {
+		case policy.MaxAge > 0 && entry.DownloadedAt.Before(cutoff):
+			reason = cleanupTooOld
+		case policy.MaxSize > 0 && total > policy.MaxSize:
+			reason = cleanupOverSize
+		default:
+			continue
+		}
+		total -= entry.Size
+
+		candidate := CleanupCandidate{
+			Hash:         entry.Hash,
+			Title:        entry.Title,
+			Location:     entry.Location,
+			Size:         entry.Size,
+			DownloadedAt: entry.DownloadedAt,
+			Reason:       reason,
+		}
+		if !remove {
+			candidates = append(candidates, candidate)
+			continue
+		}
+
+		if err := removeDownload(entry); err != nil {
+			l.Warn("Failed to remove download",
+				zap.String("bookHash", entry.Hash),
+				zap.String("location", entry.Location),
+				zap.Error(err),
+			)
+			candidate.Skipped = err.Error()
+			candidates = append(candidates, candidate)
+			continue
+		}
+		removeEmptyDirs(filepath.Dir(entry.Location), root)
+
+		if err := idx.MarkDeleted(scope, entry.Hash); err != nil {
+			return candidates, err
+		}
+		if env.FullText {
+			removeFromFullText(scope, entry.Hash)
+		}
+
+		candidate.Removed = true
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
+
+// removeDownload deletes a downloaded file, the sidecars stored next to it,
+// and the archive it was unpacked from.
+func removeDownload(entry library.Entry) error {
+	if err := os.Remove(entry.Location); err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(entry.Location, filepath.Ext(entry.Location))
+	others := []string{base + "." + anna.SidecarOPF, base + "." + anna.SidecarJSON}
+	if entry.Archive != "" {
+		others = append(others, entry.Archive)
+	}
+	for _, other := range others {
+		if err := os.Remove(other); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.GetLogger().Warn("Failed to remove file of the download",
+				zap.String("location", other),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// removeFromFullText drops a removed download from the full-text index.
+// Failures are logged, as the file is gone already.
+func removeFromFullText(scope, hash string) {
+	idx, err := fulltext.Open(fullTextPath(os.Getenv("ANNAS_DOWNLOAD_PATH")))
+	if err == nil {
+		err = idx.Remove(scope, hash)
+	}
+	if err != nil {
+		logger.GetLogger().Warn("Failed to remove the book from the full-text index",
+			zap.String("bookHash", hash),
+			zap.Error(err),
+		)
+	}
+}
+
+// cleanupTotal returns the number and size of the candidates that are or
+// would be removed.
+func cleanupTotal(candidates []CleanupCandidate) (int, int64) {
+	count := 0
+	var size int64
+	for _, candidate := range candidates {
+		if candidate.Skipped == "" {
+			count++
+			size += candidate.Size
+		}
+	}
+
+	return count, size
+}
+
+func formatCleanup(candidates []CleanupCandidate, removed bool) string {
+	count, size := cleanupTotal(candidates)
+	if len(candidates) == 0 {
+		return "The library is within the retention policy, nothing to remove."
+	}
+
+	verb := "Would remove"
+	if removed {
+		verb = "Removed"
+	}
+
+	var sb strings.Builder
+	for _, candidate := range candidates {
+		if candidate.Skipped != "" {
+			fmt.Fprintf(&sb, "Skipped %q (%s): %s\n", candidate.Title, candidate.Location, candidate.Skipped)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %q, %s, downloaded %s, %s\n  %s\n", verb, candidate.Title, formatByteSize(candidate.Size), candidate.DownloadedAt.Format("2006-01-02"), candidate.Reason, candidate.Location)
+	}
+	fmt.Fprintf(&sb, "%s %d files, %s in total.\n", verb, count, formatByteSize(size))
+
+	return sb.String()
+}
@@ -0,0 +1,239 @@
+package modes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// rootsAllowed tells if downloads can go to the filesystem roots of clients,
+// which are only on the filesystem of the server if the client runs on the
+// same machine: over stdio, or over HTTP with ANNAS_HTTP_ROOTS set.
+var rootsAllowed atomic.Bool
+
+// errRootsNotAllowed is returned for downloads to a root of a remote client.
+var errRootsNotAllowed = errors.New("downloads to the roots of the client are only allowed over stdio, or over HTTP with ANNAS_HTTP_ROOTS=true")
+
+// Root is a directory of the filesystem that the MCP client exposes.
+type Root struct {
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri"`
+	Path string `json:"path"`
+}
+
+// clientRoots caches the filesystem roots of every MCP session, until the
+// client announces that they changed.
+var clientRoots = &rootCache{roots: make(map[*mcp.ServerSession][]Root)}
+
+type rootCache struct {
+	mu    sync.Mutex
+	roots map[*mcp.ServerSession][]Root
+}
+
+// list returns the filesystem roots of the session, asking the client for
+// them unless they are cached. Roots that are not file URIs are left out, and
+// clients that do not support roots have none.
+func (c *rootCache) list(ctx context.Context, ss *mcp.ServerSession) ([]Root, error) {
+	if ss == nil {
+		return make([]Root, 0), nil
+	}
+
+	c.mu.Lock()
+	roots, ok := c.roots[ss]
+	c.mu.Unlock()
+	if ok {
+		return roots, nil
+	}
+
+	result, err := ss.ListRoots(ctx, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.GetLogger().Info("The client does not list roots", zap.Error(err))
+		return make([]Root, 0), nil
+	}
+
+	roots = make([]Root, 0, len(result.Roots))
+	for _, root := range result.Roots {
+		path, err := rootPath(root.URI)
+		if err != nil {
+			logger.GetLogger().Info("Ignoring root of the client",
+				zap.String("uri", root.URI),
+				zap.Error(err),
+			)
+			continue
+		}
+		roots = append(roots, Root{Name: root.Name, URI: root.URI, Path: path})
+	}
+
+	c.mu.Lock()
+	c.roots[ss] = roots
+	c.mu.Unlock()
+
+	return roots, nil
+}
+
+// invalidate drops the cached roots of a session whose client announced that
+// they changed.
+func (c *rootCache) invalidate(_ context.Context, ss *mcp.ServerSession, _ *mcp.RootsListChangedParams) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.roots, ss)
+}
+
+// forget drops the cached roots of a closed session.
+func (c *rootCache) forget(ss *mcp.ServerSession) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.roots, ss)
+}
+
+// rootPath returns the local path of a file URI.
+func rootPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("not a file URI: %s", uri)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("the root is on another host: %s", u.Host)
+	}
+
+	path := filepath.FromSlash(u.Path)
+	// Windows paths come as /C:/Users/...
+	if len(path) > 2 && path[0] == filepath.Separator && path[2] == ':' {
+		path = path[1:]
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("not an absolute path: %s", uri)
+	}
+
+	return filepath.Clean(path), nil
+}
+
+// downloadRoot returns the directory a download to the given root of the
+// client goes to. The root can be given by its name, its URI, or its path,
+// or as a directory inside one of the roots, which is created if needed. It
+// fails for directories outside the roots, also through symbolic links.
+func downloadRoot(ctx context.Context, ss *mcp.ServerSession, choice string) (string, error) {
+	if !rootsAllowed.Load() {
+		return "", errRootsNotAllowed
+	}
+
+	roots, err := clientRoots.list(ctx, ss)
+	if err != nil {
+		return "", err
+	}
+	if len(roots) == 0 {
+		return "", errors.New("the client exposes no filesystem roots")
+	}
+
+	dir := choice
+	if strings.HasPrefix(choice, "file:") {
+		if dir, err = rootPath(choice); err != nil {
+			return "", err
+		}
+	}
+	for _, root := range roots {
+		if choice == root.Name || choice == root.URI {
+			dir = root.Path
+			break
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("unknown root %q, the client exposes %s", choice, formatRootChoices(roots))
+	}
+	dir = filepath.Clean(dir)
+
+	for _, root := range roots {
+		if !withinDir(root.Path, dir) {
+			continue
+		}
+		info, err := os.Stat(root.Path)
+		if err != nil {
+			return "", fmt.Errorf("the root %s cannot be used: %w", root.Path, err)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("the root %s is not a directory", root.Path)
+		}
+		// Symbolic links inside the root could lead out of it, which is
+		// checked on the part of the directory that exists already.
+		existing := dir
+		for !dirExists(existing) && existing != root.Path {
+			existing = filepath.Dir(existing)
+		}
+		resolvedRoot, err := filepath.EvalSymlinks(root.Path)
+		if err != nil {
+			return "", err
+		}
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err != nil {
+			return "", err
+		}
+		if !withinDir(resolvedRoot, resolved) {
+			return "", fmt.Errorf("%s leads out of the root %s", dir, root.Path)
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+
+		return dir, nil
+	}
+
+	return "", fmt.Errorf("%s is not inside the roots of the client, which are %s", dir, formatRootChoices(roots))
+}
+
+// withinDir tells if path is dir or inside it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func formatRootChoices(roots []Root) string {
+	choices := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if root.Name != "" {
+			choices = append(choices, fmt.Sprintf("%s (%s)", root.Name, root.Path))
+		} else {
+			choices = append(choices, root.Path)
+		}
+	}
+
+	return strings.Join(choices, ", ")
+}
+
+func formatRoots(roots []Root) string {
+	if !rootsAllowed.Load() {
+		return tr("Downloads to the roots of the client are only allowed over stdio, or over HTTP with ANNAS_HTTP_ROOTS=true.") + "\n"
+	}
+	if len(roots) == 0 {
+		return tr("The client exposes no filesystem roots, downloads go to the download directory of the server.") + "\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(tr("Downloads can be saved to these roots of the client, by passing their name or path as root:") + "\n")
+	for _, root := range roots {
+		if root.Name != "" {
+			fmt.Fprintf(&sb, "- %s: %s\n", root.Name, root.Path)
+		} else {
+			fmt.Fprintf(&sb, "- %s\n", root.Path)
+		}
+	}
+
+	return sb.String()
+}
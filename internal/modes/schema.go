@@ -0,0 +1,90 @@
+package modes
+
+import (
+	"github.com/iosifache/annas-mcp/internal/i18n"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// bookFormats are the file formats found on Anna's Archive.
+var bookFormats = []any{
+	"pdf", "epub", "mobi", "azw3", "fb2", "djvu", "cbz", "cbr", "txt",
+	"doc", "docx", "rtf", "lit", "chm", "zip", "rar",
+}
+
+const md5SchemaPattern = "^[0-9a-fA-F]{32}$"
+
+func minimum(n int) *int { return &n }
+
+func boolPtr(b bool) *bool { return &b }
+
+// stringProperty is a non-empty string property.
+func stringProperty(description string) mcp.SchemaOption {
+	return mcp.Schema(&jsonschema.Schema{
+		Type:        "string",
+		Description: description,
+		MinLength:   minimum(1),
+	})
+}
+
+// hashProperty is an MD5 hash property.
+func hashProperty(description string) mcp.SchemaOption {
+	return mcp.Schema(&jsonschema.Schema{
+		Type:        "string",
+		Description: description,
+		Pattern:     md5SchemaPattern,
+	})
+}
+
+func formatProperty(description string) mcp.SchemaOption {
+	return mcp.Schema(&jsonschema.Schema{
+		Type:        "string",
+		Description: description,
+		Enum:        bookFormats,
+	})
+}
+
+func formatListProperty(description string) mcp.SchemaOption {
+	return mcp.Schema(&jsonschema.Schema{
+		Type:        "array",
+		Description: description,
+		Items:       &jsonschema.Schema{Type: "string", Enum: bookFormats},
+	})
+}
+
+// readOnlyTool marks a tool that only reads from Anna's Archive.
+func readOnlyTool(title string) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{
+		Title:         title,
+		ReadOnlyHint:  true,
+		OpenWorldHint: boolPtr(true),
+	}
+}
+
+// localizeTools translates the descriptions of the tools and of their
+// parameters, and their titles, to the given language.
+func localizeTools(tools []*mcp.ServerTool, lang string) {
+	if lang == i18n.English {
+		return
+	}
+
+	for _, tool := range tools {
+		tool.Tool.Description = i18n.T(lang, tool.Tool.Description)
+		if tool.Tool.Annotations != nil {
+			tool.Tool.Annotations.Title = i18n.T(lang, tool.Tool.Annotations.Title)
+		}
+		if tool.Tool.InputSchema == nil {
+			continue
+		}
+		for _, property := range tool.Tool.InputSchema.Properties {
+			property.Description = i18n.T(lang, property.Description)
+		}
+	}
+}
+
+// annotate sets the annotations of a tool, which NewServerTool has no option
+// for.
+func annotate(tool *mcp.ServerTool, annotations *mcp.ToolAnnotations) *mcp.ServerTool {
+	tool.Tool.Annotations = annotations
+	return tool
+}
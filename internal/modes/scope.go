@@ -0,0 +1,214 @@
+package modes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Scoping modes for downloads made through the HTTP transport.
+const (
+	ScopeNone    = "none"
+	ScopeSession = "session"
+	ScopeKey     = "key"
+)
+
+var ErrQuotaExceeded = errors.New("download quota exceeded")
+
+var (
+	sessionScopesMu sync.Mutex
+	sessionScopes   = make(map[*mcp.ServerSession]string)
+
+	ledger = &quotaLedger{used: make(map[string]int64)}
+)
+
+// keyScopeFromRequest derives a stable, non-reversible scope name from the
+// identity behind the bearer token of an HTTP request.
+func keyScopeFromRequest(r *http.Request) string {
+	return keyScope(requestIdentity(r))
+}
+
+func keyScope(identity string) string {
+	if identity == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(identity))
+	return "key-" + hex.EncodeToString(sum[:8])
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+// sessionScope returns the scope name of an MCP session. Transports that do
+// not expose session IDs get a random one for the lifetime of the session.
+func sessionScope(ss *mcp.ServerSession) string {
+	if ss == nil {
+		return ""
+	}
+	if id := ss.ID(); id != "" {
+		return "session-" + id
+	}
+
+	sessionScopesMu.Lock()
+	defer sessionScopesMu.Unlock()
+
+	if scope, ok := sessionScopes[ss]; ok {
+		return scope
+	}
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	scope := "session-" + hex.EncodeToString(buf)
+	sessionScopes[ss] = scope
+
+	return scope
+}
+
+// forgetSessionScope drops the scope name of a closed session.
+func forgetSessionScope(ss *mcp.ServerSession) {
+	sessionScopesMu.Lock()
+	defer sessionScopesMu.Unlock()
+
+	delete(sessionScopes, ss)
+}
+
+// resolveScope picks the download scope for a tool call, or an empty string
+// if downloads are shared.
+func resolveScope(env *Env, ss *mcp.ServerSession, keyScope string) string {
+	switch env.HTTPScope {
+	case ScopeSession:
+		return sessionScope(ss)
+	case ScopeKey:
+		if keyScope != "" {
+			return keyScope
+		}
+		return sessionScope(ss)
+	default:
+		return ""
+	}
+}
+
+// scopedStorage returns the storage to use for the given scope, enforcing the
+// per-scope quota if one is configured.
+func scopedStorage(env *Env, scope string) (storage.Storage, error) {
+	cfg := env.Storage
+	if scope != "" {
+		cfg = cfg.Sub(scope)
+	}
+
+	store, err := storage.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == "" || env.UserQuota <= 0 {
+		return store, nil
+	}
+
+	if ledger.usage(cfg, scope) >= env.UserQuota {
+		return nil, fmt.Errorf("%w: %s of %d bytes used", ErrQuotaExceeded, scope, env.UserQuota)
+	}
+
+	return &quotaStorage{
+		inner: store,
+		scope: scope,
+		limit: env.UserQuota,
+	}, nil
+}
+
+// quotaLedger tracks the bytes written per scope. Usage of local scopes is
+// seeded from disk so it survives restarts, remote ones are counted for the
+// lifetime of the process.
+type quotaLedger struct {
+	mu   sync.Mutex
+	used map[string]int64
+}
+
+func (q *quotaLedger) usage(cfg storage.Config, scope string) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if used, ok := q.used[scope]; ok {
+		return used
+	}
+
+	var used int64
+	if cfg.Backend == "" || cfg.Backend == storage.BackendLocal {
+		filepath.WalkDir(cfg.LocalPath, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				used += info.Size()
+			}
+			return nil
+		})
+	}
+	q.used[scope] = used
+
+	return used
+}
+
+func (q *quotaLedger) add(scope string, n int64) int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.used[scope] += n
+	return q.used[scope]
+}
+
+type quotaStorage struct {
+	inner storage.Storage
+	scope string
+	limit int64
+}
+
+func (s *quotaStorage) Store(name string, r io.Reader, size int64) (string, error) {
+	qr := &quotaReader{r: r, scope: s.scope, limit: s.limit}
+
+	location, err := s.inner.Store(name, qr, size)
+	if err != nil {
+		// Failed writes are discarded by the backends, so release their bytes.
+		ledger.add(s.scope, -qr.read)
+		return "", err
+	}
+
+	return location, nil
+}
+
+// quotaReader accounts bytes as they are read and fails once the scope goes
+// over its limit, so the storage backend aborts the write.
+type quotaReader struct {
+	r     io.Reader
+	scope string
+	limit int64
+	read  int64
+}
+
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if n > 0 && ledger.add(r.scope, int64(n)) > r.limit {
+		return n, fmt.Errorf("%w: %s is over %d bytes", ErrQuotaExceeded, r.scope, r.limit)
+	}
+
+	return n, err
+}
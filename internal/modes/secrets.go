@@ -0,0 +1,216 @@
+package modes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+	"go.uber.org/zap"
+	"golang.org/x/term"
+)
+
+// keychainService is the service the secrets are stored under in the
+// keychain of the operating system.
+const keychainService = "annas-mcp"
+
+// secret is a credential that can come from the environment, a file, or the
+// keychain.
+type secret struct {
+	// envVar holds the value itself, and envVar+"_FILE" the path of a file
+	// holding it.
+	envVar string
+	// keychainAccount names the keychain entry holding the value.
+	keychainAccount string
+}
+
+var (
+	secretKeySecret     = secret{envVar: "ANNAS_SECRET_KEY", keychainAccount: "secret-key"}
+	accountCookieSecret = secret{envVar: "ANNAS_ACCOUNT_COOKIE", keychainAccount: "account-cookie"}
+	telegramTokenSecret = secret{envVar: "ANNAS_TELEGRAM_BOT_TOKEN", keychainAccount: "telegram-bot-token"}
+)
+
+// secrets lists the credentials by their keychain account, as used by the
+// secret command.
+var secrets = map[string]secret{
+	secretKeySecret.keychainAccount:     secretKeySecret,
+	accountCookieSecret.keychainAccount: accountCookieSecret,
+	telegramTokenSecret.keychainAccount: telegramTokenSecret,
+}
+
+// lookup returns the value of the secret, from the environment variable, the
+// file it names, or the keychain when ANNAS_KEYCHAIN=true, in that order. A
+// secret that is not set anywhere is empty.
+func (s secret) lookup() (string, error) {
+	if value := os.Getenv(s.envVar); value != "" {
+		return value, nil
+	}
+
+	if path := os.Getenv(s.envVar + "_FILE"); path != "" {
+		value, err := readSecretFile(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s_FILE: %w", s.envVar, err)
+		}
+		return value, nil
+	}
+
+	if os.Getenv("ANNAS_KEYCHAIN") == "true" {
+		value, err := keyring.Get(keychainService, s.keychainAccount)
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s from the keychain: %w", s.keychainAccount, err)
+		}
+		return value, nil
+	}
+
+	return "", nil
+}
+
+// value returns the secret like lookup, logging failures instead of
+// returning them, for callers that work without it.
+func (s secret) value() string {
+	value, err := s.lookup()
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring secret", zap.String("name", s.envVar), zap.Error(err))
+	}
+
+	return value
+}
+
+// readSecretFile reads a secret from a file that other users cannot access,
+// like SSH does for private keys.
+func readSecretFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	// Windows does not map its access control lists to permission bits.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("%s is accessible by other users, restrict it with chmod 600", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+
+	return value, nil
+}
+
+// secretNames returns the keychain accounts of the secrets, sorted.
+func secretNames() []string {
+	return slices.Sorted(maps.Keys(secrets))
+}
+
+func newSecretCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Store credentials in the keychain of the operating system",
+		Long: "Store the secret key, the account cookie, or the Telegram bot token in the macOS Keychain, the Windows Credential Manager, or the Secret Service on Linux, " +
+			"so they do not have to be set in environment variables. Set ANNAS_KEYCHAIN=true to use them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newSecretSetCmd(), newSecretDeleteCmd())
+
+	return cmd
+}
+
+func newSecretSetCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:       "set [secret-key|account-cookie|telegram-bot-token]",
+		Short:     "Store a credential read from the standard input in the keychain",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: secretNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Secret set command called", zap.String("name", args[0]))
+
+			value, err := readSecretInput(args[0])
+			if err != nil {
+				l.Error("Secret set command failed", zap.Error(err))
+				return err
+			}
+
+			if err := keyring.Set(keychainService, secrets[args[0]].keychainAccount, value); err != nil {
+				l.Error("Secret set command failed", zap.Error(err))
+				return fmt.Errorf("failed to store %s in the keychain: %w", args[0], err)
+			}
+
+			l.Info("Secret set command completed successfully")
+
+			fmt.Fprintf(os.Stderr, "Stored %s in the keychain. Set ANNAS_KEYCHAIN=true to use it.\n", args[0])
+
+			return nil
+		},
+	}
+}
+
+func newSecretDeleteCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	return &cobra.Command{
+		Use:       "delete [secret-key|account-cookie|telegram-bot-token]",
+		Short:     "Remove a credential from the keychain",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: secretNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Secret delete command called", zap.String("name", args[0]))
+
+			if err := keyring.Delete(keychainService, secrets[args[0]].keychainAccount); err != nil {
+				l.Error("Secret delete command failed", zap.Error(err))
+				return fmt.Errorf("failed to remove %s from the keychain: %w", args[0], err)
+			}
+
+			l.Info("Secret delete command completed successfully")
+
+			fmt.Fprintf(os.Stderr, "Removed %s from the keychain.\n", args[0])
+
+			return nil
+		},
+	}
+}
+
+// readSecretInput reads a secret from the standard input, without echoing
+// it when it is typed in a terminal.
+func readSecretInput(name string) (string, error) {
+	var value string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Enter the %s: ", name)
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		value = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the %s: %w", name, err)
+		}
+		value = line
+	}
+
+	if value = strings.TrimSpace(value); value == "" {
+		return "", fmt.Errorf("the %s must not be empty", name)
+	}
+
+	return value, nil
+}
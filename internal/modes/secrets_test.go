@@ -0,0 +1,28 @@
+package modes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSecretNames(t *testing.T) {
+	names := secretNames()
+	if len(names) != len(secrets) {
+		t.Fatalf("secretNames returned %v, want every secret of %d", names, len(secrets))
+	}
+	for name := range secrets {
+		if !slices.Contains(names, name) {
+			t.Errorf("secretNames is missing %s", name)
+		}
+	}
+
+	for _, cmd := range []string{"set", "delete"} {
+		set, _, err := newSecretCmd().Find([]string{cmd})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := set.ValidateArgs([]string{telegramTokenSecret.keychainAccount}); err != nil {
+			t.Errorf("secret %s rejects %s: %v", cmd, telegramTokenSecret.keychainAccount, err)
+		}
+	}
+}
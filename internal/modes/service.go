@@ -0,0 +1,314 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// defaultServiceName names the installed service unless --name is given.
+const defaultServiceName = "annas-mcp"
+
+// defaultServiceArgs are the serve flags of a service installed without
+// any.
+var defaultServiceArgs = []string{"--transport", TransportHTTP}
+
+// serviceConfig describes a service running the server in the background.
+type serviceConfig struct {
+	Name       string
+	Executable string
+	// Args are the flags passed to the serve command.
+	Args []string
+	// Env holds the ANNAS_ variables of the current configuration, which
+	// the service is started with.
+	Env map[string]string
+	// User installs a systemd user unit instead of a system one.
+	User bool
+}
+
+func newServiceConfig(name string, args []string, user bool) (*serviceConfig, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return nil, fmt.Errorf("failed to locate the executable: %w", err)
+	}
+
+	if len(args) == 0 {
+		args = defaultServiceArgs
+	}
+
+	// The .env file is loaded already, so its settings are carried over too.
+	env := make(map[string]string)
+	for _, variable := range os.Environ() {
+		if key, value, ok := strings.Cut(variable, "="); ok && strings.HasPrefix(key, "ANNAS_") {
+			env[key] = value
+		}
+	}
+
+	return &serviceConfig{
+		Name:       name,
+		Executable: executable,
+		Args:       args,
+		Env:        env,
+		User:       user,
+	}, nil
+}
+
+// envKeys returns the names of the variables of the service, sorted.
+func (s *serviceConfig) envKeys() []string {
+	keys := make([]string, 0, len(s.Env))
+	for key := range s.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func newInstallServiceCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var (
+		name string
+		user bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install-service [-- serve flags]",
+		Short: "Run the server in the background as a system service",
+		Long: "Register a systemd unit (on Linux) or a Windows service that starts the server with the current ANNAS_ configuration at boot. " +
+			"Flags after -- are passed to serve, which defaults to --transport http.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Install service command called", zap.String("name", name))
+
+			cfg, err := newServiceConfig(name, args, user)
+			if err != nil {
+				l.Error("Install service command failed", zap.Error(err))
+				return err
+			}
+
+			location, err := installService(cfg)
+			if err != nil {
+				l.Error("Install service command failed", zap.Error(err))
+				return fmt.Errorf("failed to install the service: %w", err)
+			}
+
+			l.Info("Install service command completed successfully", zap.String("location", location))
+
+			if jsonOutput {
+				return printJSON(map[string]string{"name": name, "location": location})
+			}
+
+			fmt.Printf("Installed and started service %s (%s)\n", name, location)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", defaultServiceName, "Name of the service")
+	cmd.Flags().BoolVar(&user, "user", os.Geteuid() > 0, "Install a systemd user unit instead of a system one, the default when not running as root")
+
+	return cmd
+}
+
+func newUninstallServiceCmd() *cobra.Command {
+	l := logger.GetLogger()
+
+	var (
+		name string
+		user bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "uninstall-service",
+		Short: "Stop and remove the service installed with install-service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l.Info("Uninstall service command called", zap.String("name", name))
+
+			if err := uninstallService(name, user); err != nil {
+				l.Error("Uninstall service command failed", zap.Error(err))
+				return fmt.Errorf("failed to uninstall the service: %w", err)
+			}
+
+			l.Info("Uninstall service command completed successfully")
+
+			if jsonOutput {
+				return printJSON(map[string]string{"name": name})
+			}
+
+			fmt.Printf("Removed service %s\n", name)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", defaultServiceName, "Name of the service")
+	cmd.Flags().BoolVar(&user, "user", os.Geteuid() > 0, "Remove a systemd user unit instead of a system one, the default when not running as root")
+
+	return cmd
+}
+
+// installService registers and starts the service, and returns where it is
+// defined.
+func installService(cfg *serviceConfig) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemdUnit(cfg)
+	case "windows":
+		return installWindowsService(cfg)
+	default:
+		return "", fmt.Errorf("services are not supported on %s", runtime.GOOS)
+	}
+}
+
+func uninstallService(name string, user bool) error {
+	switch runtime.GOOS {
+	case "linux":
+		return uninstallSystemdUnit(name, user)
+	case "windows":
+		return uninstallWindowsService(name)
+	default:
+		return fmt.Errorf("services are not supported on %s", runtime.GOOS)
+	}
+}
+
+// systemdPaths returns the locations of the unit and of the environment
+// file holding the configuration, which may contain secrets and is only
+// readable by its owner.
+func systemdPaths(name string, user bool) (string, string, error) {
+	if !user {
+		return filepath.Join("/etc/systemd/system", name+".service"), filepath.Join("/etc/annas-mcp", name+".env"), nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	return filepath.Join(configDir, "systemd", "user", name+".service"), filepath.Join(configDir, "annas-mcp", name+".env"), nil
+}
+
+func systemdUnit(cfg *serviceConfig, envFile string) string {
+	command := make([]string, 0, len(cfg.Args)+2)
+	for _, arg := range append([]string{cfg.Executable, "serve"}, cfg.Args...) {
+		command = append(command, systemdQuote(arg))
+	}
+
+	wantedBy := "multi-user.target"
+	if cfg.User {
+		wantedBy = "default.target"
+	}
+
+	return fmt.Sprintf(`[Unit]
+Description=Anna's Archive MCP server
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s
+EnvironmentFile=%s
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=%s
+`, strings.Join(command, " "), envFile, wantedBy)
+}
+
+// systemdQuote quotes an argument of ExecStart, if needed.
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%;") {
+		return arg
+	}
+
+	return strconv.Quote(strings.ReplaceAll(arg, "%", "%%"))
+}
+
+func systemdEnvFile(cfg *serviceConfig) string {
+	var sb strings.Builder
+	for _, key := range cfg.envKeys() {
+		fmt.Fprintf(&sb, "%s=%s\n", key, strconv.Quote(cfg.Env[key]))
+	}
+
+	return sb.String()
+}
+
+func installSystemdUnit(cfg *serviceConfig) (string, error) {
+	unitPath, envPath, err := systemdPaths(cfg.Name, cfg.User)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(envPath), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(envPath, []byte(systemdEnvFile(cfg)), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(unitPath), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(unitPath, []byte(systemdUnit(cfg, envPath)), 0o644); err != nil {
+		return "", err
+	}
+
+	if err := systemctl(cfg.User, "daemon-reload"); err != nil {
+		return "", err
+	}
+	if err := systemctl(cfg.User, "enable", "--now", cfg.Name+".service"); err != nil {
+		return "", err
+	}
+
+	return unitPath, nil
+}
+
+func uninstallSystemdUnit(name string, user bool) error {
+	unitPath, envPath, err := systemdPaths(name, user)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(unitPath); err != nil {
+		return fmt.Errorf("no service installed at %s", unitPath)
+	}
+
+	if err := systemctl(user, "disable", "--now", name+".service"); err != nil {
+		return err
+	}
+
+	if err := os.Remove(unitPath); err != nil {
+		return err
+	}
+	if err := os.Remove(envPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return systemctl(user, "daemon-reload")
+}
+
+func systemctl(user bool, args ...string) error {
+	if user {
+		args = append([]string{"--user"}, args...)
+	}
+
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package modes
+
+import "errors"
+
+var errNotWindows = errors.New("Windows services are only supported on Windows")
+
+func installWindowsService(*serviceConfig) (string, error) {
+	return "", errNotWindows
+}
+
+func uninstallWindowsService(string) error {
+	return errNotWindows
+}
+
+// runAsService reports whether the process was started by the Windows
+// service manager, which never happens on other systems.
+func runAsService(ServeOptions) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,114 @@
+//go:build windows
+
+package modes
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+func installWindowsService(cfg *serviceConfig) (string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return "", err
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(cfg.Name); err == nil {
+		s.Close()
+		return "", fmt.Errorf("service %s already exists", cfg.Name)
+	}
+
+	s, err := m.CreateService(cfg.Name, cfg.Executable, mgr.Config{
+		DisplayName: "Anna's Archive MCP server",
+		Description: "Serves the Anna's Archive MCP server in the background.",
+		StartType:   mgr.StartAutomatic,
+	}, append([]string{"serve"}, cfg.Args...)...)
+	if err != nil {
+		return "", err
+	}
+	defer s.Close()
+
+	// Services do not inherit the environment of the installing user, so
+	// the configuration is stored with the service, where the service
+	// manager passes it on at start.
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+cfg.Name, registry.SET_VALUE)
+	if err != nil {
+		s.Delete()
+		return "", err
+	}
+	defer key.Close()
+
+	env := make([]string, 0, len(cfg.Env))
+	for _, name := range cfg.envKeys() {
+		env = append(env, name+"="+cfg.Env[name])
+	}
+	if err := key.SetStringsValue("Environment", env); err != nil {
+		s.Delete()
+		return "", err
+	}
+
+	if err := s.Start(); err != nil {
+		return "", err
+	}
+
+	return `HKLM\SYSTEM\CurrentControlSet\Services\` + cfg.Name, nil
+}
+
+func uninstallWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("no service named %s: %w", name, err)
+	}
+	defer s.Close()
+
+	// A service that is not running cannot be stopped, which is fine.
+	s.Control(svc.Stop)
+
+	return s.Delete()
+}
+
+// runAsService serves under the Windows service manager, if it started the
+// process, and reports whether it did.
+func runAsService(opts ServeOptions) (bool, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false, err
+	}
+
+	return true, svc.Run(defaultServiceName, &windowsService{opts: opts})
+}
+
+type windowsService struct {
+	opts ServeOptions
+}
+
+func (w *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	// The server exits the process when it fails, and runs until the
+	// service is stopped otherwise.
+	go StartMCPServer(w.opts)
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for request := range requests {
+		switch request.Cmd {
+		case svc.Interrogate:
+			status <- request.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		}
+	}
+
+	return false, 0
+}
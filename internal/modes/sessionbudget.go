@@ -0,0 +1,90 @@
+package modes
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var ErrSessionBudgetExceeded = errors.New("session download budget exceeded")
+
+// sessionUsage counts the downloads of an MCP session.
+type sessionUsage struct {
+	downloads int
+	bytes     int64
+}
+
+// sessionBudgets tracks the downloads of every MCP session against the
+// limits of ANNAS_SESSION_MAX_DOWNLOADS and ANNAS_SESSION_MAX_BYTES, so that
+// an agent stuck in a loop cannot spend the daily quota of the account.
+var sessionBudgets = &sessionBudget{usage: make(map[*mcp.ServerSession]*sessionUsage)}
+
+type sessionBudget struct {
+	mu    sync.Mutex
+	usage map[*mcp.ServerSession]*sessionUsage
+}
+
+// reserve counts a download against the budget of the session, or fails if
+// the session has used up its budget. The returned function settles the
+// reservation once the download is done, with the bytes it stored. Failed
+// downloads release it, unless they were issued a download URL and so spent
+// the quota of the account anyway, as retries after a failure do too.
+func (b *sessionBudget) reserve(env *Env, ss *mcp.ServerSession) (func(size int64, err error), error) {
+	if ss == nil || (env.SessionDownloads <= 0 && env.SessionBytes <= 0) {
+		return func(int64, error) {}, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	usage, ok := b.usage[ss]
+	if !ok {
+		usage = &sessionUsage{}
+		b.usage[ss] = usage
+	}
+	if env.SessionDownloads > 0 && usage.downloads >= env.SessionDownloads {
+		return nil, fmt.Errorf("%w: %d of %d downloads made", ErrSessionBudgetExceeded, usage.downloads, env.SessionDownloads)
+	}
+	if env.SessionBytes > 0 && usage.bytes >= env.SessionBytes {
+		return nil, fmt.Errorf("%w: %s of %s downloaded", ErrSessionBudgetExceeded, formatByteSize(usage.bytes), formatByteSize(env.SessionBytes))
+	}
+	usage.downloads++
+
+	return func(size int64, err error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if err != nil {
+			if !anna.IsQuotaSpent(err) {
+				usage.downloads--
+			}
+			return
+		}
+		usage.bytes += size
+	}, nil
+}
+
+// usageOf returns the downloads and bytes counted against the budget of the
+// session.
+func (b *sessionBudget) usageOf(ss *mcp.ServerSession) (int, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	usage, ok := b.usage[ss]
+	if !ok {
+		return 0, 0
+	}
+
+	return usage.downloads, usage.bytes
+}
+
+// forget drops the usage of a closed session.
+func (b *sessionBudget) forget(ss *mcp.ServerSession) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.usage, ss)
+}
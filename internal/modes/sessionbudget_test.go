@@ -0,0 +1,42 @@
+package modes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSessionBudgetCountsSpentDownloads(t *testing.T) {
+	ss, cs := connectSession(t)
+	defer cs.Close()
+
+	budget := &sessionBudget{usage: make(map[*mcp.ServerSession]*sessionUsage)}
+	env := &Env{SessionDownloads: 2}
+
+	settle, err := budget.reserve(env, ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(0, anna.ErrNoDownloadsLeft)
+	if downloads, _ := budget.usageOf(ss); downloads != 0 {
+		t.Errorf("a download failing before it got a URL is counted, %d downloads made", downloads)
+	}
+
+	for range 2 {
+		settle, err := budget.reserve(env, ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+		settle(0, &anna.QuotaSpentError{Err: errors.New("file download returned status 502 Bad Gateway")})
+	}
+	if _, err := budget.reserve(env, ss); !errors.Is(err, ErrSessionBudgetExceeded) {
+		t.Errorf("reserve after two downloads that spent the quota returned %v, want %v", err, ErrSessionBudgetExceeded)
+	}
+
+	budget.forget(ss)
+	if downloads, _ := budget.usageOf(ss); downloads != 0 {
+		t.Errorf("the forgotten session still has %d downloads", downloads)
+	}
+}
@@ -0,0 +1,115 @@
+package modes
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// sessions forgets the state kept for every MCP session once the session is
+// closed, so that a long-running HTTP server does not hold on to the
+// sessions of all the clients it ever served.
+var sessions = &sessionTracker{open: make(map[*mcp.ServerSession]bool)}
+
+type sessionTracker struct {
+	mu   sync.Mutex
+	open map[*mcp.ServerSession]bool
+}
+
+// middleware watches the sessions that send requests to the server.
+func (t *sessionTracker) middleware(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+	return func(ctx context.Context, ss *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+		t.watch(ss)
+		return next(ctx, ss, method, params)
+	}
+}
+
+// watch forgets the state of the session once it is closed. The session
+// waits for its pending requests before closing, so none of them adds state
+// back afterwards.
+func (t *sessionTracker) watch(ss *mcp.ServerSession) {
+	if ss == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.open[ss] {
+		return
+	}
+	t.open[ss] = true
+
+	go func() {
+		ss.Wait()
+		forgetSession(ss)
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.open, ss)
+	}()
+}
+
+// forgetSession drops the state kept for a closed session.
+func forgetSession(ss *mcp.ServerSession) {
+	forgetSessionScope(ss)
+	sessionBudgets.forget(ss)
+	clientRoots.forget(ss)
+}
+
+// maxServers bounds the MCP servers kept for the key scopes of HTTP clients.
+// Without authentication, every bearer token that is sent gets a scope of its
+// own, so the servers of the scopes that were used the longest ago are
+// dropped. Sessions keep the server they started with, and a new server is
+// made for the scope the next time a session starts.
+const maxServers = 256
+
+// serverCache holds the MCP servers of the key scopes of HTTP clients, so
+// that clients sharing a bearer token share a server.
+type serverCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	servers map[string]*list.Element
+	create  func(keyScope string) *mcp.Server
+}
+
+type cachedServer struct {
+	keyScope string
+	server   *mcp.Server
+}
+
+func newServerCache(max int, create func(keyScope string) *mcp.Server) *serverCache {
+	return &serverCache{
+		max:     max,
+		order:   list.New(),
+		servers: make(map[string]*list.Element),
+		create:  create,
+	}
+}
+
+// get returns the server of the key scope of the request, made if needed.
+func (c *serverCache) get(r *http.Request) *mcp.Server {
+	keyScope := keyScopeFromRequest(r)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.servers[keyScope]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*cachedServer).server
+	}
+
+	server := c.create(keyScope)
+	c.servers[keyScope] = c.order.PushFront(&cachedServer{keyScope: keyScope, server: server})
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.servers, oldest.Value.(*cachedServer).keyScope)
+	}
+
+	return server
+}
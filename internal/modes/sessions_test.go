@@ -0,0 +1,140 @@
+package modes
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connectSession connects a client to a new server over in-memory
+// transports, returning the session of the server and of the client.
+func connectSession(t *testing.T) (*mcp.ServerSession, *mcp.ClientSession) {
+	t.Helper()
+
+	ctx := context.Background()
+	st, ct := mcp.NewInMemoryTransports()
+	ss, err := newMCPServer("").Connect(ctx, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := mcp.NewClient("test", "1", nil).Connect(ctx, ct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ss, cs
+}
+
+// eventually polls cond until it holds or a second has passed.
+func eventually(t *testing.T, cond func() bool) bool {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+
+	return cond()
+}
+
+func TestClosedSessionsAreForgotten(t *testing.T) {
+	ss, cs := connectSession(t)
+	if _, err := cs.ListTools(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	scope := sessionScope(ss)
+	if scope == "" || sessionScope(ss) != scope {
+		t.Fatalf("sessionScope returned %q, then another scope", scope)
+	}
+
+	sessionScopesMu.Lock()
+	_, kept := sessionScopes[ss]
+	sessionScopesMu.Unlock()
+	if !kept {
+		t.Fatal("the session has an ID, its scope is not kept")
+	}
+
+	settle, err := sessionBudgets.reserve(&Env{SessionDownloads: 1}, ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(1, nil)
+	roots, err := clientRoots.list(context.Background(), ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientRoots.mu.Lock()
+	_, cached := clientRoots.roots[ss]
+	clientRoots.mu.Unlock()
+	if !cached || len(roots) != 0 {
+		t.Fatalf("the session has %d roots, cached: %t", len(roots), cached)
+	}
+
+	cs.Close()
+
+	forgotten := eventually(t, func() bool {
+		sessionScopesMu.Lock()
+		defer sessionScopesMu.Unlock()
+		_, ok := sessionScopes[ss]
+		return !ok
+	})
+	if !forgotten {
+		t.Error("the scope of the closed session is still kept")
+	}
+
+	forgotten = eventually(t, func() bool {
+		sessionBudgets.mu.Lock()
+		defer sessionBudgets.mu.Unlock()
+		_, ok := sessionBudgets.usage[ss]
+		return !ok
+	})
+	if !forgotten {
+		t.Error("the download budget of the closed session is still kept")
+	}
+
+	forgotten = eventually(t, func() bool {
+		clientRoots.mu.Lock()
+		defer clientRoots.mu.Unlock()
+		_, ok := clientRoots.roots[ss]
+		return !ok
+	})
+	if !forgotten {
+		t.Error("the roots of the closed session are still cached")
+	}
+}
+
+func TestServerCacheIsBounded(t *testing.T) {
+	created := make(map[string]int)
+	cache := newServerCache(2, func(keyScope string) *mcp.Server {
+		created[keyScope]++
+		return mcp.NewServer("test", "1", nil)
+	})
+	get := func(token string) *mcp.Server {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return cache.get(r)
+	}
+
+	first := get("a")
+	if get("a") != first {
+		t.Error("requests with the same token got different servers")
+	}
+	get("b")
+	get("a")
+	get("c")
+
+	if len(cache.servers) != 2 || cache.order.Len() != 2 {
+		t.Errorf("the cache holds %d servers, want 2", len(cache.servers))
+	}
+	if get("a") != first {
+		t.Error("the most recently used server was dropped")
+	}
+	get("b")
+	if created[keyScope("b")] != 2 {
+		t.Errorf("the server of the least recently used token was made %d times, want it dropped and made again", created[keyScope("b")])
+	}
+}
@@ -0,0 +1,287 @@
+package modes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ServerStats is the usage of the server since it started, reported by the
+// server_stats tool so that agents can tell why their calls fail.
+type ServerStats struct {
+	Uptime string `json:"uptime"`
+	// Calls counts the calls of every tool, and Failures those that failed.
+	Calls    map[string]int `json:"calls"`
+	Failures map[string]int `json:"failures,omitempty"`
+	// Errors counts the failures by their cause, such as "book not found".
+	Errors map[string]int `json:"errors,omitempty"`
+	// CacheHits and CacheMisses count the scraped pages answered from the
+	// cache in ANNAS_CACHE_DIR, and those that were not.
+	CacheHits    int64          `json:"cache_hits"`
+	CacheMisses  int64          `json:"cache_misses"`
+	CacheHitRate float64        `json:"cache_hit_rate"`
+	RateLimits   RateLimitState `json:"rate_limits"`
+}
+
+// RateLimitState describes the limits the downloads and searches of the
+// session are subject to.
+type RateLimitState struct {
+	Politeness string `json:"politeness"`
+	// Quota is the account quota reported by the latest download.
+	Quota   *anna.FastDownloadInfo `json:"quota,omitempty"`
+	QuotaAt *time.Time             `json:"quota_at,omitempty"`
+
+	SessionDownloads    int   `json:"session_downloads"`
+	SessionMaxDownloads int   `json:"session_max_downloads,omitempty"`
+	SessionBytes        int64 `json:"session_bytes"`
+	SessionMaxBytes     int64 `json:"session_max_bytes,omitempty"`
+
+	// Cooldowns lists the hosts that kept throttling requests, and are left
+	// alone until the given time.
+	Cooldowns []anna.Cooldown `json:"cooldowns,omitempty"`
+	// Budgets lists the requests in flight to each class of hosts, against
+	// their budget.
+	Budgets []anna.BudgetState `json:"budgets,omitempty"`
+}
+
+// toolStats counts the tool calls of all the MCP servers of the process.
+var toolStats = newToolUsage()
+
+type toolUsage struct {
+	started time.Time
+
+	mu       sync.Mutex
+	calls    map[string]int
+	failures map[string]int
+	errors   map[string]int
+	quota    *anna.FastDownloadInfo
+	quotaAt  time.Time
+}
+
+func newToolUsage() *toolUsage {
+	return &toolUsage{
+		started:  time.Now(),
+		calls:    make(map[string]int),
+		failures: make(map[string]int),
+		errors:   make(map[string]int),
+	}
+}
+
+// middleware counts the tool calls received by a server, and their failures.
+func (u *toolUsage) middleware(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+	return func(ctx context.Context, ss *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+		result, err := next(ctx, ss, method, params)
+
+		call, ok := params.(*mcp.CallToolParamsFor[json.RawMessage])
+		if method != "tools/call" || !ok {
+			return result, err
+		}
+		failed := err != nil
+		if res, ok := result.(*mcp.CallToolResult); ok && res != nil && res.IsError {
+			failed = true
+		}
+
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		u.calls[call.Name]++
+		if failed {
+			u.failures[call.Name]++
+			u.errors[errorKind(err)]++
+		}
+
+		return result, err
+	}
+}
+
+// recordQuota keeps the account quota reported by a download.
+func (u *toolUsage) recordQuota(quota *anna.FastDownloadInfo) {
+	if quota == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.quota = quota
+	u.quotaAt = time.Now()
+}
+
+// snapshot returns the usage of the server, with the session budget of ss.
+func (u *toolUsage) snapshot(env *Env, ss *mcp.ServerSession) *ServerStats {
+	u.mu.Lock()
+	stats := &ServerStats{
+		Uptime:   time.Since(u.started).Round(time.Second).String(),
+		Calls:    copyCounts(u.calls),
+		Failures: copyCounts(u.failures),
+		Errors:   copyCounts(u.errors),
+	}
+	if u.quota != nil {
+		quota, at := *u.quota, u.quotaAt
+		stats.RateLimits.Quota = &quota
+		stats.RateLimits.QuotaAt = &at
+	}
+	u.mu.Unlock()
+
+	stats.CacheHits, stats.CacheMisses = anna.CacheStats()
+	if total := stats.CacheHits + stats.CacheMisses; total > 0 {
+		stats.CacheHitRate = float64(stats.CacheHits) / float64(total)
+	}
+
+	stats.RateLimits.Politeness = os.Getenv("ANNAS_POLITENESS")
+	if stats.RateLimits.Politeness == "" {
+		stats.RateLimits.Politeness = anna.PolitenessAggressive
+	}
+	stats.RateLimits.SessionDownloads, stats.RateLimits.SessionBytes = sessionBudgets.usageOf(ss)
+	stats.RateLimits.Cooldowns = anna.Cooldowns()
+	stats.RateLimits.Budgets = anna.BudgetStates()
+	if env != nil {
+		stats.RateLimits.SessionMaxDownloads = env.SessionDownloads
+		stats.RateLimits.SessionMaxBytes = env.SessionBytes
+	}
+
+	return stats
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	copied := make(map[string]int, len(counts))
+	for key, count := range counts {
+		copied[key] = count
+	}
+
+	return copied
+}
+
+// errorKind names the cause of a failed tool call.
+func errorKind(err error) string {
+	var (
+		statusErr   *anna.StatusError
+		apiErr      *anna.APIError
+		cooldownErr *anna.CooldownError
+		netErr      net.Error
+	)
+
+	switch {
+	case err == nil:
+		return "error result"
+	case errors.Is(err, anna.ErrBookNotFound):
+		return "book not found"
+	case errors.Is(err, anna.ErrNoDownloadsLeft):
+		return "no fast downloads left"
+	case errors.Is(err, anna.ErrNotLoggedIn), errors.Is(err, anna.ErrNoCredentials):
+		return "credentials"
+	case errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrSessionBudgetExceeded):
+		return "download budget exceeded"
+	case errors.Is(err, ErrInsufficientSpace):
+		return "insufficient disk space"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &cooldownErr):
+		return "cooling down"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("HTTP %d from %s", statusErr.StatusCode, statusErr.Endpoint)
+	case errors.As(err, &apiErr):
+		return "API error"
+	case anna.IsDeadLink(err):
+		return "dead link"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// ServerStatsTool reports the usage of the server, with the download budget
+// of the session of the caller.
+func ServerStatsTool(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[struct{}]) (*mcp.CallToolResultFor[any], error) {
+	l := logger.GetLogger()
+
+	l.Info("Server stats command called")
+
+	// The stats are still useful without a download configuration, only the
+	// session limits are then left out.
+	env, _ := GetEnv()
+	stats := toolStats.snapshot(env, cc)
+
+	l.Info("Server stats command completed successfully")
+
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: formatServerStats(stats)}},
+		StructuredContent: stats,
+	}, nil
+}
+
+func formatServerStats(stats *ServerStats) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Uptime: %s\n", stats.Uptime)
+
+	total, failed := 0, 0
+	tools := make([]string, 0, len(stats.Calls))
+	for name, count := range stats.Calls {
+		total += count
+		failed += stats.Failures[name]
+		tools = append(tools, name)
+	}
+	slices.Sort(tools)
+	fmt.Fprintf(&sb, "Tool calls: %d, of which %d failed\n", total, failed)
+	for _, name := range tools {
+		fmt.Fprintf(&sb, "  %s: %d", name, stats.Calls[name])
+		if stats.Failures[name] > 0 {
+			fmt.Fprintf(&sb, " (%d failed)", stats.Failures[name])
+		}
+		sb.WriteString("\n")
+	}
+	if len(stats.Errors) > 0 {
+		fmt.Fprintf(&sb, "Errors: %s\n", strings.Join(countsByFrequency(stats.Errors), ", "))
+	}
+
+	if stats.CacheHits+stats.CacheMisses > 0 {
+		fmt.Fprintf(&sb, "Cache: %d hits, %d misses (%.0f%% hit rate)\n", stats.CacheHits, stats.CacheMisses, stats.CacheHitRate*100)
+	} else {
+		sb.WriteString("Cache: not used\n")
+	}
+
+	limits := stats.RateLimits
+	fmt.Fprintf(&sb, "Politeness: %s\n", limits.Politeness)
+	if limits.Quota != nil {
+		fmt.Fprintf(&sb, "Fast downloads: %d left of %d, as of %s\n", limits.Quota.DownloadsLeft, limits.Quota.DownloadsPerDay, limits.QuotaAt.Format(time.RFC3339))
+	} else {
+		sb.WriteString("Fast downloads: unknown until the first download\n")
+	}
+	if limits.SessionMaxDownloads > 0 {
+		fmt.Fprintf(&sb, "Session downloads: %d of %d\n", limits.SessionDownloads, limits.SessionMaxDownloads)
+	}
+	if limits.SessionMaxBytes > 0 {
+		fmt.Fprintf(&sb, "Session bytes: %s of %s\n", formatByteSize(limits.SessionBytes), formatByteSize(limits.SessionMaxBytes))
+	}
+	for _, cooldown := range limits.Cooldowns {
+		fmt.Fprintf(&sb, "Cooling down: %s throttled requests, backing off until %s\n", cooldown.Host, cooldown.Until.Local().Format("15:04"))
+	}
+	for _, budget := range limits.Budgets {
+		fmt.Fprintf(&sb, "Requests to %s hosts: %d in flight", budget.Class, budget.InFlight)
+		if budget.Budget.Concurrency > 0 {
+			fmt.Fprintf(&sb, " of at most %d", budget.Budget.Concurrency)
+		}
+		if budget.Budget.RequestsPerSecond > 0 {
+			fmt.Fprintf(&sb, ", at most %g per second", budget.Budget.RequestsPerSecond)
+		}
+		if budget.Waiting > 0 {
+			fmt.Fprintf(&sb, ", %d waiting", budget.Waiting)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,79 @@
+package modes
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/internal/version"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+)
+
+// StatusReport summarizes the configuration and the account state, without
+// exercising the search like the doctor does.
+type StatusReport struct {
+	Version       string                 `json:"version"`
+	SecretKey     string                 `json:"secret_key"`
+	AccountCookie bool                   `json:"account_cookie"`
+	Quota         *anna.FastDownloadInfo `json:"quota,omitempty"`
+	Storage       string                 `json:"storage"`
+	SearchBackend string                 `json:"search_backend"`
+}
+
+func GetStatus() *StatusReport {
+	report := &StatusReport{
+		Version:       version.GetVersion(),
+		SecretKey:     "not set",
+		AccountCookie: accountCookieSecret.value() != "",
+		Storage:       "not configured",
+		SearchBackend: "scraping",
+	}
+
+	if secretKeySecret.value() != "" {
+		quota, err := GetClient().FastDownloadStatus(context.Background())
+		if err != nil {
+			report.SecretKey = fmt.Sprintf("not accepted: %v", err)
+		} else {
+			report.SecretKey = "accepted"
+			report.Quota = quota
+		}
+
+		if os.Getenv("ANNAS_SEARCH_API") == "true" {
+			report.SearchBackend = "JSON API, falling back to scraping"
+		}
+	}
+
+	if env, err := GetEnv(); err == nil {
+		report.Storage = describeStorage(env.Storage)
+	}
+
+	return report
+}
+
+func describeStorage(cfg storage.Config) string {
+	switch cfg.Backend {
+	case storage.BackendS3:
+		return fmt.Sprintf("s3://%s/%s", cfg.S3Bucket, cfg.S3Prefix)
+	case storage.BackendWebDAV:
+		return cfg.WebDAVURL
+	default:
+		return cfg.LocalPath
+	}
+}
+
+func (r *StatusReport) String() string {
+	cookie := "not set"
+	if r.AccountCookie {
+		cookie = "set"
+	}
+
+	s := fmt.Sprintf("Version: %s\nSecret key: %s\nAccount cookie: %s\nStorage: %s\nSearch backend: %s",
+		r.Version, r.SecretKey, cookie, r.Storage, r.SearchBackend)
+
+	if summary := (&anna.DownloadResult{Quota: r.Quota}).QuotaSummary(); summary != "" {
+		s += "\n" + summary
+	}
+
+	return s
+}
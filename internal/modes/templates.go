@@ -0,0 +1,113 @@
+package modes
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// Presets of ANNAS_RESULT_TEMPLATE.
+const (
+	// ResultsVerbose lists every field of a book on a line of its own,
+	// which is the default.
+	ResultsVerbose = "verbose"
+	// ResultsCompact lists every book on a single line, for clients that
+	// render tool results as plain text.
+	ResultsCompact = "compact"
+)
+
+// resultItem is what result templates are executed with: a book of the
+// results, its position counting from 1, and for deep searches its score
+// and the query variants that found it.
+type resultItem struct {
+	*anna.Book
+	Index          int
+	Score          float64
+	MatchedQueries []string
+}
+
+var templateFuncs = template.FuncMap{
+	"join": func(sep string, items []string) string { return strings.Join(items, sep) },
+	"truncate": func(n int, s string) string {
+		if runes := []rune(s); len(runes) > n {
+			return string(runes[:n]) + "…"
+		}
+		return s
+	},
+}
+
+// resultTemplate returns the template of the books of search results, read
+// from ANNAS_RESULT_TEMPLATE or the file in ANNAS_RESULT_TEMPLATE_FILE. It
+// returns the name of the preset instead if one is set, and an empty name
+// and nil template if neither is.
+func resultTemplate() (string, *template.Template, error) {
+	text := os.Getenv("ANNAS_RESULT_TEMPLATE")
+	if path := os.Getenv("ANNAS_RESULT_TEMPLATE_FILE"); path != "" {
+		if text != "" {
+			return "", nil, errors.New("only one of ANNAS_RESULT_TEMPLATE and ANNAS_RESULT_TEMPLATE_FILE can be set")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+		text = string(data)
+	}
+
+	switch strings.TrimSpace(text) {
+	case "", ResultsVerbose:
+		return ResultsVerbose, nil, nil
+	case ResultsCompact:
+		return ResultsCompact, nil, nil
+	}
+
+	tmpl, err := template.New("result").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", nil, err
+	}
+	// Templates referring to fields that do not exist only fail once they
+	// are executed, which is better found out at startup.
+	if err := tmpl.Execute(&strings.Builder{}, resultItem{Book: &anna.Book{}, Index: 1}); err != nil {
+		return "", nil, err
+	}
+
+	return "", tmpl, nil
+}
+
+// resultRenderer returns a function rendering the books of search results
+// with the template set in ANNAS_RESULT_TEMPLATE, which returns verbose if it
+// is not set. Each rendered book ends with a line break.
+func resultRenderer() func(item resultItem, verbose string) string {
+	l := logger.GetLogger()
+
+	preset, tmpl, err := resultTemplate()
+	if err != nil {
+		l.Warn("Ignoring result template", zap.Error(err))
+		preset = ResultsVerbose
+	}
+
+	return func(item resultItem, verbose string) string {
+		switch {
+		case preset == ResultsCompact:
+			return compactBook(item.Book)
+		case tmpl == nil:
+			return verbose
+		}
+
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, item); err != nil {
+			l.Warn("Failed to render result template", zap.Error(err))
+			return verbose
+		}
+		text := sb.String()
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+
+		return text
+	}
+}
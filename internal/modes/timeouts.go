@@ -0,0 +1,89 @@
+package modes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Operations whose tools are bounded by the timeout in ANNAS_<OPERATION>_TIMEOUT.
+const (
+	opSearch   = "search"
+	opMetadata = "metadata"
+	opDownload = "download"
+)
+
+// timeoutVariable returns the name of the variable holding the timeout of an
+// operation.
+func timeoutVariable(op string) string {
+	return "ANNAS_" + strings.ToUpper(op) + "_TIMEOUT"
+}
+
+// operationTimeout returns the timeout of an operation, given as a duration
+// such as "45s" or "2m" or as a number of seconds, or zero if it has none.
+// Invalid values are ignored with a warning.
+func operationTimeout(op string) time.Duration {
+	value := strings.TrimSpace(os.Getenv(timeoutVariable(op)))
+	if value == "" {
+		return 0
+	}
+
+	timeout, err := parseDuration(value)
+	if err != nil {
+		logger.GetLogger().Warn("Ignoring invalid timeout",
+			zap.String("variable", timeoutVariable(op)),
+			zap.String("value", value),
+		)
+		return 0
+	}
+
+	return timeout
+}
+
+// parseDuration parses a duration such as "45s" or "2m", or a number of
+// seconds.
+func parseDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, atoiErr := strconv.Atoi(value)
+		if atoiErr != nil {
+			return 0, fmt.Errorf("expected a duration such as 45s or 2m, or a number of seconds, got %q", value)
+		}
+		d = time.Duration(seconds) * time.Second
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative, got %q", value)
+	}
+
+	return d, nil
+}
+
+// withTimeout bounds a tool handler by the timeout of its operation, so that
+// a slow mirror or download fails with a clear error before the MCP client
+// gives up on the call.
+func withTimeout[P any](op string, handler mcp.ToolHandlerFor[P, any]) mcp.ToolHandlerFor[P, any] {
+	timeout := operationTimeout(op)
+	if timeout <= 0 {
+		return handler
+	}
+
+	return func(ctx context.Context, cc *mcp.ServerSession, params *mcp.CallToolParamsFor[P]) (*mcp.CallToolResultFor[any], error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result, err := handler(ctx, cc, params)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s did not finish within %s, set %s to allow more time: %w", op, timeout, timeoutVariable(op), err)
+		}
+
+		return result, err
+	}
+}
@@ -0,0 +1,63 @@
+package modes
+
+import (
+	"net/http"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serveHTTP serves the handler in plain text, over TLS with the configured
+// certificate, or over TLS with certificates obtained from Let's Encrypt.
+func serveHTTP(opts ServeOptions, handler http.Handler, authenticated bool) error {
+	l := logger.GetLogger()
+
+	server := &http.Server{
+		Addr:    opts.Addr,
+		Handler: handler,
+	}
+
+	switch {
+	case opts.TLSCertFile != "":
+		l.Info("MCP server started successfully",
+			zap.String("addr", opts.Addr),
+			zap.Bool("authenticated", authenticated),
+			zap.String("tls", "certificate"),
+		)
+
+		return server.ListenAndServeTLS(opts.TLSCertFile, opts.TLSKeyFile)
+
+	case len(opts.AutocertDomains) > 0:
+		// The TLS-ALPN-01 challenge is answered by the TLS listener itself,
+		// which therefore has to be reachable on port 443.
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(opts.AutocertDomains...),
+			Cache:      autocert.DirCache(opts.AutocertCacheDir),
+			Email:      opts.AutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+
+		l.Info("MCP server started successfully",
+			zap.String("addr", opts.Addr),
+			zap.Bool("authenticated", authenticated),
+			zap.String("tls", "autocert"),
+			zap.Strings("domains", opts.AutocertDomains),
+		)
+
+		return server.ListenAndServeTLS("", "")
+
+	default:
+		l.Info("MCP server started successfully",
+			zap.String("addr", opts.Addr),
+			zap.Bool("authenticated", authenticated),
+		)
+
+		if authenticated {
+			l.Warn("Bearer tokens are sent in cleartext, consider --tls-cert or --autocert-domain")
+		}
+
+		return server.ListenAndServe()
+	}
+}
@@ -0,0 +1,299 @@
+package modes
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"github.com/iosifache/annas-mcp/internal/storage"
+	"github.com/iosifache/annas-mcp/pkg/anna"
+	"go.uber.org/zap"
+)
+
+// Outcomes of the verification of a local file.
+const (
+	// VerifyOK files match the hash they were downloaded under.
+	VerifyOK = "ok"
+	// VerifyModified files were changed on purpose after the download, by
+	// unwrapping an archive or embedding EPUB metadata, so their hash
+	// differs.
+	VerifyModified = "modified"
+	// VerifyCorrupted files do not match the hash they were downloaded under.
+	VerifyCorrupted = "corrupted"
+	// VerifyMissing files are in the library index but not on disk.
+	VerifyMissing = "missing"
+	// VerifyUnindexed files are not in the library index, but their hash is
+	// known to Anna's Archive.
+	VerifyUnindexed = "unindexed"
+	// VerifyUnknown files are neither in the library index nor, unless the
+	// lookup was skipped, on Anna's Archive.
+	VerifyUnknown = "unknown"
+	// VerifyFailed files could not be read, for example for lack of
+	// permission.
+	VerifyFailed = "failed"
+)
+
+// FileCheck is the verification of a file of the download directory.
+type FileCheck struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	// Hash is the MD5 hash of the file, empty if it is missing.
+	Hash string `json:"hash,omitempty"`
+	// Expected is the hash the file was downloaded under.
+	Expected string `json:"expected,omitempty"`
+	Title    string `json:"title,omitempty"`
+	// Error tells why a file could not be checked.
+	Error string `json:"error,omitempty"`
+}
+
+// VerifyLocal hashes the files of the download directory of the scope, and
+// checks them against the library index. Files missing from the index are
+// looked up by their hash on Anna's Archive, unless offline is set. The files
+// are hashed in parallel, and progress, if set, is told how many are done.
+func VerifyLocal(ctx context.Context, env *Env, scope string, offline bool, progress HashProgress) ([]FileCheck, error) {
+	l := logger.GetLogger()
+
+	if env.Storage.Backend != "" && env.Storage.Backend != storage.BackendLocal {
+		return nil, errors.New("only downloads to the local filesystem can be verified")
+	}
+
+	root := env.Storage.LocalPath
+	if scope != "" {
+		root = env.Storage.Sub(scope).LocalPath
+	}
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := idx.Entries(scope)
+	entries := make(map[string]library.Entry, len(indexed))
+	archives := make(map[string]library.Entry)
+	for _, entry := range indexed {
+		entries[filepath.Clean(entry.Location)] = entry
+		if entry.Archive != "" {
+			archives[filepath.Clean(entry.Archive)] = entry
+		}
+	}
+
+	// The files below the download directory come first, followed by the
+	// books imported from elsewhere, which are indexed where they are.
+	paths := make([]string, 0)
+	seen := make(map[string]bool)
+	err = walkBooks(root, scope == "", func(path string) error {
+		seen[path] = true
+		paths = append(paths, path)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	walked := len(paths)
+	for _, entry := range indexed {
+		path := filepath.Clean(entry.Location)
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	hashed, err := hashFiles(ctx, paths, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]FileCheck, 0, len(hashed))
+	client := env.Client()
+	for i, file := range hashed {
+		path, hash := file.path, file.hash
+		if i >= walked {
+			entry := entries[path]
+			if errors.Is(file.err, fs.ErrNotExist) {
+				checks = append(checks, FileCheck{Path: path, Status: VerifyMissing, Expected: entry.Hash, Title: entry.Title})
+				continue
+			}
+			if file.err != nil {
+				checks = append(checks, failedCheck(path, entry, file.err))
+				continue
+			}
+			checks = append(checks, checkIndexed(env, entry, path, hash))
+			continue
+		}
+		if file.err != nil {
+			checks = append(checks, failedCheck(path, entries[path], file.err))
+			continue
+		}
+
+		check := FileCheck{Path: path, Hash: hash}
+		if entry, ok := entries[path]; ok {
+			check = checkIndexed(env, entry, path, hash)
+		} else if entry, ok := archives[path]; ok {
+			// The archive a book was unpacked from is the file that was
+			// downloaded, so it keeps the hash.
+			check = checkIndexed(env, library.Entry{Hash: entry.Hash, Title: entry.Title}, path, hash)
+		} else {
+			check.Status = VerifyUnknown
+			if !offline {
+				if err := ctx.Err(); err != nil {
+					return checks, err
+				}
+				if details, err := client.GetBook(ctx, hash); err == nil {
+					check.Status = VerifyUnindexed
+					check.Title = details.Book.Title
+				} else if !errors.Is(err, anna.ErrBookNotFound) {
+					l.Warn("Failed to look up file on Anna's Archive",
+						zap.String("path", path),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// failedCheck reports a file that could not be hashed, so that one
+// unreadable file does not stop the verification of the others.
+func failedCheck(path string, entry library.Entry, err error) FileCheck {
+	logger.GetLogger().Warn("Failed to hash file",
+		zap.String("path", path),
+		zap.Error(err),
+	)
+
+	return FileCheck{Path: path, Status: VerifyFailed, Expected: entry.Hash, Title: entry.Title, Error: err.Error()}
+}
+
+// checkIndexed compares the hash of a file with the one of its library
+// index entry.
+func checkIndexed(env *Env, entry library.Entry, path, hash string) FileCheck {
+	check := FileCheck{Path: path, Hash: hash, Expected: entry.Hash, Title: entry.Title}
+	switch {
+	case hash == entry.Hash:
+		check.Status = VerifyOK
+	case entry.Transform != "" || (env.EmbedEPUB && strings.EqualFold(entry.Format, "epub")):
+		check.Status = VerifyModified
+	default:
+		check.Status = VerifyCorrupted
+	}
+
+	return check
+}
+
+// walkBooks calls fn with the books found below root. Hidden files, such as
+// the library index, and sidecars are skipped, and so are the folders of the
+// download scopes when walking the shared download directory.
+func walkBooks(root string, skipScopes bool, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		name := d.Name()
+		if path != root && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if skipScopes && filepath.Dir(path) == filepath.Clean(root) &&
+				(strings.HasPrefix(name, "session-") || strings.HasPrefix(name, "key-")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || isSidecar(path) {
+			return nil
+		}
+
+		return fn(filepath.Clean(path))
+	})
+}
+
+// isSidecar reports whether a file is a metadata sidecar written next to a
+// book.
+func isSidecar(path string) bool {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext != anna.SidecarOPF && ext != anna.SidecarJSON {
+		return false
+	}
+
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	matches, _ := filepath.Glob(base + ".*")
+	return len(matches) > 1
+}
+
+// md5File returns the MD5 hash of a file, which is how Anna's Archive
+// identifies its files.
+func md5File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyProblems counts the files that are corrupted, missing, or could not
+// be read.
+func verifyProblems(checks []FileCheck) int {
+	problems := 0
+	for _, check := range checks {
+		if check.Status == VerifyCorrupted || check.Status == VerifyMissing || check.Status == VerifyFailed {
+			problems++
+		}
+	}
+
+	return problems
+}
+
+func formatFileChecks(checks []FileCheck) string {
+	if len(checks) == 0 {
+		return "No files found in the download directory."
+	}
+
+	counts := make(map[string]int)
+	var sb strings.Builder
+	for _, check := range checks {
+		counts[check.Status]++
+		if check.Status == VerifyOK {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "%s: %s", check.Status, check.Path)
+		if check.Title != "" {
+			fmt.Fprintf(&sb, " (%s)", check.Title)
+		}
+		if check.Status == VerifyCorrupted {
+			fmt.Fprintf(&sb, ", hash %s instead of %s", check.Hash, check.Expected)
+		}
+		if check.Error != "" {
+			fmt.Fprintf(&sb, ": %s", check.Error)
+		}
+		sb.WriteString("\n")
+	}
+
+	return fmt.Sprintf("Checked %d files: %s\n", len(checks), strings.Join(countsByFrequency(counts), ", ")) + sb.String()
+}
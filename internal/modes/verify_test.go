@@ -0,0 +1,94 @@
+package modes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iosifache/annas-mcp/internal/library"
+)
+
+func TestVerifyLocalGoesPastUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ANNAS_SECRET_KEY", "key")
+	t.Setenv("ANNAS_DOWNLOAD_PATH", dir)
+	env, err := GetEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	book := filepath.Join(dir, "Dune.epub")
+	if err := os.WriteFile(book, []byte("dune"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := md5File(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory cannot be hashed, whoever runs the test.
+	unreadable := t.TempDir()
+
+	idx, err := library.Open(env.LibraryPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []library.Entry{
+		{Hash: "0123456789abcdef0123456789abcdef", Title: "Unreadable", Format: "epub", Location: unreadable},
+		{Hash: hash, Title: "Dune", Format: "epub", Location: book},
+	} {
+		if err := idx.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checks, err := VerifyLocal(context.Background(), env, "", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]FileCheck)
+	for _, check := range checks {
+		statuses[check.Path] = check
+	}
+	if check := statuses[book]; check.Status != VerifyOK {
+		t.Errorf("the readable book is %q, want %q", check.Status, VerifyOK)
+	}
+	if check := statuses[unreadable]; check.Status != VerifyFailed || check.Error == "" {
+		t.Errorf("the unreadable book is %q with error %q, want %q with an error", check.Status, check.Error, VerifyFailed)
+	}
+	if problems := verifyProblems(checks); problems != 1 {
+		t.Errorf("verifyProblems returned %d, want the unreadable book", problems)
+	}
+}
+
+func TestImportLibraryGoesPastUnreadableFiles(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files without permission")
+	}
+	t.Setenv("ANNAS_SECRET_KEY", "key")
+	t.Setenv("ANNAS_DOWNLOAD_PATH", t.TempDir())
+	env, err := GetEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.epub", "b.epub"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	imported, err := ImportLibrary(context.Background(), env, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 {
+		t.Fatalf("ImportLibrary returned %d files, want both", len(imported))
+	}
+	for _, file := range imported {
+		if file.Status != ImportFailed || file.Error == "" {
+			t.Errorf("%s is %q with error %q, want %q with an error", file.Path, file.Status, file.Error, ImportFailed)
+		}
+	}
+}
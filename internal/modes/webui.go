@@ -0,0 +1,20 @@
+package modes
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// webUI is a single page searching and queuing downloads through the REST
+// API, for people without an MCP client.
+//
+//go:embed webui.html
+var webUI []byte
+
+// serveWebUI serves the web UI. The page holds no data, so it is served
+// without authentication, and asks for the token the REST API needs.
+func serveWebUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	w.Write(webUI)
+}
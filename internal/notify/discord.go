@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Discord posts notifications to a channel through a webhook.
+type Discord struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewDiscord(webhookURL string) (*Discord, error) {
+	if !strings.HasPrefix(webhookURL, "https://") {
+		return nil, errors.New("Discord notifications require the HTTPS URL of a webhook")
+	}
+
+	return &Discord{webhookURL: webhookURL, client: http.DefaultClient}, nil
+}
+
+func (d *Discord) Notify(ctx context.Context, n Notification) error {
+	data, err := json.Marshal(map[string]string{
+		"content": "**" + n.Title + "**\n" + n.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	return post(ctx, d.client, "Discord", d.webhookURL, "application/json", bytes.NewReader(data), nil)
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	SinkDiscord  = "discord"
+	SinkTelegram = "telegram"
+	SinkNtfy     = "ntfy"
+)
+
+// Notification reports the outcome of a long-running job, such as a batch
+// of queued downloads.
+type Notification struct {
+	Title   string
+	Message string
+	// Failed marks notifications about jobs that did not succeed, which some
+	// sinks render differently.
+	Failed bool
+}
+
+// Sink delivers notifications to a service the user already looks at.
+type Sink interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+type Config struct {
+	// Sinks are specifications of the form "kind:target", such as
+	// "discord:https://discord.com/api/webhooks/..." or "ntfy:my-topic".
+	Sinks         []string
+	TelegramToken string
+}
+
+// New returns the sinks of the configuration.
+func New(cfg Config) (Sinks, error) {
+	sinks := make(Sinks, 0, len(cfg.Sinks))
+	for _, spec := range cfg.Sinks {
+		kind, target, ok := strings.Cut(spec, ":")
+		if !ok || target == "" {
+			return nil, fmt.Errorf("invalid notification sink %q, expected kind:target", spec)
+		}
+
+		var (
+			sink Sink
+			err  error
+		)
+		switch kind {
+		case SinkDiscord:
+			sink, err = NewDiscord(target)
+		case SinkTelegram:
+			sink, err = NewTelegram(cfg.TelegramToken, target)
+		case SinkNtfy:
+			sink, err = NewNtfy(target)
+		default:
+			err = fmt.Errorf("unknown notification sink: %s", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
+
+// Sinks delivers notifications to several sinks.
+type Sinks []Sink
+
+// Notify delivers the notification to every sink, and returns the errors of
+// those that failed.
+func (s Sinks) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// post sends a request and checks that the service accepted it.
+func post(ctx context.Context, client *http.Client, service, rawURL, contentType string, body io.Reader, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s notification failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s notification failed with status %s", service, resp.Status)
+	}
+
+	return nil
+}
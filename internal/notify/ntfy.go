@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const ntfyServer = "https://ntfy.sh/"
+
+// Ntfy publishes notifications to a topic of ntfy.sh or of a self-hosted
+// ntfy server.
+type Ntfy struct {
+	topicURL string
+	client   *http.Client
+}
+
+// NewNtfy publishes to the given topic URL, or to the topic of that name on
+// ntfy.sh.
+func NewNtfy(topic string) (*Ntfy, error) {
+	if !strings.HasPrefix(topic, "https://") && !strings.HasPrefix(topic, "http://") {
+		topic = ntfyServer + topic
+	}
+
+	return &Ntfy{topicURL: topic, client: http.DefaultClient}, nil
+}
+
+func (f *Ntfy) Notify(ctx context.Context, n Notification) error {
+	header := http.Header{"Title": {n.Title}, "Tags": {"books"}}
+	if n.Failed {
+		header.Set("Tags", "warning")
+		header.Set("Priority", "high")
+	}
+
+	return post(ctx, f.client, "ntfy", f.topicURL, "text/plain", strings.NewReader(n.Message), header)
+}
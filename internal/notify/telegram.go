@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const telegramEndpoint = "https://api.telegram.org/bot%s/sendMessage"
+
+// Telegram sends notifications to a chat through a bot.
+type Telegram struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func NewTelegram(token, chatID string) (*Telegram, error) {
+	if token == "" {
+		return nil, errors.New("Telegram notifications require a bot token")
+	}
+
+	return &Telegram{token: token, chatID: chatID, client: http.DefaultClient}, nil
+}
+
+func (t *Telegram) Notify(ctx context.Context, n Notification) error {
+	data, err := json.Marshal(map[string]string{
+		"chat_id": t.chatID,
+		"text":    n.Title + "\n" + n.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The token is part of the URL, so it is left out of the errors.
+	err = post(ctx, t.client, "Telegram", fmt.Sprintf(telegramEndpoint, t.token), "application/json", bytes.NewReader(data), nil)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("Telegram notification failed: %w", urlErr.Err)
+	}
+
+	return err
+}
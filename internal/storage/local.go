@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type Local struct {
+	Dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if dir == "" {
+		return nil, errors.New("local storage requires a directory")
+	}
+
+	return &Local{Dir: dir}, nil
+}
+
+func (s *Local) Store(name string, r io.Reader, size int64) (string, error) {
+	filePath := filepath.Join(s.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return "", err
+	}
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(filePath)
+		return "", err
+	}
+
+	return filePath, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 stores files in any S3-compatible object store, such as AWS S3, MinIO,
+// or Backblaze B2.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func NewS3(cfg Config) (*S3, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("S3 storage requires an endpoint and a bucket")
+	}
+
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{
+		client: client,
+		bucket: cfg.S3Bucket,
+		prefix: cfg.S3Prefix,
+	}, nil
+}
+
+func (s *S3) Store(name string, r io.Reader, size int64) (string, error) {
+	key := path.Join(s.prefix, name)
+
+	_, err := s.client.PutObject(context.Background(), s.bucket, key, r, size, minio.PutObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucket, key), nil
+}
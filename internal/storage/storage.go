@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	BackendLocal  = "local"
+	BackendS3     = "s3"
+	BackendWebDAV = "webdav"
+)
+
+// Storage persists downloaded files. Names may be slash-separated paths,
+// whose folders are created as needed. Store returns a human-readable
+// location of the written file, such as a path or a URL.
+type Storage interface {
+	Store(name string, r io.Reader, size int64) (string, error)
+}
+
+type Config struct {
+	Backend string `json:"backend"`
+
+	LocalPath string `json:"local_path"`
+
+	S3Endpoint  string `json:"s3_endpoint"`
+	S3Region    string `json:"s3_region"`
+	S3Bucket    string `json:"s3_bucket"`
+	S3Prefix    string `json:"s3_prefix"`
+	S3AccessKey string `json:"-"`
+	S3SecretKey string `json:"-"`
+	S3UseSSL    bool   `json:"s3_use_ssl"`
+
+	WebDAVURL      string `json:"webdav_url"`
+	WebDAVUsername string `json:"webdav_username"`
+	WebDAVPassword string `json:"-"`
+}
+
+func New(cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocal(cfg.LocalPath)
+	case BackendS3:
+		return NewS3(cfg)
+	case BackendWebDAV:
+		return NewWebDAV(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
+
+// Sub returns a copy of the configuration that stores files in the given
+// subdirectory of the original location.
+func (c Config) Sub(dir string) Config {
+	c.LocalPath = filepath.Join(c.LocalPath, dir)
+	c.S3Prefix = path.Join(c.S3Prefix, dir)
+	if c.WebDAVURL != "" {
+		c.WebDAVURL = strings.TrimSuffix(c.WebDAVURL, "/") + "/" + dir
+	}
+
+	return c
+}
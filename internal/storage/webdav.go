@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WebDAV stores files on a WebDAV server, such as Nextcloud or ownCloud. The
+// configured URL must point to an existing collection.
+type WebDAV struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+func NewWebDAV(cfg Config) (*WebDAV, error) {
+	if cfg.WebDAVURL == "" {
+		return nil, errors.New("WebDAV storage requires a URL")
+	}
+
+	return &WebDAV{
+		baseURL:  strings.TrimSuffix(cfg.WebDAVURL, "/"),
+		username: cfg.WebDAVUsername,
+		password: cfg.WebDAVPassword,
+		client:   http.DefaultClient,
+	}, nil
+}
+
+func (s *WebDAV) Store(name string, r io.Reader, size int64) (string, error) {
+	if err := s.ensureCollection(s.baseURL); err != nil {
+		return "", err
+	}
+
+	fileURL := s.baseURL
+	elements := strings.Split(name, "/")
+	for i, element := range elements {
+		fileURL += "/" + url.PathEscape(element)
+		if i < len(elements)-1 {
+			if err := s.ensureCollection(fileURL); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fileURL, r)
+	if err != nil {
+		return "", err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("WebDAV upload failed with status %s", resp.Status)
+	}
+
+	return fileURL, nil
+}
+
+// ensureCollection creates a collection, treating an already existing one as
+// success.
+func (s *WebDAV) ensureCollection(collectionURL string) error {
+	req, err := http.NewRequest("MKCOL", collectionURL, nil)
+	if err != nil {
+		return err
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	// Servers answer 405 Method Not Allowed when the collection exists.
+	if resp.StatusCode == http.StatusMethodNotAllowed || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return nil
+	}
+
+	return fmt.Errorf("WebDAV folder creation failed with status %s", resp.Status)
+}
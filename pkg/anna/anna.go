@@ -0,0 +1,525 @@
+package anna
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+
+	"strings"
+
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	colly "github.com/gocolly/colly/v2"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	AnnasSearchEndpoint   = "https://annas-archive.org/search?q=%s"
+	AnnasDownloadEndpoint = "https://annas-archive.org/dyn/api/fast_download.json?md5=%s&key=%s"
+
+	// probeHash is a syntactically valid MD5 that matches no record, so it can
+	// be used to check a secret key without spending a fast download.
+	probeHash = "00000000000000000000000000000000"
+)
+
+var yearPattern = regexp.MustCompile(`^(1[5-9]|20)\d{2}$`)
+
+func extractMetaInformation(meta string) (language, format, size string) {
+	// The meta format may be:
+	// - "✅ English [en] · EPUB · 0.7MB · 2015 · ..."
+	// - "✅ English [en] · Hindi [hi] · EPUB · 0.7MB · ..."
+	parts := strings.Split(meta, " · ")
+	if len(parts) < 3 {
+		return "", "", ""
+	}
+
+	languagePart := strings.TrimSpace(parts[0])
+	if idx := strings.Index(languagePart, "["); idx > 0 {
+		language = strings.TrimSpace(languagePart[:idx])
+		language = strings.TrimLeft(language, "✅ ")
+	}
+
+	// Format is typically all caps (EPUB, PDF, MOBI, etc.). Size contains MB, KB, GB.
+	formatIdx := -1
+	sizeIdx := -1
+
+	for i := 1; i < len(parts); i++ {
+		part := strings.TrimSpace(parts[i])
+		if strings.Contains(part, "MB") || strings.Contains(part, "KB") || strings.Contains(part, "GB") {
+			sizeIdx = i
+			if formatIdx == -1 && i > 0 {
+				formatIdx = i - 1
+			}
+			break
+		}
+	}
+
+	if formatIdx > 0 && formatIdx < len(parts) {
+		format = strings.TrimSpace(parts[formatIdx])
+	}
+
+	if sizeIdx > 0 && sizeIdx < len(parts) {
+		size = strings.TrimSpace(parts[sizeIdx])
+	}
+
+	return language, format, size
+}
+
+// extractLanguageCode returns the code of the first language in the meta
+// information, for example "en" for "✅ English [en] · EPUB · ...".
+func extractLanguageCode(meta string) string {
+	languagePart := strings.Split(meta, " · ")[0]
+
+	start := strings.Index(languagePart, "[")
+	end := strings.Index(languagePart, "]")
+	if start < 0 || end <= start {
+		return ""
+	}
+
+	return strings.TrimSpace(languagePart[start+1 : end])
+}
+
+// extractYear returns the first part of the meta information that looks like
+// a publication year.
+func extractYear(meta string) string {
+	for _, part := range strings.Split(meta, " · ") {
+		part = strings.TrimSpace(part)
+		if yearPattern.MatchString(part) {
+			return part
+		}
+	}
+
+	return ""
+}
+
+// newCollector returns a collector bound to the context that sends its
+// requests through the HTTP client of the client, and answers them from its
+// cache if it has one. Collectors must not be made asynchronous, as their
+// callbacks write to the variables of the caller without locking.
+func (c *Client) newCollector(ctx context.Context) *colly.Collector {
+	collector := colly.NewCollector(colly.StdlibContext(ctx))
+	collector.SetClient(c.httpClient)
+	c.useCache(collector)
+
+	return collector
+}
+
+// scrapeSearch searches by scraping the HTML search page, calling fn with
+// every result as soon as it is parsed.
+func (c *Client) scrapeSearch(ctx context.Context, query string, fn func(*Book)) ([]*Book, error) {
+	return c.scrapeSearchAt(ctx, fmt.Sprintf(AnnasSearchEndpoint, url.QueryEscape(query)), fn)
+}
+
+// scrapeSearchAt scrapes the search page at the given URL, which is on Anna's
+// Archive or one of its mirrors.
+func (c *Client) scrapeSearchAt(ctx context.Context, fullURL string, fn func(*Book)) ([]*Book, error) {
+	bookListParsed := make([]*Book, 0)
+
+	err := c.fetch(ctx, OperationSearch, fullURL, func(e *colly.HTMLElement) {
+		for _, book := range detectLayout(e).searchResults(e) {
+			bookListParsed = append(bookListParsed, book)
+			if fn != nil {
+				fn(book)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bookListParsed, nil
+}
+
+// fastDownloadURL asks the fast download API for a download URL on the
+// server with the given index, also returning the remaining quota of the
+// account if the API reports it.
+func (c *Client) fastDownloadURL(ctx context.Context, b *Book, source int) (string, *FastDownloadInfo, error) {
+	apiURL := fmt.Sprintf(AnnasDownloadEndpoint, b.Hash, url.QueryEscape(c.secretKey))
+	if source > 0 {
+		apiURL += fmt.Sprintf("&domain_index=%d", source)
+	}
+
+	resp, err := c.get(ctx, apiURL)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp fastDownloadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return "", nil, err
+	}
+	if apiResp.DownloadURL == "" {
+		if apiResp.AccountInfo != nil && apiResp.AccountInfo.DownloadsLeft <= 0 {
+			return "", apiResp.AccountInfo, ErrNoDownloadsLeft
+		}
+		if apiResp.Error != "" {
+			return "", apiResp.AccountInfo, &APIError{Message: apiResp.Error}
+		}
+		return "", apiResp.AccountInfo, errors.New("failed to get download URL")
+	}
+
+	return apiResp.DownloadURL, apiResp.AccountInfo, nil
+}
+
+// maxDownloadSources is the number of servers tried when a download does not
+// match the size advertised on the detail page.
+const maxDownloadSources = 3
+
+// openDownload requests the file of the book from the server with the given
+// index, through the fast download API if the client has a secret key and the
+// member web flow otherwise.
+func (c *Client) openDownload(ctx context.Context, b *Book, source int) (*http.Response, *FastDownloadInfo, error) {
+	var (
+		downloadURL string
+		quota       *FastDownloadInfo
+		err         error
+	)
+	if c.secretKey != "" {
+		downloadURL, quota, err = c.fastDownloadURL(ctx, b, source)
+	} else {
+		downloadURL, err = c.memberDownloadURL(ctx, b, source)
+	}
+	if err != nil {
+		return nil, quota, err
+	}
+
+	// The download URL counts against the quota of the account, whether the
+	// file can be fetched from it or not.
+	resp, err := c.get(ctx, downloadURL)
+	if err != nil {
+		return nil, quota, &QuotaSpentError{Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, quota, &QuotaSpentError{Err: &StatusError{Endpoint: "file download", StatusCode: resp.StatusCode, Status: resp.Status}}
+	}
+	if err := checkPayload(resp, b.Format); err != nil {
+		resp.Body.Close()
+		return nil, quota, &QuotaSpentError{Err: err}
+	}
+
+	return resp, quota, nil
+}
+
+// htmlTitle matches the title of an HTML page.
+var htmlTitle = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// checkPayload returns a *PayloadError if the body of the response is empty
+// or an HTML page, as partner servers send for expired links and waitlists.
+// The start of the body is only peeked at, so the file can still be read
+// from the response.
+func checkPayload(resp *http.Response, format string) error {
+	br := bufio.NewReader(resp.Body)
+	start, err := br.Peek(512)
+	if len(start) == 0 {
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return &PayloadError{Reason: "an empty response"}
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, resp.Body}
+
+	switch strings.ToLower(format) {
+	case "html", "htm":
+		return nil
+	}
+	if !strings.HasPrefix(http.DetectContentType(start), "text/html") {
+		return nil
+	}
+
+	// The page is read a little further, as the title is often past the
+	// scripts and styles of its head.
+	page, _ := io.ReadAll(io.LimitReader(br, 16<<10))
+	title := ""
+	if match := htmlTitle.FindSubmatch(page); match != nil {
+		title = cleanText(string(match[1]))
+	}
+
+	return &PayloadError{Reason: "an HTML page", Title: title}
+}
+
+// Download fetches the book and writes it to the given storage, returning the
+// location of the stored file and the remaining quota. The fast download API
+// is used if the client has a secret key, the member web flow with the account
+// cookie otherwise. Only the hash, title, and format of the book are needed.
+//
+// If the book carries the size advertised on its detail page, the download is
+// checked against it. Files that do not match, usually truncated ones, are
+// fetched again from another server, and if none of them match the last file
+// is stored and flagged in the result. Servers answering with an empty file or
+// an HTML error page instead of the book are skipped for the next one too.
+//
+// Downloads that fail once a download URL was issued return a
+// *QuotaSpentError, as they count against the quota of the account.
+func (c *Client) Download(ctx context.Context, b *Book, store Storage) (_ *DownloadResult, err error) {
+	if err := CheckHash(b.Hash); err != nil {
+		return nil, err
+	}
+
+	spent := false
+	defer func() {
+		if err != nil && spent && !IsQuotaSpent(err) {
+			err = &QuotaSpentError{Err: err}
+		}
+	}()
+
+	l := logger.GetLogger()
+	cfg := c.downloadCfg
+	expected := b.Bytes()
+
+	var (
+		body     io.Reader
+		size     int64
+		quota    *FastDownloadInfo
+		mismatch string
+	)
+	for source := 0; source < maxDownloadSources; source++ {
+		resp, sourceQuota, err := c.openDownload(ctx, b, source)
+		if sourceQuota != nil {
+			quota = sourceQuota
+		}
+		if err == nil || IsQuotaSpent(err) {
+			spent = true
+		}
+		var payloadErr *PayloadError
+		if errors.As(err, &payloadErr) && source+1 < maxDownloadSources {
+			l.Warn("Download server did not return the file, trying another source",
+				zap.String("bookHash", b.Hash),
+				zap.Int("source", source),
+				zap.Error(err),
+			)
+			continue
+		}
+		if err != nil {
+			if body == nil {
+				return nil, err
+			}
+			l.Warn("Alternate download source failed, keeping the mismatched file",
+				zap.String("bookHash", b.Hash),
+				zap.Int("source", source),
+				zap.Error(err),
+			)
+			break
+		}
+
+		if expected <= 0 {
+			defer resp.Body.Close()
+			body, size = newRateLimitedReader(ctx, resp.Body, cfg.MaxRate), resp.ContentLength
+			break
+		}
+
+		// The file is spooled so that it can be checked before it is stored.
+		spooled, spooledSize, err := spoolToTemp(newRateLimitedReader(ctx, resp.Body, cfg.MaxRate))
+		resp.Body.Close()
+		if err != nil {
+			if source+1 < maxDownloadSources {
+				l.Warn("Download was interrupted, trying another source",
+					zap.String("bookHash", b.Hash),
+					zap.Int("source", source),
+					zap.Error(err),
+				)
+				continue
+			}
+			if body == nil {
+				return nil, err
+			}
+			break
+		}
+		defer removeTemp(spooled)
+
+		body, size = spooled, spooledSize
+		mismatch = sizeMismatch(spooledSize, expected)
+		if mismatch == "" {
+			break
+		}
+
+		l.Warn("Downloaded file does not match the advertised size",
+			zap.String("bookHash", b.Hash),
+			zap.Int("source", source),
+			zap.String("mismatch", mismatch),
+		)
+	}
+
+	art, err := normalizeArtifact(body, size, b.Format, cfg.UnwrapArchives)
+	if err != nil {
+		return nil, err
+	}
+	defer art.cleanup()
+	if art.transform != "" {
+		l.Info("Normalized downloaded file",
+			zap.String("bookHash", b.Hash),
+			zap.String("transform", art.transform),
+		)
+	}
+
+	body, size = art.body, art.size
+
+	embed := cfg.EmbedMetadata && strings.EqualFold(art.format, "epub")
+	organize := cfg.Organization != "" && b.Authors == "" && needsDetails(cfg.Organization)
+	metadata := b
+	if embed || len(cfg.Sidecars) > 0 || organize {
+		metadata = c.withDetails(ctx, b)
+	}
+
+	filename := SanitizeFilename(b.Title, art.format, cfg.FilenameEncoding)
+	if cfg.Organization != "" {
+		filename = OrganizedPath(cfg.Organization, metadata, art.format, cfg.FilenameEncoding)
+	}
+
+	if embed {
+		spooled, spooledSize, err := spoolToTemp(body)
+		if err != nil {
+			return nil, err
+		}
+		defer removeTemp(spooled)
+		body, size = spooled, spooledSize
+
+		embedded, embeddedSize, err := embedEPUBMetadata(spooled, spooledSize, metadata)
+		if err != nil {
+			l.Warn("Failed to embed metadata, storing the EPUB unchanged",
+				zap.String("bookHash", b.Hash),
+				zap.Error(err),
+			)
+			spooled.Seek(0, io.SeekStart)
+		} else {
+			defer removeTemp(embedded)
+			body, size = embedded, embeddedSize
+		}
+	}
+
+	counted := &countingReader{r: body}
+	location, err := store.Store(filename, counted, size)
+	if err != nil {
+		return nil, err
+	}
+
+	writeSidecars(store, filename, metadata, cfg.Sidecars)
+
+	var archiveLocation string
+	if art.archive != nil {
+		archiveName := strings.TrimSuffix(filename, path.Ext(filename)) + "." + art.archiveFormat
+		archiveLocation, err = store.Store(archiveName, art.archive, art.archiveSize)
+		if err != nil {
+			// The book is stored already, so only the other files of the
+			// archive are lost.
+			l.Warn("Failed to store the unpacked archive",
+				zap.String("bookHash", b.Hash),
+				zap.Error(err),
+			)
+			archiveLocation = ""
+		}
+	}
+
+	return &DownloadResult{
+		Location:      location,
+		Name:          filename,
+		Size:          counted.n,
+		Format:        art.format,
+		Transform:     art.transform,
+		ArchiveMember: art.member,
+		Archive:       archiveLocation,
+		SizeMismatch:  mismatch,
+		Quota:         quota,
+	}, nil
+}
+
+// ValidateSecretKey checks the secret key against the fast download API
+// without consuming a download.
+func (c *Client) ValidateSecretKey(ctx context.Context) error {
+	_, err := c.FastDownloadStatus(ctx)
+	return err
+}
+
+// FastDownloadStatus validates the secret key without consuming a download
+// and returns the fast download quota of the account. The quota is nil if the
+// API does not report it.
+func (c *Client) FastDownloadStatus(ctx context.Context) (*FastDownloadInfo, error) {
+	if c.secretKey == "" {
+		return nil, errors.New("no secret key set")
+	}
+
+	apiURL := fmt.Sprintf(AnnasDownloadEndpoint, probeHash, url.QueryEscape(c.secretKey))
+
+	resp, err := c.get(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp fastDownloadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+
+	// The API validates the key before looking up the record, so any error
+	// other than an authentication one means the key was accepted.
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if apiResp.Error != "" {
+			return nil, &APIError{Message: apiResp.Error}
+		}
+		return nil, errors.New("secret key was rejected")
+	}
+
+	return apiResp.AccountInfo, nil
+}
+
+// QuotaSummary returns a line describing the remaining fast downloads, or an
+// empty string if the quota is unknown.
+func (r *DownloadResult) QuotaSummary() string {
+	if r.Quota == nil {
+		return ""
+	}
+	if r.Quota.DownloadsPerDay > 0 {
+		return fmt.Sprintf("Downloads remaining today: %d of %d", r.Quota.DownloadsLeft, r.Quota.DownloadsPerDay)
+	}
+
+	return fmt.Sprintf("Downloads remaining today: %d", r.Quota.DownloadsLeft)
+}
+
+// Bytes returns the size of the book in bytes, parsing the displayed size if
+// SizeBytes is not set, or zero if it is unknown.
+func (b *Book) Bytes() int64 {
+	if b.SizeBytes > 0 {
+		return b.SizeBytes
+	}
+
+	return parseSize(b.Size)
+}
+
+func (b *Book) String() string {
+	s := fmt.Sprintf("Title: %s\nAuthors: %s\nPublisher: %s\nLanguage: %s\nFormat: %s\nSize: %s\nYear: %s\nURL: %s\nHash: %s",
+		b.Title, b.Authors, b.Publisher, b.Language, b.Format, b.Size, b.Year, b.URL, b.Hash)
+
+	if len(b.Sources) > 0 {
+		s += "\nSources: " + strings.Join(b.Sources, ", ")
+	}
+	if b.Description != "" {
+		s += "\nDescription: " + b.Description
+	}
+	if len(b.TOC) > 0 {
+		s += "\nTable of contents:\n  " + strings.Join(b.TOC, "\n  ")
+	}
+
+	return s
+}
+
+func (b *Book) ToJSON() (string, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,302 @@
+package anna
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/nwaples/rardecode/v2"
+)
+
+// Kinds of archives recognized in downloads.
+const (
+	archiveZip = "zip"
+	archiveRar = "rar"
+)
+
+var (
+	zipMagic = []byte("PK\x03\x04")
+	rarMagic = []byte("Rar!\x1a\x07")
+)
+
+// containerFormats are archives by design, mapped to the kind of archive they
+// are, and are never unwrapped.
+var containerFormats = map[string]string{
+	"zip":  archiveZip,
+	"epub": archiveZip,
+	"cbz":  archiveZip,
+	"docx": archiveZip,
+	"odt":  archiveZip,
+	"rar":  archiveRar,
+	"cbr":  archiveRar,
+}
+
+// payloadFormats are the formats of the files that may be the book inside an
+// archive holding several files.
+var payloadFormats = map[string]bool{
+	"pdf": true, "epub": true, "mobi": true, "azw": true, "azw3": true,
+	"fb2": true, "djvu": true, "cbz": true, "cbr": true, "txt": true,
+	"doc": true, "docx": true, "rtf": true, "lit": true, "chm": true,
+	"odt": true,
+}
+
+// artifact is a downloaded file after archive detection.
+type artifact struct {
+	body   io.Reader
+	size   int64
+	format string
+	// transform describes how the file was changed, empty if it was not.
+	transform string
+	// member is the path of the file inside the archive it was unpacked
+	// from.
+	member string
+	// archive is the original download, kept when the file was unpacked
+	// from an archive holding other files too.
+	archive       *os.File
+	archiveSize   int64
+	archiveFormat string
+	temps         []*os.File
+}
+
+func (a *artifact) cleanup() {
+	for _, f := range a.temps {
+		removeTemp(f)
+	}
+}
+
+// archiveMember is a file inside an archive.
+type archiveMember struct {
+	name string
+	size int64
+}
+
+func (m archiveMember) format() string {
+	return strings.ToLower(strings.TrimPrefix(path.Ext(m.name), "."))
+}
+
+// normalizeArtifact detects files that arrive wrapped in a zip or rar
+// archive, such as the "epub.zip" files some mirrors serve. If unwrap is set,
+// archives holding a single file are replaced by that file, and archives
+// holding several files by the one most likely to be the book, otherwise they
+// are stored with the extension of the archive so that the name matches the
+// content. Formats that are archives themselves, such as EPUB, are only
+// unwrapped if the archive holds a file of that format.
+func normalizeArtifact(body io.Reader, size int64, format string, unwrap bool) (*artifact, error) {
+	a := &artifact{body: body, size: size, format: format}
+
+	wanted := strings.ToLower(strings.TrimPrefix(format, "."))
+	declared := ""
+	for _, kind := range []string{archiveZip, archiveRar} {
+		if trimmed, ok := strings.CutSuffix(wanted, "."+kind); ok {
+			wanted, declared = trimmed, kind
+			break
+		}
+	}
+	if wanted == "" {
+		return a, nil
+	}
+
+	br := bufio.NewReader(body)
+	a.body = br
+	magic, _ := br.Peek(len(rarMagic))
+	var kind string
+	switch {
+	case bytes.HasPrefix(magic, zipMagic):
+		kind = archiveZip
+	case bytes.Equal(magic, rarMagic):
+		kind = archiveRar
+	default:
+		if declared != "" {
+			a.format = wanted
+			a.transform = fmt.Sprintf("renamed from .%s.%s, the file is not an archive", wanted, declared)
+		}
+		return a, nil
+	}
+	container := containerFormats[wanted] == kind
+
+	if !container && !unwrap {
+		a.format = kind
+		a.transform = fmt.Sprintf("stored as %s instead of %s, the download is a %s archive", kind, wanted, kind)
+		return a, nil
+	}
+
+	spooled, spooledSize, err := spoolToTemp(br)
+	if err != nil {
+		return nil, err
+	}
+	a.temps = append(a.temps, spooled)
+	a.body, a.size = spooled, spooledSize
+
+	members, err := listArchive(kind, spooled, spooledSize)
+	if err != nil {
+		// The file only looks like an archive, so it is kept as is.
+		members = nil
+	}
+	if _, err := spooled.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	payload, wrapped := pickPayload(members, wanted, container)
+
+	switch {
+	case !wrapped && container:
+		// The archive is the book itself.
+		if declared != "" {
+			a.format = wanted
+			a.transform = fmt.Sprintf("renamed from .%s.%s, the archive is the %s itself", wanted, declared, wanted)
+		}
+		return a, nil
+	case !wrapped:
+		a.format = kind
+		a.transform = fmt.Sprintf("stored as %s instead of %s, the archive holds %d files", kind, wanted, len(members))
+		return a, nil
+	case !unwrap:
+		a.format = kind
+		a.transform = fmt.Sprintf("stored as %s instead of %s, the download is a %s archive", kind, wanted, kind)
+		return a, nil
+	}
+
+	rc, err := openMember(kind, spooled, spooledSize, payload.name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	inner, innerSize, err := spoolToTemp(rc)
+	if err != nil {
+		return nil, err
+	}
+	a.temps = append(a.temps, inner)
+	a.body, a.size = inner, innerSize
+
+	a.format = wanted
+	if innerFormat := payload.format(); innerFormat != "" {
+		a.format = innerFormat
+	}
+	a.member = payload.name
+	if len(members) == 1 {
+		a.transform = fmt.Sprintf("unwrapped %s from a %s archive", path.Base(payload.name), kind)
+		return a, nil
+	}
+
+	// The other files may matter too, so the archive is stored next to the
+	// book.
+	if _, err := spooled.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	a.archive, a.archiveSize, a.archiveFormat = spooled, spooledSize, kind
+	a.transform = fmt.Sprintf("unpacked %s from a %s archive of %d files, which is kept next to it", path.Base(payload.name), kind, len(members))
+
+	return a, nil
+}
+
+// pickPayload returns the file of the archive most likely to be the book.
+// Archives holding a single file are assumed to wrap it, unless the book is
+// an archive itself and that file has another format. Otherwise, the largest
+// file of the requested format is picked, or the largest book of another
+// format if there is none.
+func pickPayload(members []archiveMember, wanted string, container bool) (archiveMember, bool) {
+	if len(members) == 1 && (!container || members[0].format() == wanted) {
+		return members[0], true
+	}
+
+	var best archiveMember
+	found, bestWanted := false, false
+	for _, member := range members {
+		format := member.format()
+		isWanted := format == wanted
+		if container && !isWanted {
+			continue
+		}
+		if !isWanted && !payloadFormats[format] {
+			continue
+		}
+		if found && (bestWanted && !isWanted || bestWanted == isWanted && member.size <= best.size) {
+			continue
+		}
+		best, found, bestWanted = member, true, isWanted
+	}
+
+	return best, found
+}
+
+// listArchive returns the files of an archive, leaving out folders and the
+// hidden files archivers add.
+func listArchive(kind string, f *os.File, size int64) ([]archiveMember, error) {
+	var members []archiveMember
+	keep := func(name string) bool {
+		return !strings.HasPrefix(name, "__MACOSX/") && !strings.HasPrefix(path.Base(name), ".")
+	}
+
+	if kind == archiveZip {
+		zr, err := zip.NewReader(f, size)
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if !zf.FileInfo().IsDir() && keep(zf.Name) {
+				members = append(members, archiveMember{name: zf.Name, size: int64(zf.UncompressedSize64)})
+			}
+		}
+		return members, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rr, err := rardecode.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			return members, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !header.IsDir && keep(header.Name) {
+			members = append(members, archiveMember{name: header.Name, size: header.UnPackedSize})
+		}
+	}
+}
+
+// openMember opens the file of an archive with the given name.
+func openMember(kind string, f *os.File, size int64, name string) (io.ReadCloser, error) {
+	if kind == archiveZip {
+		zr, err := zip.NewReader(f, size)
+		if err != nil {
+			return nil, err
+		}
+		for _, zf := range zr.File {
+			if zf.Name == name {
+				return zf.Open()
+			}
+		}
+		return nil, fmt.Errorf("failed to find %s in the zip archive", name)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rr, err := rardecode.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		header, err := rr.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s in the rar archive: %w", name, err)
+		}
+		if header.Name == name {
+			return io.NopCloser(rr), nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package anna
+
+import "strings"
+
+// nameSuffixes are the parts that follow a name after a comma without being
+// another author, as in "Martin Luther King, Jr.".
+var nameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	"phd": true, "md": true, "esq": true,
+}
+
+// SplitAuthors splits the authors of a book, scraped as a single string, into
+// one name per author. Names are separated by ";" or "&", and by "," unless
+// the comma belongs to a "Last, First" name or precedes a suffix such as
+// "Jr.". If normalize is set, "Last, First" names are rewritten as
+// "First Last".
+func SplitAuthors(raw string, normalize bool) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.Join(strings.Fields(name), " ")
+		if normalize {
+			name = DisplayName(name)
+		}
+		if name == "" || seen[strings.ToLower(name)] {
+			return
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+
+	// Semicolons are the separator Anna's Archive uses, so commas within the
+	// parts they separate belong to the names.
+	semicolons := strings.Contains(raw, ";")
+	for _, group := range strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == '&' }) {
+		if semicolons || isInvertedName(group) {
+			add(group)
+			continue
+		}
+
+		pieces := strings.Split(group, ",")
+		if isInvertedList(pieces) {
+			for i := 0; i < len(pieces); i += 2 {
+				add(pieces[i] + "," + pieces[i+1])
+			}
+			continue
+		}
+		for i := 0; i < len(pieces); i++ {
+			name := pieces[i]
+			if i+1 < len(pieces) && isNameSuffix(pieces[i+1]) {
+				name += "," + pieces[i+1]
+				i++
+			}
+			add(name)
+		}
+	}
+
+	return names
+}
+
+// DisplayName rewrites a "Last, First" name as "First Last". Other names are
+// returned unchanged.
+func DisplayName(name string) string {
+	if !isInvertedName(name) {
+		return strings.TrimSpace(name)
+	}
+
+	last, first, _ := strings.Cut(name, ",")
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// isInvertedName reports whether a name has the "Last, First" form: a single
+// comma after a one-word surname, and no suffix after it.
+func isInvertedName(name string) bool {
+	last, first, ok := strings.Cut(name, ",")
+	if !ok || strings.Contains(first, ",") || isNameSuffix(first) {
+		return false
+	}
+
+	return len(strings.Fields(last)) == 1 && strings.TrimSpace(first) != ""
+}
+
+// isInvertedList reports whether comma separated pieces are "Last, First"
+// names listed one after the other, as in "Smith, John, Doe, Jane".
+func isInvertedList(pieces []string) bool {
+	if len(pieces) < 4 || len(pieces)%2 != 0 {
+		return false
+	}
+	for _, piece := range pieces {
+		if len(strings.Fields(piece)) != 1 || isNameSuffix(piece) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNameSuffix(piece string) bool {
+	return nameSuffixes[strings.ToLower(strings.Trim(strings.TrimSpace(piece), "."))]
+}
@@ -0,0 +1,275 @@
+package anna
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// Classes of hosts, whose requests are budgeted separately so that downloads
+// from partner servers and IPFS gateways do not hold up the scraping of Anna's
+// Archive, and the other way around.
+const (
+	// HostArchive is Anna's Archive and the search mirrors of the client.
+	HostArchive = "archive"
+	// HostPartner is the partner servers and other sites files are
+	// downloaded from.
+	HostPartner = "partner"
+	// HostIPFS is the IPFS gateways.
+	HostIPFS = "ipfs"
+)
+
+// HostClasses lists the classes of hosts, in the order budgets are reported.
+var HostClasses = []string{HostArchive, HostPartner, HostIPFS}
+
+// ipfsGateways are the public gateways whose names do not mention IPFS.
+var ipfsGateways = []string{"dweb.link", "w3s.link", "nftstorage.link", "4everland.io", "flk-ipfs.xyz"}
+
+// Budget bounds the requests to a class of hosts. Zero fields leave the
+// requests unbounded.
+type Budget struct {
+	// Concurrency caps the requests in flight. A request counts until its
+	// body is closed, so a download holds its slot until it is complete.
+	Concurrency int `json:"concurrency,omitempty"`
+	// RequestsPerSecond caps the rate at which requests are sent.
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+}
+
+// Budgets holds the budget of each class of hosts.
+type Budgets struct {
+	Archive Budget
+	Partner Budget
+	IPFS    Budget
+}
+
+// DefaultBudgets are the budgets of clients created without WithBudgets:
+// enough requests to Anna's Archive for deep searches and comparisons, a few
+// downloads at a time, and fewer from the slower IPFS gateways.
+var DefaultBudgets = Budgets{
+	Archive: Budget{Concurrency: 8},
+	Partner: Budget{Concurrency: 4},
+	IPFS:    Budget{Concurrency: 2},
+}
+
+// WithBudgets sets the budgets of the classes of hosts. Clients with the same
+// budget for a class share it, since they usually live for a single call.
+func WithBudgets(b Budgets) Option {
+	return func(c *Client) {
+		c.budgets = b
+	}
+}
+
+// ParseBudget parses a budget given as a concurrency, optionally followed by
+// a comma and a number of requests per second, such as "4" or "4,0.5".
+func ParseBudget(value string) (Budget, error) {
+	var b Budget
+	concurrency, perSecond, hasRate := strings.Cut(strings.TrimSpace(value), ",")
+
+	var err error
+	if b.Concurrency, err = strconv.Atoi(strings.TrimSpace(concurrency)); err != nil || b.Concurrency < 0 {
+		return Budget{}, fmt.Errorf("invalid concurrency: %s", concurrency)
+	}
+	if hasRate {
+		if b.RequestsPerSecond, err = strconv.ParseFloat(strings.TrimSpace(perSecond), 64); err != nil || b.RequestsPerSecond < 0 {
+			return Budget{}, fmt.Errorf("invalid requests per second: %s", perSecond)
+		}
+	}
+
+	return b, nil
+}
+
+func (b Budgets) of(class string) Budget {
+	switch class {
+	case HostArchive:
+		return b.Archive
+	case HostIPFS:
+		return b.IPFS
+	default:
+		return b.Partner
+	}
+}
+
+// BudgetState is the use of the budget of a class of hosts.
+type BudgetState struct {
+	Class    string `json:"class"`
+	Budget   Budget `json:"budget"`
+	InFlight int64  `json:"in_flight"`
+	// Waiting is the number of requests waiting for a slot or for the rate
+	// limit.
+	Waiting int64 `json:"waiting,omitempty"`
+}
+
+// hostBudget is the state of the budget of a class of hosts, shared by the
+// clients of the process.
+type hostBudget struct {
+	class    string
+	budget   Budget
+	slots    chan struct{}
+	limiter  *rate.Limiter
+	inFlight atomic.Int64
+	waiting  atomic.Int64
+}
+
+type budgetKey struct {
+	class  string
+	budget Budget
+}
+
+var sharedBudgets = struct {
+	mu      sync.Mutex
+	budgets map[budgetKey]*hostBudget
+}{budgets: make(map[budgetKey]*hostBudget)}
+
+func sharedBudget(class string, b Budget) *hostBudget {
+	sharedBudgets.mu.Lock()
+	defer sharedBudgets.mu.Unlock()
+
+	key := budgetKey{class: class, budget: b}
+	if budget, ok := sharedBudgets.budgets[key]; ok {
+		return budget
+	}
+
+	budget := &hostBudget{class: class, budget: b}
+	if b.Concurrency > 0 {
+		budget.slots = make(chan struct{}, b.Concurrency)
+	}
+	if b.RequestsPerSecond > 0 {
+		budget.limiter = rate.NewLimiter(rate.Limit(b.RequestsPerSecond), 1)
+	}
+	sharedBudgets.budgets[key] = budget
+
+	return budget
+}
+
+// BudgetStates returns the use of the budgets of the classes of hosts that
+// were sent requests.
+func BudgetStates() []BudgetState {
+	sharedBudgets.mu.Lock()
+	defer sharedBudgets.mu.Unlock()
+
+	states := make([]BudgetState, 0, len(sharedBudgets.budgets))
+	for _, budget := range sharedBudgets.budgets {
+		states = append(states, BudgetState{
+			Class:    budget.class,
+			Budget:   budget.budget,
+			InFlight: budget.inFlight.Load(),
+			Waiting:  budget.waiting.Load(),
+		})
+	}
+	slices.SortFunc(states, func(a, b BudgetState) int {
+		return slices.Index(HostClasses, a.Class) - slices.Index(HostClasses, b.Class)
+	})
+
+	return states
+}
+
+// acquire waits for a slot and for the rate limit of the budget, and returns
+// the function giving the slot back.
+func (b *hostBudget) acquire(req *http.Request) (func(), error) {
+	b.waiting.Add(1)
+	defer b.waiting.Add(-1)
+
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	release := func() {
+		b.inFlight.Add(-1)
+		if b.slots != nil {
+			<-b.slots
+		}
+	}
+	b.inFlight.Add(1)
+
+	if b.limiter != nil {
+		if err := b.limiter.Wait(req.Context()); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// budgetTransport applies the budget of the class of the host to the
+// requests it forwards.
+type budgetTransport struct {
+	base    http.RoundTripper
+	budgets Budgets
+	mirrors []string
+}
+
+func newBudgetTransport(base http.RoundTripper, budgets Budgets, mirrors []string) *budgetTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	hosts := make([]string, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		if u, err := url.Parse(mirror); err == nil && u.Hostname() != "" {
+			hosts = append(hosts, strings.ToLower(u.Hostname()))
+		}
+	}
+
+	return &budgetTransport{base: base, budgets: budgets, mirrors: hosts}
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	class := t.classify(req.URL)
+	release, err := sharedBudget(class, t.budgets.of(class)).acquire(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+// classify returns the class of the host of u.
+func (t *budgetTransport) classify(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case strings.Contains(host, "annas-archive"), slices.Contains(t.mirrors, host):
+		return HostArchive
+	case strings.Contains(host, "ipfs"), strings.HasPrefix(u.Path, "/ipfs/"):
+		return HostIPFS
+	}
+	for _, gateway := range ipfsGateways {
+		if host == gateway || strings.HasSuffix(host, "."+gateway) {
+			return HostIPFS
+		}
+	}
+
+	return HostPartner
+}
+
+// releasingBody gives the slot of a request back once its body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
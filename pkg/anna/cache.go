@@ -0,0 +1,76 @@
+package anna
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// WithCache keeps the pages scraped from Anna's Archive, such as search
+// results and detail pages, in dir for ttl, so that repeated identical
+// requests are answered from disk without reaching the site. A zero ttl keeps
+// them until they are removed. Downloads and API calls are never cached.
+func WithCache(dir string, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cacheDir = dir
+		c.cacheTTL = ttl
+	}
+}
+
+// Counters of the requests answered from the caches of all clients, and of
+// those that had to reach the site.
+var cacheHits, cacheMisses atomic.Int64
+
+// CacheStats returns how many requests of the clients with a cache were
+// answered from it, and how many were not, since the program started.
+func CacheStats() (hits, misses int64) {
+	return cacheHits.Load(), cacheMisses.Load()
+}
+
+// useCache makes the collector read and write the cache of the client,
+// dropping the entries that are older than the TTL before they are read.
+// Colly caches every response below 500, so error pages such as throttling
+// answers, anti-bot challenges, and missing pages are dropped once received,
+// instead of being replayed without reaching the site until they expire.
+func (c *Client) useCache(collector *colly.Collector) {
+	if c.cacheDir == "" {
+		return
+	}
+
+	collector.CacheDir = c.cacheDir
+	collector.OnRequest(func(r *colly.Request) {
+		path := cachePath(c.cacheDir, r.URL.String())
+		r.Ctx.Put("cachePath", path)
+		info, err := os.Stat(path)
+		if err == nil && c.cacheTTL > 0 && time.Since(info.ModTime()) > c.cacheTTL {
+			os.Remove(path)
+			err = os.ErrNotExist
+		}
+		if err == nil {
+			cacheHits.Add(1)
+		} else {
+			cacheMisses.Add(1)
+		}
+	})
+	collector.OnError(func(r *colly.Response, _ error) {
+		if r == nil || r.Ctx == nil || r.StatusCode == 0 {
+			return
+		}
+		if path := r.Ctx.Get("cachePath"); path != "" {
+			os.Remove(path)
+		}
+	})
+}
+
+// cachePath returns where colly caches the response to a URL.
+func cachePath(dir, rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	hash := hex.EncodeToString(sum[:])
+
+	return filepath.Join(dir, hash[:2], hash)
+}
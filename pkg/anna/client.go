@@ -0,0 +1,137 @@
+package anna
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Storage persists downloaded files. Names may be slash-separated paths,
+// whose folders are created as needed. Store returns a human-readable
+// location of the written file, such as a path or a URL.
+type Storage interface {
+	Store(name string, r io.Reader, size int64) (string, error)
+}
+
+// Client searches and downloads books from Anna's Archive. The zero value is
+// not usable, create clients with New.
+type Client struct {
+	secretKey     string
+	accountCookie string
+	searchAPI     bool
+	httpClient    *http.Client
+	downloadCfg   DownloadConfig
+	politeness    *Politeness
+	budgets       Budgets
+	fetchers      map[string]Fetcher
+	prefs         Preferences
+	mirrors       []string
+	cacheDir      string
+	cacheTTL      time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithSecretKey sets the membership key used by the fast download API and,
+// if enabled, the JSON search API.
+func WithSecretKey(secretKey string) Option {
+	return func(c *Client) {
+		c.secretKey = secretKey
+	}
+}
+
+// WithAccountCookie sets the aa_account_id2 session cookie of a member
+// account, used to download when no secret key is set.
+func WithAccountCookie(cookie string) Option {
+	return func(c *Client) {
+		c.accountCookie = cookie
+	}
+}
+
+// WithSearchAPI makes searches go through the JSON search API, falling back
+// to scraping if it fails. It requires a secret key.
+func WithSearchAPI(enabled bool) Option {
+	return func(c *Client) {
+		c.searchAPI = enabled
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for all requests. Timeouts should
+// leave room for large downloads.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithDownloadConfig tunes how Download fetches and stores files.
+func WithDownloadConfig(cfg DownloadConfig) Option {
+	return func(c *Client) {
+		c.downloadCfg = cfg
+	}
+}
+
+func New(opts ...Option) *Client {
+	c := &Client{
+		httpClient: defaultHTTPClient,
+		budgets:    DefaultBudgets,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	httpClient := *c.httpClient
+	if c.politeness != nil {
+		httpClient.Transport = newPoliteTransport(httpClient.Transport, *c.politeness)
+	}
+	// Requests wait for the budget of their class of hosts before the pacing
+	// of the politeness settings, which applies to each host on its own.
+	httpClient.Transport = newBudgetTransport(httpClient.Transport, c.budgets, c.mirrors)
+	// Hosts that are cooling down are refused before waiting for the pacing
+	// of the politeness settings.
+	httpClient.Transport = newCooldownTransport(httpClient.Transport)
+	c.httpClient = &httpClient
+
+	return c
+}
+
+// Searcher returns the search backend of the client.
+func (c *Client) Searcher() Searcher {
+	var scraper Searcher = &ScrapeSearcher{client: c}
+	if len(c.mirrors) > 0 {
+		scraper = &RacingSearcher{client: c, mirrors: c.mirrors}
+	}
+
+	if !c.searchAPI || c.secretKey == "" {
+		return scraper
+	}
+
+	return &FallbackSearcher{
+		Primary:  &APISearcher{client: c},
+		Fallback: scraper,
+	}
+}
+
+// Search returns the books matching the query.
+func (c *Client) Search(ctx context.Context, query string) ([]*Book, error) {
+	return c.Searcher().Search(ctx, query)
+}
+
+// SearchStream returns the books matching the query like Search, and also
+// calls fn with every result as soon as it is known, so that callers can show
+// the first hits before the search finishes.
+func (c *Client) SearchStream(ctx context.Context, query string, fn func(*Book)) ([]*Book, error) {
+	return searchStream(ctx, c.Searcher(), query, fn)
+}
+
+// get sends a GET request bound to the context.
+func (c *Client) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.httpClient.Do(req)
+}
@@ -0,0 +1,141 @@
+package anna
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentLookups(t *testing.T) {
+	t.Run("without cache", func(t *testing.T) {
+		concurrentLookups(t, replayClient())
+	})
+	t.Run("with cache", func(t *testing.T) {
+		concurrentLookups(t, replayClient(WithCache(t.TempDir(), time.Hour)))
+	})
+}
+
+// concurrentLookups searches for and looks up Dune concurrently, which the
+// race detector checks when the tests run with -race.
+func concurrentLookups(t *testing.T, client *Client) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			books, err := client.Search(ctx, "dune")
+			if err == nil && len(books) != 2 {
+				err = errors.New("Search did not return the two books of the fixture")
+			}
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			details, err := client.GetBook(ctx, duneHash)
+			if err == nil && details.Book.Title != "Dune" {
+				err = errors.New("GetBook did not return Dune")
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// blockingTransport holds every request until its context ends.
+type blockingTransport struct {
+	started chan struct{}
+	once    sync.Once
+}
+
+func newBlockingTransport() *blockingTransport {
+	return &blockingTransport{started: make(chan struct{})}
+}
+
+func (t *blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() { close(t.started) })
+	<-req.Context().Done()
+
+	return nil, req.Context().Err()
+}
+
+// lookups are the calls of a client that must return once their context
+// ends.
+var lookups = map[string]func(ctx context.Context, c *Client) error{
+	"Search": func(ctx context.Context, c *Client) error {
+		_, err := c.Search(ctx, "dune")
+		return err
+	},
+	"GetBook": func(ctx context.Context, c *Client) error {
+		_, err := c.GetBook(ctx, duneHash)
+		return err
+	},
+	"Download": func(ctx context.Context, c *Client) error {
+		_, err := c.Download(ctx, &Book{Hash: duneHash, Title: "Dune", Format: "epub"}, &memoryStorage{})
+		return err
+	},
+}
+
+func TestLookupsStopAtDeadline(t *testing.T) {
+	for name, lookup := range lookups {
+		t.Run(name, func(t *testing.T) {
+			client := New(WithHTTPClient(&http.Client{Transport: newBlockingTransport()}), WithSecretKey("secret"))
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- lookup(ctx, client) }()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("%s returned %v, want %v", name, err, context.DeadlineExceeded)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s did not return after its deadline", name)
+			}
+		})
+	}
+}
+
+func TestLookupsStopWhenCanceled(t *testing.T) {
+	for name, lookup := range lookups {
+		t.Run(name, func(t *testing.T) {
+			transport := newBlockingTransport()
+			client := New(WithHTTPClient(&http.Client{Transport: transport}), WithSecretKey("secret"))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() { done <- lookup(ctx, client) }()
+
+			select {
+			case <-transport.started:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s sent no request", name)
+			}
+			cancel()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("%s returned %v, want %v", name, err, context.Canceled)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s did not return once canceled", name)
+			}
+		})
+	}
+}
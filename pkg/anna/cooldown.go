@@ -0,0 +1,192 @@
+package anna
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Throttling detection. A host answering throttleStrikes requests in a row
+// with a rate limit or a bot challenge is left alone for a cool-down, which
+// doubles every time the host keeps throttling right after one, up to
+// maxCooldown.
+const (
+	throttleStrikes = 3
+	minCooldown     = 15 * time.Minute
+	maxCooldown     = 2 * time.Hour
+)
+
+// CooldownError is returned instead of sending requests to a host that was
+// throttling them, so that retries do not make a ban worse.
+type CooldownError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("%s is throttling requests, temporarily backing off until %s", e.Host, e.Until.Local().Format("15:04"))
+}
+
+// Cooldown is a host that requests are currently kept away from.
+type Cooldown struct {
+	Host  string    `json:"host"`
+	Until time.Time `json:"until"`
+}
+
+type hostThrottle struct {
+	strikes int
+	until   time.Time
+	// length is the duration of the latest cool-down, kept until the host
+	// answers normally again.
+	length time.Duration
+}
+
+// throttles tracks the hosts of all clients, as a ban applies to the address
+// of the machine rather than to a client.
+var throttles = struct {
+	mu    sync.Mutex
+	hosts map[string]*hostThrottle
+}{hosts: make(map[string]*hostThrottle)}
+
+// Cooldowns returns the hosts that are cooling down, soonest available
+// first.
+func Cooldowns() []Cooldown {
+	throttles.mu.Lock()
+	defer throttles.mu.Unlock()
+
+	now := time.Now()
+	cooldowns := make([]Cooldown, 0)
+	for host, throttle := range throttles.hosts {
+		if throttle.until.After(now) {
+			cooldowns = append(cooldowns, Cooldown{Host: host, Until: throttle.until})
+		}
+	}
+	slices.SortFunc(cooldowns, func(a, b Cooldown) int { return a.Until.Compare(b.Until) })
+
+	return cooldowns
+}
+
+// cooldownTransport refuses requests to hosts that are cooling down, and
+// starts a cool-down when a host keeps throttling.
+type cooldownTransport struct {
+	base http.RoundTripper
+}
+
+func newCooldownTransport(base http.RoundTripper) *cooldownTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &cooldownTransport{base: base}
+}
+
+func (t *cooldownTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if err := checkCooldown(host); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isThrottled(resp):
+		recordThrottled(host, retryAfter(resp))
+	case resp.StatusCode < http.StatusBadRequest:
+		recordAnswered(host)
+	}
+
+	return resp, nil
+}
+
+func checkCooldown(host string) error {
+	throttles.mu.Lock()
+	defer throttles.mu.Unlock()
+
+	if throttle, ok := throttles.hosts[host]; ok && throttle.until.After(time.Now()) {
+		return &CooldownError{Host: host, Until: throttle.until}
+	}
+
+	return nil
+}
+
+func recordThrottled(host string, hint time.Duration) {
+	throttles.mu.Lock()
+	defer throttles.mu.Unlock()
+
+	throttle, ok := throttles.hosts[host]
+	if !ok {
+		throttle = &hostThrottle{}
+		throttles.hosts[host] = throttle
+	}
+
+	throttle.strikes++
+	if throttle.strikes < throttleStrikes {
+		return
+	}
+
+	length := minCooldown
+	if throttle.length > 0 {
+		length = min(2*throttle.length, maxCooldown)
+	}
+	length = max(length, min(hint, maxCooldown))
+
+	throttle.strikes = 0
+	throttle.length = length
+	throttle.until = time.Now().Add(length)
+
+	logger.GetLogger().Warn("Host keeps throttling requests, cooling down",
+		zap.String("host", host),
+		zap.Duration("cooldown", length),
+		zap.Time("until", throttle.until),
+	)
+}
+
+func recordAnswered(host string) {
+	throttles.mu.Lock()
+	defer throttles.mu.Unlock()
+
+	delete(throttles.hosts, host)
+}
+
+// isThrottled reports whether a response is a rate limit or a bot challenge
+// of the anti-bot protection in front of the site.
+func isThrottled(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+
+	server := strings.ToLower(resp.Header.Get("Server"))
+	return resp.Header.Get("Cf-Mitigated") == "challenge" ||
+		strings.Contains(server, "ddos-guard") ||
+		strings.Contains(server, "cloudflare")
+}
+
+// retryAfter parses the Retry-After header, given in seconds or as a date,
+// and returns zero if it is missing.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+
+	return 0
+}
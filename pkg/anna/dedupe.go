@@ -0,0 +1,19 @@
+package anna
+
+// Dedupe returns the books without the repeats of a hash, keeping the first
+// of each, and the number of repeats left out. Result pages overlap when the
+// results shift between two requests, and the same book can be listed twice
+// on a page.
+func Dedupe(books []*Book) ([]*Book, int) {
+	seen := make(map[string]bool, len(books))
+	unique := make([]*Book, 0, len(books))
+	for _, book := range books {
+		if seen[book.Hash] {
+			continue
+		}
+		seen[book.Hash] = true
+		unique = append(unique, book)
+	}
+
+	return unique, len(books) - len(unique)
+}
@@ -0,0 +1,187 @@
+package anna
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+const OpenLibrarySearchEndpoint = "https://openlibrary.org/search.json?title=%s&author=%s&limit=1&fields=isbn"
+
+// RankedBook is a search result merged from several query variants.
+type RankedBook struct {
+	*Book
+	Score          float64  `json:"score"`
+	MatchedQueries []string `json:"matched_queries"`
+}
+
+// MarshalJSON encodes the book like Book, with its score and matched
+// queries, which the promoted Book.MarshalJSON would leave out.
+func (r RankedBook) MarshalJSON() ([]byte, error) {
+	type book Book
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schema_version"`
+		*book
+		Score          float64  `json:"score"`
+		MatchedQueries []string `json:"matched_queries"`
+	}{BookSchemaVersion, (*book)(r.Book), r.Score, r.MatchedQueries})
+}
+
+type openLibrarySearchResponse struct {
+	Docs []struct {
+		ISBN []string `json:"isbn"`
+	} `json:"docs"`
+}
+
+// ResolveISBN looks up an ISBN for the title and author on Open Library,
+// preferring ISBN-13s.
+func (c *Client) ResolveISBN(ctx context.Context, title, author string) (string, error) {
+	resp, err := c.get(ctx, fmt.Sprintf(OpenLibrarySearchEndpoint, url.QueryEscape(title), url.QueryEscape(author)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var apiResp openLibrarySearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return "", err
+	}
+	if len(apiResp.Docs) == 0 || len(apiResp.Docs[0].ISBN) == 0 {
+		return "", errors.New("no ISBN found")
+	}
+
+	isbns := apiResp.Docs[0].ISBN
+	for _, isbn := range isbns {
+		if len(isbn) == 13 {
+			return isbn, nil
+		}
+	}
+
+	return isbns[0], nil
+}
+
+// deepSearchQueries builds the query variants for a deep search, skipping
+// duplicates and empty ones.
+func deepSearchQueries(title, author, isbn string) []string {
+	candidates := []string{
+		title,
+		strings.TrimSpace(title + " " + author),
+		isbn,
+	}
+
+	queries := make([]string, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, query := range candidates {
+		query = strings.TrimSpace(query)
+		if query == "" || seen[query] {
+			continue
+		}
+		seen[query] = true
+		queries = append(queries, query)
+	}
+
+	return queries
+}
+
+// DeepSearch runs several permutations of a query concurrently and merges
+// their results by hash. Books are ranked by reciprocal rank fusion, so those
+// found early by several variants come first, with a bonus for the preferred
+// formats and languages of the client. It also returns the number of results
+// merged into another one with the same hash.
+func (c *Client) DeepSearch(ctx context.Context, title, author, isbn string) ([]*RankedBook, int, error) {
+	l := logger.GetLogger()
+
+	if title == "" && isbn == "" {
+		return nil, 0, errors.New("a title or an ISBN is required")
+	}
+
+	if isbn == "" && title != "" {
+		isbnCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		resolved, err := c.ResolveISBN(isbnCtx, title, author)
+		cancel()
+		if err != nil {
+			l.Info("Could not resolve ISBN for deep search",
+				zap.String("title", title),
+				zap.String("author", author),
+				zap.Error(err),
+			)
+		} else {
+			isbn = resolved
+		}
+	}
+
+	return c.searchVariants(ctx, deepSearchQueries(title, author, isbn))
+}
+
+// searchVariants runs the queries concurrently and merges their results by
+// hash, ranking them as DeepSearch does. It fails only if every query does.
+func (c *Client) searchVariants(ctx context.Context, queries []string) ([]*RankedBook, int, error) {
+	l := logger.GetLogger()
+
+	searcher := c.Searcher()
+	results := make([][]*Book, len(queries))
+	errs := make([]error, len(queries))
+
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+			results[i], errs[i] = searcher.Search(ctx, query)
+		}(i, query)
+	}
+	wg.Wait()
+
+	ranked := make([]*RankedBook, 0)
+	byHash := make(map[string]*RankedBook)
+	failed, duplicates := 0, 0
+	for i, books := range results {
+		if errs[i] != nil {
+			l.Warn("Search variant failed",
+				zap.String("query", queries[i]),
+				zap.Error(errs[i]),
+			)
+			failed++
+			continue
+		}
+
+		// A book listed twice by a variant only counts once for it.
+		books, repeats := Dedupe(books)
+		duplicates += repeats
+		for position, book := range books {
+			entry, ok := byHash[book.Hash]
+			if ok {
+				duplicates++
+			} else {
+				entry = &RankedBook{Book: book, MatchedQueries: make([]string, 0)}
+				byHash[book.Hash] = entry
+				ranked = append(ranked, entry)
+			}
+			entry.Score += 1 / float64(position+1)
+			entry.MatchedQueries = append(entry.MatchedQueries, queries[i])
+		}
+	}
+
+	if failed == len(queries) {
+		return nil, 0, errs[0]
+	}
+
+	for _, entry := range ranked {
+		entry.Score += preferenceWeight * c.prefs.score(entry.Book)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	return ranked, duplicates, nil
+}
@@ -0,0 +1,259 @@
+package anna
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	colly "github.com/gocolly/colly/v2"
+)
+
+const AnnasBookEndpoint = "https://annas-archive.org/md5/%s"
+
+var tocLabelPattern = regexp.MustCompile(`(?i)^(table of contents|contents|toc)$`)
+
+// qualityMarkers maps patterns found in titles and metadata to the hint that
+// is reported for them.
+var qualityMarkers = []struct {
+	pattern *regexp.Regexp
+	hint    string
+}{
+	{regexp.MustCompile(`(?i)\bocr\b`), "OCR text layer"},
+	{regexp.MustCompile(`(?i)\bscan(ned)?\b`), "scanned copy"},
+	{regexp.MustCompile(`(?i)\bretail\b`), "retail edition"},
+	{regexp.MustCompile(`(?i)\b(bad|broken|corrupt(ed)?)\b`), "reported as broken"},
+	{regexp.MustCompile(`(?i)\b(fixed|repaired)\b`), "repaired copy"},
+	{regexp.MustCompile(`(?i)\b(watermark(ed)?)\b`), "watermarked"},
+	{regexp.MustCompile(`(?i)\b(abridged|excerpt|sample)\b`), "possibly incomplete"},
+}
+
+// MinPlausibleSize is the size below which a book file is most likely an
+// error page, a placeholder, or a fragment rather than the book.
+const MinPlausibleSize = 20 << 10
+
+// Kinds of download options.
+const (
+	DownloadKindFast     = "fast"
+	DownloadKindPartner  = "partner"
+	DownloadKindLibgen   = "libgen"
+	DownloadKindZlib     = "zlib"
+	DownloadKindIPFS     = "ipfs"
+	DownloadKindTorrent  = "torrent"
+	DownloadKindExternal = "external"
+)
+
+// typicalWaits is shown for options whose listing has no note of its own.
+var typicalWaits = map[string]string{
+	DownloadKindFast:     "none, requires a membership",
+	DownloadKindPartner:  "a few seconds to several minutes, depending on the waitlist",
+	DownloadKindLibgen:   "none, but mirrors are often slow or offline",
+	DownloadKindZlib:     "none, requires a Z-Library account",
+	DownloadKindIPFS:     "none, but gateways can take minutes to find the file",
+	DownloadKindTorrent:  "depends on seeders, torrents usually hold many files",
+	DownloadKindExternal: "unknown",
+}
+
+// BookDetails is the information scraped from the detail page of a book.
+type BookDetails struct {
+	Book            *Book
+	Meta            string
+	DownloadOptions []*DownloadOption
+	// Supplements lists the files accompanying the book, such as solutions
+	// or the content of a companion CD.
+	Supplements []*SupplementaryFile
+}
+
+// classifyDownloadURL guesses the kind of a download link from its URL.
+func classifyDownloadURL(link string) string {
+	lower := strings.ToLower(link)
+
+	switch {
+	case strings.Contains(lower, "/fast_download/"):
+		return DownloadKindFast
+	case strings.Contains(lower, "/slow_download/"):
+		return DownloadKindPartner
+	case strings.Contains(lower, "ipfs"):
+		return DownloadKindIPFS
+	case strings.HasPrefix(lower, "magnet:"), strings.Contains(lower, "/torrents"), strings.HasSuffix(lower, ".torrent"):
+		return DownloadKindTorrent
+	case strings.Contains(lower, "libgen"), strings.Contains(lower, "library.lol"):
+		return DownloadKindLibgen
+	case strings.Contains(lower, "z-lib"), strings.Contains(lower, "zlibrary"), strings.Contains(lower, "singlelogin"):
+		return DownloadKindZlib
+	default:
+		return DownloadKindExternal
+	}
+}
+
+// extractDownloadOptions returns all download links of the detail page, with
+// the note the page shows next to each of them.
+func extractDownloadOptions(e *colly.HTMLElement) []*DownloadOption {
+	options := make([]*DownloadOption, 0)
+	seen := make(map[string]bool)
+
+	e.ForEach("a.js-download-link", func(_ int, el *colly.HTMLElement) {
+		link := e.Request.AbsoluteURL(el.Attr("href"))
+		if link == "" || seen[link] {
+			return
+		}
+		seen[link] = true
+
+		label := cleanText(el.Text)
+		note := ""
+		if item := el.DOM.Closest("li"); item.Length() > 0 {
+			note = strings.TrimSpace(strings.Replace(cleanText(item.Text()), label, "", 1))
+			note = strings.Trim(note, " -·()")
+		}
+
+		kind := classifyDownloadURL(link)
+		options = append(options, &DownloadOption{
+			Label:       label,
+			URL:         link,
+			Kind:        kind,
+			Note:        note,
+			TypicalWait: typicalWaits[kind],
+		})
+	})
+
+	return options
+}
+
+// extractSources returns the upstream collections listed in the meta
+// information, for example "🚀/lgli/lgrs/zlib".
+func extractSources(meta string) []string {
+	for _, part := range strings.Split(meta, " · ") {
+		part = strings.TrimSpace(part)
+		if idx := strings.Index(part, "/"); idx >= 0 && !strings.Contains(part, " ") {
+			sources := make([]string, 0)
+			for _, source := range strings.Split(part[idx+1:], "/") {
+				if source = strings.ToLower(strings.TrimSpace(source)); source != "" {
+					sources = append(sources, source)
+				}
+			}
+			return sources
+		}
+	}
+
+	return []string{}
+}
+
+// cleanText collapses the whitespace of scraped text while keeping line
+// breaks between paragraphs.
+func cleanText(text string) string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// extractTOC returns the entries of the table of contents, which the detail
+// page shows as a labelled block among the other metadata.
+func extractTOC(doc *goquery.Selection) []string {
+	var toc []string
+
+	doc.Find("div").EachWithBreak(func(_ int, label *goquery.Selection) bool {
+		if label.Children().Length() > 0 || !tocLabelPattern.MatchString(strings.TrimSpace(label.Text())) {
+			return true
+		}
+
+		toc = make([]string, 0)
+		for _, entry := range strings.Split(cleanText(label.Next().Text()), "\n") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				toc = append(toc, entry)
+			}
+		}
+		return len(toc) == 0
+	})
+
+	return toc
+}
+
+// GetBook scrapes the detail page of the book with the given hash.
+func (c *Client) GetBook(ctx context.Context, hash string) (*BookDetails, error) {
+	var details *BookDetails
+
+	err := c.fetch(ctx, OperationDetails, fmt.Sprintf(AnnasBookEndpoint, hash), func(e *colly.HTMLElement) {
+		details = detectLayout(e).bookDetails(e, hash)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, ErrBookNotFound
+	}
+
+	return details, nil
+}
+
+// qualityHints returns the quality markers found in the given texts.
+func qualityHints(texts ...string) []string {
+	joined := strings.Join(texts, " ")
+
+	hints := make([]string, 0)
+	for _, marker := range qualityMarkers {
+		if marker.pattern.MatchString(joined) {
+			hints = append(hints, marker.hint)
+		}
+	}
+
+	return hints
+}
+
+// CompareBooks fetches the details of all given books concurrently. Books that
+// cannot be fetched are reported with an error instead of failing the whole
+// comparison.
+func (c *Client) CompareBooks(ctx context.Context, hashes []string) []*BookComparison {
+	comparisons := make([]*BookComparison, len(hashes))
+
+	var wg sync.WaitGroup
+	for i, hash := range hashes {
+		wg.Add(1)
+		go func(i int, hash string) {
+			defer wg.Done()
+
+			details, err := c.GetBook(ctx, hash)
+			if err != nil {
+				comparisons[i] = &BookComparison{
+					Hash:         hash,
+					Sources:      []string{},
+					QualityHints: []string{},
+					Error:        err.Error(),
+				}
+				return
+			}
+
+			book := details.Book
+			hints := qualityHints(book.Title, details.Meta)
+			if strings.EqualFold(book.Format, "djvu") {
+				hints = append(hints, "image-based format")
+			}
+			if book.SizeBytes > 0 && book.SizeBytes < MinPlausibleSize {
+				hints = append(hints, "suspiciously small file")
+			}
+			hints = append(hints, c.prefs.hints(book)...)
+
+			comparisons[i] = &BookComparison{
+				Hash:         hash,
+				Title:        book.Title,
+				Authors:      book.Authors,
+				Language:     book.Language,
+				Format:       book.Format,
+				Size:         book.Size,
+				SizeBytes:    book.SizeBytes,
+				Year:         book.Year,
+				Sources:      book.Sources,
+				QualityHints: hints,
+			}
+		}(i, hash)
+	}
+	wg.Wait()
+
+	return comparisons
+}
@@ -0,0 +1,28 @@
+// Package anna searches and downloads books from Anna's Archive.
+//
+// A Client is created with New and configured with options:
+//
+//	client := anna.New(
+//		anna.WithSecretKey(os.Getenv("ANNAS_SECRET_KEY")),
+//		anna.WithSearchAPI(true),
+//	)
+//
+//	books, err := client.Search(ctx, "The Name of the Rose")
+//	if err != nil {
+//		return err
+//	}
+//
+//	store := myStorage{} // any type implementing Storage
+//	result, err := client.Download(ctx, books[0], store)
+//	if errors.Is(err, anna.ErrNoDownloadsLeft) {
+//		return errTryTomorrow
+//	} else if err != nil {
+//		return err
+//	}
+//	fmt.Println("Stored at", result.Location)
+//
+// All methods doing network I/O take a context, which cancels the underlying
+// requests. Failures are reported with the sentinel errors of this package,
+// a *StatusError for unexpected HTTP statuses, or an *APIError for messages
+// returned by the JSON APIs.
+package anna
@@ -0,0 +1,215 @@
+package anna
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	containerRootfilePattern = regexp.MustCompile(`<rootfile\b[^>]*\bfull-path="([^"]+)"`)
+	opfMetadataOpenPattern   = regexp.MustCompile(`<(?:[\w-]+:)?metadata\b[^>]*>`)
+	junkFilenamePattern      = regexp.MustCompile(`(?i)(\.(docx?|pdf|epub|txt|rtf|html?|indd|mobi)$|^microsoft word - )`)
+)
+
+// junkValues are placeholders conversion tools write instead of real
+// metadata.
+var junkValues = map[string]bool{
+	"":               true,
+	"unknown":        true,
+	"untitled":       true,
+	"unknown author": true,
+	"author":         true,
+	"und":            true,
+	"zxx":            true,
+}
+
+func isJunkMetadata(value string) bool {
+	value = strings.TrimSpace(html.UnescapeString(value))
+	return junkValues[strings.ToLower(value)] || junkFilenamePattern.MatchString(value)
+}
+
+// spoolToTemp copies the reader into a temporary file, rewound to its start.
+func spoolToTemp(r io.Reader) (*os.File, int64, error) {
+	tmp, err := os.CreateTemp("", "annas-mcp-*")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := io.Copy(tmp, r)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeTemp(tmp)
+		return nil, 0, err
+	}
+
+	return tmp, size, nil
+}
+
+func removeTemp(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// embedEPUBMetadata rewrites the OPF of the EPUB in src, filling in the
+// title, authors, and language wherever they are missing or junk. It returns
+// a new temporary file with the result.
+func embedEPUBMetadata(src *os.File, size int64, book *Book) (*os.File, int64, error) {
+	zr, err := zip.NewReader(src, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opfPath, err := findOPFPath(zr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dst, err := os.CreateTemp("", "annas-mcp-*.epub")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := rewriteEPUB(zr, dst, opfPath, book); err != nil {
+		removeTemp(dst)
+		return nil, 0, err
+	}
+
+	written, err := dst.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = dst.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeTemp(dst)
+		return nil, 0, err
+	}
+
+	return dst, written, nil
+}
+
+func findOPFPath(zr *zip.Reader) (string, error) {
+	container, err := readZipFile(zr, "META-INF/container.xml")
+	if err != nil {
+		return "", fmt.Errorf("not a valid EPUB: %w", err)
+	}
+
+	match := containerRootfilePattern.FindSubmatch(container)
+	if match == nil {
+		return "", errors.New("not a valid EPUB: no rootfile in container.xml")
+	}
+
+	return html.UnescapeString(string(match[1])), nil
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// rewriteEPUB copies all entries verbatim, which keeps the uncompressed
+// mimetype entry first as required, except for the OPF.
+func rewriteEPUB(zr *zip.Reader, dst io.Writer, opfPath string, book *Book) error {
+	zw := zip.NewWriter(dst)
+
+	for _, f := range zr.File {
+		if f.Name != opfPath {
+			if err := zw.Copy(f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		opf, err := readZipFile(zr, f.Name)
+		if err != nil {
+			return err
+		}
+
+		header := f.FileHeader
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(fillOPFMetadata(opf, book)); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// fillOPFMetadata edits the OPF textually rather than round-tripping it
+// through an XML decoder, which would mangle namespaces and formatting.
+func fillOPFMetadata(opf []byte, book *Book) []byte {
+	doc := string(opf)
+
+	openTag := opfMetadataOpenPattern.FindStringIndex(doc)
+	if openTag == nil {
+		return opf
+	}
+
+	language := book.LanguageCode
+	if language == "" {
+		language = book.Language
+	}
+
+	insertions := ""
+	for _, field := range []struct {
+		element string
+		value   string
+	}{
+		{"title", book.Title},
+		{"creator", book.Authors},
+		{"language", language},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		var escaped strings.Builder
+		xml.EscapeText(&escaped, []byte(field.value))
+
+		pattern := regexp.MustCompile(`(?s)<dc:` + field.element + `\b([^>]*?)(?:/>|>(.*?)</dc:` + field.element + `>)`)
+		match := pattern.FindStringSubmatchIndex(doc)
+		if match == nil {
+			insertions += "\n    <dc:" + field.element + ">" + escaped.String() + "</dc:" + field.element + ">"
+			continue
+		}
+
+		content := ""
+		if match[4] >= 0 {
+			content = doc[match[4]:match[5]]
+		}
+		if !isJunkMetadata(content) {
+			continue
+		}
+
+		attrs := doc[match[2]:match[3]]
+		replacement := "<dc:" + field.element + attrs + ">" + escaped.String() + "</dc:" + field.element + ">"
+		doc = doc[:match[0]] + replacement + doc[match[1]:]
+	}
+
+	// Replacements only happen after the metadata tag, so its position is
+	// still valid.
+	if insertions != "" {
+		tag := doc[openTag[0]:openTag[1]]
+		if !strings.Contains(doc, "xmlns:dc=") {
+			tag = strings.Replace(tag, "metadata", `metadata xmlns:dc="http://purl.org/dc/elements/1.1/"`, 1)
+		}
+		doc = doc[:openTag[0]] + tag + insertions + doc[openTag[1]:]
+	}
+
+	return []byte(doc)
+}
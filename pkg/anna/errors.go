@@ -0,0 +1,98 @@
+package anna
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var (
+	ErrBookNotFound         = errors.New("book not found")
+	ErrNoDownloadsLeft      = errors.New("no fast downloads left today")
+	ErrNotLoggedIn          = errors.New("account cookie is missing, invalid, or expired")
+	ErrSearchAPIUnavailable = errors.New("search API is not available for this key")
+	ErrNoCredentials        = errors.New("either a secret key or an account cookie is required")
+)
+
+// StatusError is returned when Anna's Archive or a download server answers
+// with an unexpected HTTP status.
+type StatusError struct {
+	// Endpoint names the request that failed, for example "file download".
+	Endpoint   string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %s", e.Endpoint, e.Status)
+}
+
+// APIError is an error message returned by one of the JSON APIs.
+type APIError struct {
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// PayloadError is returned when a download server answers with something
+// other than the file, such as an empty body or an HTML error page sent with
+// a 200 status.
+type PayloadError struct {
+	// Reason tells what was received instead of the file.
+	Reason string
+	// Title is the title of the HTML page, which often tells what went
+	// wrong.
+	Title string
+}
+
+func (e *PayloadError) Error() string {
+	if e.Title != "" {
+		return fmt.Sprintf("file download returned %s instead of the file: %s", e.Reason, e.Title)
+	}
+
+	return fmt.Sprintf("file download returned %s instead of the file", e.Reason)
+}
+
+// QuotaSpentError is returned for downloads that failed after Anna's Archive
+// issued a download URL, which counts against the daily quota of the account
+// even though the file was not stored.
+type QuotaSpentError struct {
+	Err error
+}
+
+func (e *QuotaSpentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QuotaSpentError) Unwrap() error {
+	return e.Err
+}
+
+// IsQuotaSpent reports whether a failed download counted against the quota of
+// the account.
+func IsQuotaSpent(err error) bool {
+	var spentErr *QuotaSpentError
+	return errors.As(err, &spentErr)
+}
+
+// IsDeadLink reports whether a download failed because the file could not be
+// reached, such as a link that is gone or a partner server that timed out,
+// rather than because of the account or the request.
+func IsDeadLink(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Endpoint == "file download" &&
+			(statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone || statusErr.StatusCode >= 500)
+	}
+
+	var payloadErr *PayloadError
+	if errors.As(err, &payloadErr) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,223 @@
+package anna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+	colly "github.com/gocolly/colly/v2"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Operations that scrape pages, each of which can use its own Fetcher.
+const (
+	OperationSearch  = "search"
+	OperationDetails = "details"
+	OperationResolve = "resolve"
+)
+
+// Operations lists the operations that scrape pages.
+var Operations = []string{OperationSearch, OperationDetails, OperationResolve}
+
+// Fetcher loads the pages that are scraped. The parsing does not depend on
+// how a page was loaded, so a fetcher rendering pages in a browser can stand
+// in for plain HTTP requests on pages that need JavaScript.
+type Fetcher interface {
+	// Fetch loads the page at rawURL and calls fn with its root html
+	// element.
+	Fetch(ctx context.Context, rawURL string, fn func(e *colly.HTMLElement)) error
+}
+
+// WithFetcher makes the given operation load its pages with f instead of
+// plain HTTP requests.
+func WithFetcher(operation string, f Fetcher) Option {
+	return func(c *Client) {
+		if c.fetchers == nil {
+			c.fetchers = make(map[string]Fetcher)
+		}
+		c.fetchers[operation] = f
+	}
+}
+
+// fetcher returns the fetcher of the operation.
+func (c *Client) fetcher(operation string) Fetcher {
+	if f, ok := c.fetchers[operation]; ok {
+		return f
+	}
+
+	return &collyFetcher{client: c, operation: operation}
+}
+
+// fetch loads the page at rawURL with the fetcher of the operation.
+func (c *Client) fetch(ctx context.Context, operation, rawURL string, fn func(e *colly.HTMLElement)) error {
+	logger.GetLogger().Info("Visiting URL", zap.String("url", rawURL))
+
+	return c.fetcher(operation).Fetch(ctx, rawURL, fn)
+}
+
+// collyFetcher loads pages with plain HTTP requests through the HTTP client
+// of the client, answering them from its cache if it has one.
+//
+// Every fetch has a collector of its own, which is not asynchronous, so its
+// callbacks run before Visit returns, on the goroutine of the fetch. The
+// books and errors they fill in are thus never shared between fetches, and
+// the callers of Fetch can read them without locking.
+type collyFetcher struct {
+	client    *Client
+	operation string
+}
+
+func (f *collyFetcher) Fetch(ctx context.Context, rawURL string, fn func(e *colly.HTMLElement)) error {
+	collector := f.client.newCollector(ctx)
+
+	var visitErr error
+	collector.OnHTML("html", fn)
+	collector.OnError(func(r *colly.Response, err error) {
+		// Colly reports error statuses with their text only, they are kept
+		// as a StatusError so that callers can tell them apart.
+		if r != nil && r.StatusCode >= http.StatusBadRequest {
+			err = &StatusError{
+				Endpoint:   f.operation + " page",
+				StatusCode: r.StatusCode,
+				Status:     fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+			}
+		}
+		visitErr = err
+	})
+
+	if err := collector.Visit(rawURL); err != nil && visitErr == nil {
+		visitErr = err
+	}
+	collector.Wait()
+
+	return visitErr
+}
+
+// defaultSettle is how long BrowserFetcher lets scripts run after a page has
+// loaded.
+const defaultSettle = time.Second
+
+// BrowserFetcher renders pages in a headless Chrome or Chromium, for pages
+// whose content is filled in by JavaScript. A single browser is started on
+// the first fetch and shared by the later ones, each in a tab of its own, so
+// a fetcher should be shared by the clients of a process and closed when it
+// is no longer needed.
+//
+// Requests of the browser do not go through the HTTP client of the client,
+// so they are neither cached nor paced, but they are still refused for hosts
+// that are cooling down.
+type BrowserFetcher struct {
+	// ExecPath is the browser executable, looked up among the usual names
+	// and locations if empty.
+	ExecPath string
+	// Settle is how long scripts can run after the page has loaded, one
+	// second if zero.
+	Settle time.Duration
+
+	once    sync.Once
+	browser context.Context
+	cancel  context.CancelFunc
+}
+
+func (f *BrowserFetcher) Fetch(ctx context.Context, rawURL string, fn func(e *colly.HTMLElement)) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if err := checkCooldown(u.Hostname()); err != nil {
+		return err
+	}
+
+	f.once.Do(func() {
+		options := chromedp.DefaultExecAllocatorOptions[:]
+		if f.ExecPath != "" {
+			options = append(options, chromedp.ExecPath(f.ExecPath))
+		}
+		allocator, cancelAllocator := chromedp.NewExecAllocator(context.Background(), options...)
+		browser, cancelBrowser := chromedp.NewContext(allocator)
+		f.browser = browser
+		f.cancel = func() {
+			cancelBrowser()
+			cancelAllocator()
+		}
+	})
+
+	tab, cancelTab := chromedp.NewContext(f.browser)
+	defer cancelTab()
+	// Tabs live in the context of the browser, so the fetch is bound to its
+	// own context separately.
+	stop := context.AfterFunc(ctx, cancelTab)
+	defer stop()
+
+	settle := f.Settle
+	if settle <= 0 {
+		settle = defaultSettle
+	}
+
+	var (
+		page     string
+		location string
+	)
+	err = chromedp.Run(tab,
+		chromedp.Navigate(rawURL),
+		chromedp.WaitReady("body", chromedp.ByQuery),
+		chromedp.Sleep(settle),
+		chromedp.Location(&location),
+		chromedp.OuterHTML("html", &page, chromedp.ByQuery),
+	)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", rawURL, err)
+	}
+
+	if location != "" {
+		if final, err := url.Parse(location); err == nil {
+			u = final
+		}
+	}
+
+	return renderedElement(u, page, fn)
+}
+
+// Close stops the browser of the fetcher, if it was started.
+func (f *BrowserFetcher) Close() {
+	f.once.Do(func() {})
+	if f.cancel != nil {
+		f.cancel()
+	}
+}
+
+// renderedElement calls fn with the root html element of a rendered page,
+// which is built as colly would build it for a response from u.
+func renderedElement(u *url.URL, page string, fn func(e *colly.HTMLElement)) error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return err
+	}
+	root := doc.Find("html")
+	if root.Length() == 0 {
+		return errors.New("the rendered page has no html element")
+	}
+
+	request := &colly.Request{URL: u, Method: http.MethodGet, Ctx: colly.NewContext()}
+	response := &colly.Response{
+		StatusCode: http.StatusOK,
+		Body:       []byte(page),
+		Ctx:        request.Ctx,
+		Request:    request,
+		Headers:    &http.Header{},
+	}
+	fn(colly.NewHTMLElementFromSelectionNode(response, root.First(), root.Nodes[0], 0))
+
+	return nil
+}
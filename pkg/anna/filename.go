@@ -0,0 +1,115 @@
+package anna
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-unidecode"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Filename encodings. Unicode filenames are NFC-normalized, which avoids the
+// duplicates sync tools create when macOS (NFD) and other systems (NFC) meet.
+// ASCII filenames are transliterated, for filesystems and tools that mangle
+// anything else.
+const (
+	FilenameUnicode = "unicode"
+	FilenameASCII   = "ascii"
+)
+
+// maxFilenameLength is the maximum length in bytes of generated filenames and
+// folders. Filesystems allow 255 bytes per component, the lower limit leaves
+// room for the suffixes sync tools and copies append. Full paths need no
+// limit: Linux and macOS allow thousands of bytes, and on Windows the os
+// package prefixes long paths with \\?\, which lifts the 260 character
+// MAX_PATH for the files the server writes.
+const maxFilenameLength = 200
+
+// windowsReservedNames cannot be used as filenames on Windows, with or
+// without an extension, so that "aux.tar.epub" is reserved as well.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename builds a filename from a title and an extension that is
+// valid on Linux, macOS, Windows (NTFS) and SMB shares, using the given
+// filename encoding.
+func SanitizeFilename(title, format, encoding string) string {
+	title = norm.NFC.String(title)
+	if encoding == FilenameASCII {
+		title = unidecode.Unidecode(title)
+	}
+
+	ext := sanitizeComponent(strings.TrimPrefix(format, "."))
+	name := sanitizeComponent(title)
+
+	// Titles taken from filenames often carry the extension already, which
+	// would otherwise end up doubled as in "Title.epub.epub".
+	if ext != "" && len(name) > len(ext)+1 && strings.EqualFold(name[len(name)-len(ext)-1:], "."+ext) {
+		name = strings.TrimRight(name[:len(name)-len(ext)-1], ". ")
+	}
+
+	if name == "" {
+		name = "book"
+	}
+	if isReservedName(name) {
+		name = "_" + name
+	}
+
+	suffix := ""
+	if ext != "" {
+		suffix = "." + ext
+	}
+
+	if len(name)+len(suffix) > maxFilenameLength {
+		name = truncateUTF8(name, maxFilenameLength-len(suffix))
+		name = strings.TrimRight(name, ". ")
+	}
+
+	return name + suffix
+}
+
+// isReservedName tells if Windows reserves a name, which it checks on the
+// part before the first dot, ignoring trailing spaces.
+func isReservedName(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	return windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))]
+}
+
+// sanitizeComponent replaces characters that are illegal in filenames and
+// trims the leading and trailing characters Windows does not preserve.
+func sanitizeComponent(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			// Control characters, including newlines in scraped titles.
+			sb.WriteRune(' ')
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			sb.WriteRune('_')
+		case r == utf8.RuneError:
+			sb.WriteRune('_')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	name := strings.Join(strings.Fields(sb.String()), " ")
+	return strings.TrimRight(strings.TrimLeft(name, " "), ". ")
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
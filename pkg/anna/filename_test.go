@@ -0,0 +1,87 @@
+package anna
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		format   string
+		encoding string
+		want     string
+	}{
+		{"plain", "Dune", "epub", FilenameUnicode, "Dune.epub"},
+		{"dotted format", "Dune", ".pdf", FilenameUnicode, "Dune.pdf"},
+		{"illegal characters", `a<b>c:d"e/f\g|h?i*j`, "epub", FilenameUnicode, "a_b_c_d_e_f_g_h_i_j.epub"},
+		{"control characters", "Line\none\ttwo\x7f", "epub", FilenameUnicode, "Line one two.epub"},
+		{"trailing dots and spaces", "  Title. . ", "epub", FilenameUnicode, "Title.epub"},
+		{"reserved name", "con", "pdf", FilenameUnicode, "_con.pdf"},
+		{"reserved name with extension", "aux.tar", "epub", FilenameUnicode, "_aux.tar.epub"},
+		{"reserved name with space", "NUL .txt", "epub", FilenameUnicode, "_NUL .txt.epub"},
+		{"reserved prefix", "Console", "epub", FilenameUnicode, "Console.epub"},
+		{"doubled extension", "Title.EPUB", "epub", FilenameUnicode, "Title.epub"},
+		{"empty title", "", "epub", FilenameUnicode, "book.epub"},
+		{"only illegal trimmed", " . ", "epub", FilenameUnicode, "book.epub"},
+		{"no format", "Title", "", FilenameUnicode, "Title"},
+		{"ascii", "Преступление и наказание", "epub", FilenameASCII, "Prestuplenie i nakazanie.epub"},
+		{"nfc", "Café", "epub", FilenameUnicode, "Café.epub"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.title, tt.format, tt.encoding); got != tt.want {
+				t.Errorf("SanitizeFilename(%q, %q) = %q, want %q", tt.title, tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameTruncation(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+	}{
+		{"ascii", strings.Repeat("a", 300)},
+		{"two byte runes", strings.Repeat("é", 300)},
+		{"three byte runes", strings.Repeat("書", 300)},
+		{"four byte runes", strings.Repeat("𝔸", 300)},
+		{"trailing dot at the cut", strings.Repeat("a", maxFilenameLength-6) + ". . b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeFilename(tt.title, "epub", FilenameUnicode)
+			if len(got) > maxFilenameLength {
+				t.Errorf("len(%q) = %d, want at most %d", got, len(got), maxFilenameLength)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("SanitizeFilename returned invalid UTF-8 %q", got)
+			}
+			if !strings.HasSuffix(got, ".epub") {
+				t.Errorf("SanitizeFilename lost the extension: %q", got)
+			}
+			if name := strings.TrimSuffix(got, ".epub"); strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+				t.Errorf("SanitizeFilename left a trailing dot or space: %q", got)
+			}
+		})
+	}
+}
+
+func TestSanitizeFolder(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Tolkien, J. R. R.", "Tolkien, J. R. R"},
+		{"aux.d", "_aux.d"},
+		{"", unknownFolder},
+		{"a/b", "a_b"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFolder(tt.name, FilenameUnicode); got != tt.want {
+			t.Errorf("sanitizeFolder(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
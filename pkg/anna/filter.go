@@ -0,0 +1,129 @@
+package anna
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResultFilter narrows search results by attributes Anna's Archive does not
+// search by, so they are checked against the parsed results instead.
+type ResultFilter struct {
+	// ExcludeFormats lists the formats, such as "djvu", left out of the
+	// results.
+	ExcludeFormats []string `json:"exclude_formats,omitempty"`
+	// YearFrom and YearTo bound the publication year of the results. Results
+	// without a year are excluded when either is set.
+	YearFrom int `json:"year_from,omitempty"`
+	YearTo   int `json:"year_to,omitempty"`
+	// MinSize and MaxSize bound the size of the results in bytes. Results
+	// of unknown size are excluded when either is set.
+	MinSize int64 `json:"min_size,omitempty"`
+	MaxSize int64 `json:"max_size,omitempty"`
+}
+
+// NormalizeFormats lowercases formats and strips their leading dots, dropping
+// empty ones.
+func NormalizeFormats(formats []string) []string {
+	normalized := make([]string, 0, len(formats))
+	for _, format := range formats {
+		if format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), ".")); format != "" {
+			normalized = append(normalized, format)
+		}
+	}
+
+	return normalized
+}
+
+// Validate checks that the filter can match anything.
+func (f *ResultFilter) Validate() error {
+	if f.YearFrom > 0 && f.YearTo > 0 && f.YearFrom > f.YearTo {
+		return fmt.Errorf("year range %d-%d is empty", f.YearFrom, f.YearTo)
+	}
+	if f.MinSize > 0 && f.MaxSize > 0 && f.MinSize > f.MaxSize {
+		return fmt.Errorf("size range %s-%s is empty", formatSize(f.MinSize), formatSize(f.MaxSize))
+	}
+
+	return nil
+}
+
+// Empty reports whether the filter lets every result through.
+func (f *ResultFilter) Empty() bool {
+	return len(f.ExcludeFormats) == 0 && f.YearFrom <= 0 && f.YearTo <= 0 && f.MinSize <= 0 && f.MaxSize <= 0
+}
+
+// Describe lists the criteria of the filter in words.
+func (f *ResultFilter) Describe() []string {
+	descriptions := make([]string, 0)
+	switch {
+	case f.YearFrom > 0 && f.YearTo > 0:
+		descriptions = append(descriptions, fmt.Sprintf("year %d-%d", f.YearFrom, f.YearTo))
+	case f.YearFrom > 0:
+		descriptions = append(descriptions, fmt.Sprintf("year %d or later", f.YearFrom))
+	case f.YearTo > 0:
+		descriptions = append(descriptions, fmt.Sprintf("year %d or earlier", f.YearTo))
+	}
+	switch {
+	case f.MinSize > 0 && f.MaxSize > 0:
+		descriptions = append(descriptions, fmt.Sprintf("size %s-%s", formatSize(f.MinSize), formatSize(f.MaxSize)))
+	case f.MinSize > 0:
+		descriptions = append(descriptions, "size "+formatSize(f.MinSize)+" or more")
+	case f.MaxSize > 0:
+		descriptions = append(descriptions, "size "+formatSize(f.MaxSize)+" or less")
+	}
+	if len(f.ExcludeFormats) > 0 {
+		descriptions = append(descriptions, "no "+strings.Join(f.ExcludeFormats, ", "))
+	}
+
+	return descriptions
+}
+
+// Matches reports whether a search result passes the filter, and if not,
+// why.
+func (f *ResultFilter) Matches(b *Book) (bool, string) {
+	for _, format := range f.ExcludeFormats {
+		if strings.EqualFold(b.Format, format) {
+			return false, "format " + strings.ToLower(b.Format)
+		}
+	}
+
+	if f.YearFrom > 0 || f.YearTo > 0 {
+		year, err := strconv.Atoi(b.Year)
+		if err != nil {
+			return false, "unknown year"
+		}
+		if (f.YearFrom > 0 && year < f.YearFrom) || (f.YearTo > 0 && year > f.YearTo) {
+			return false, "year " + b.Year
+		}
+	}
+
+	if f.MinSize > 0 || f.MaxSize > 0 {
+		size := b.Bytes()
+		switch {
+		case size <= 0:
+			return false, "unknown size"
+		case f.MinSize > 0 && size < f.MinSize:
+			return false, "too small"
+		case f.MaxSize > 0 && size > f.MaxSize:
+			return false, "too large"
+		}
+	}
+
+	return true, ""
+}
+
+// Apply returns the books that pass the filter, and counts the others by the
+// reason they were excluded.
+func (f *ResultFilter) Apply(books []*Book) ([]*Book, map[string]int) {
+	kept := make([]*Book, 0, len(books))
+	excluded := make(map[string]int)
+	for _, book := range books {
+		if ok, reason := f.Matches(book); ok {
+			kept = append(kept, book)
+		} else {
+			excluded[reason]++
+		}
+	}
+
+	return kept, excluded
+}
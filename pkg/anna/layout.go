@@ -0,0 +1,169 @@
+package anna
+
+import (
+	"strings"
+
+	colly "github.com/gocolly/colly/v2"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// pageLayout parses the pages rendered with one version of the site layout.
+// When Anna's Archive is redesigned, a parser for the new layout is added to
+// layouts instead of changing the existing one, so that mirrors still serving
+// the old pages keep working.
+type pageLayout struct {
+	name string
+	// detect reports whether the page carries the markers of the layout.
+	detect func(page *colly.HTMLElement) bool
+	// searchResults returns the books listed on a search page.
+	searchResults func(page *colly.HTMLElement) []*Book
+	// bookDetails returns the book shown on a detail page, or nil if the page
+	// has none.
+	bookDetails func(page *colly.HTMLElement, hash string) *BookDetails
+}
+
+// layouts holds the supported layouts, newest first. Pages without the
+// markers of any of them are parsed with the newest one.
+var layouts = []*pageLayout{
+	tailwind2024Layout,
+}
+
+// detectLayout returns the layout the page was rendered with and logs it.
+func detectLayout(page *colly.HTMLElement) *pageLayout {
+	l := logger.GetLogger()
+
+	for _, layout := range layouts {
+		if layout.detect(page) {
+			l.Info("Parsing page",
+				zap.String("url", page.Request.URL.String()),
+				zap.String("layout", layout.name),
+			)
+			return layout
+		}
+	}
+
+	l.Warn("Unknown page layout, the site may have been redesigned",
+		zap.String("url", page.Request.URL.String()),
+		zap.String("layout", layouts[0].name),
+	)
+
+	return layouts[0]
+}
+
+// tailwind2024Layout is the Tailwind layout the site has used since 2024,
+// recognizable by its Material Design icon classes such as
+// "icon-[mdi--user-edit]".
+var tailwind2024Layout = &pageLayout{
+	name: "tailwind-2024",
+	detect: func(page *colly.HTMLElement) bool {
+		return page.DOM.Find("[class*='icon-[mdi--']").Length() > 0 ||
+			page.DOM.Find("a.custom-a[href^='/md5/']").Length() > 0
+	},
+	searchResults: func(page *colly.HTMLElement) []*Book {
+		books := make([]*Book, 0)
+		page.ForEach("a[href^='/md5/']", func(_ int, e *colly.HTMLElement) {
+			// Only process the first link (the cover image link), not the duplicate title link
+			if e.Attr("class") != "custom-a block mr-2 sm:mr-4 hover:opacity-80" {
+				return
+			}
+
+			books = append(books, parseSearchResult(e))
+		})
+
+		return books
+	},
+	bookDetails: parseBookDetails,
+}
+
+// parseSearchResult extracts a book from the cover link of a search result.
+func parseSearchResult(e *colly.HTMLElement) *Book {
+	bookInfoDiv := e.DOM.Parent().Find("div.max-w-full")
+
+	title := bookInfoDiv.Find("a[href^='/md5/']").Text()
+
+	authorsRaw := bookInfoDiv.Find("a[href^='/search'] span.icon-\\[mdi--user-edit\\]").Parent().Text()
+	authors := strings.TrimSpace(authorsRaw)
+
+	publisherRaw := bookInfoDiv.Find("a[href^='/search'] span.icon-\\[mdi--company\\]").Parent().Text()
+	publisher := strings.TrimSpace(publisherRaw)
+
+	meta := bookInfoDiv.Find("div.text-gray-800").Text()
+
+	language, format, size := extractMetaInformation(meta)
+	year := extractYear(meta)
+
+	link := e.Attr("href")
+	hash := strings.TrimPrefix(link, "/md5/")
+
+	coverURL := ""
+	if src := e.DOM.Find("img").AttrOr("src", ""); src != "" {
+		coverURL = e.Request.AbsoluteURL(src)
+	}
+
+	return &Book{
+		Language:     language,
+		LanguageCode: extractLanguageCode(meta),
+		Format:       format,
+		Size:         size,
+		SizeBytes:    parseSize(size),
+		Year:         year,
+		Title:        strings.TrimSpace(title),
+		Publisher:    publisher,
+		Authors:      authors,
+		AuthorList:   SplitAuthors(authors, false),
+		URL:          e.Request.AbsoluteURL(link),
+		Hash:         hash,
+		CoverURL:     coverURL,
+		Sources:      extractSources(meta),
+	}
+}
+
+// parseBookDetails extracts the book shown on a detail page.
+func parseBookDetails(e *colly.HTMLElement, hash string) *BookDetails {
+	title := strings.TrimSpace(e.DOM.Find("div.font-semibold.text-2xl").First().Text())
+	title = strings.TrimSpace(strings.TrimSuffix(title, "🔍"))
+	if title == "" {
+		return nil
+	}
+
+	authors := strings.TrimSpace(e.DOM.Find("a[href^='/search'] span.icon-\\[mdi--user-edit\\]").First().Parent().Text())
+	publisher := strings.TrimSpace(e.DOM.Find("a[href^='/search'] span.icon-\\[mdi--company\\]").First().Parent().Text())
+
+	meta := ""
+	e.ForEachWithBreak("div.text-gray-800", func(_ int, el *colly.HTMLElement) bool {
+		if strings.Contains(el.Text, " · ") {
+			meta = strings.TrimSpace(el.Text)
+			return false
+		}
+		return true
+	})
+
+	language, format, size := extractMetaInformation(meta)
+
+	description := cleanText(e.DOM.Find("div.js-md5-top-box-description").First().Text())
+
+	return &BookDetails{
+		Book: &Book{
+			Language:     language,
+			LanguageCode: extractLanguageCode(meta),
+			Format:       format,
+			Size:         size,
+			SizeBytes:    parseSize(size),
+			Year:         extractYear(meta),
+			Title:        title,
+			Publisher:    publisher,
+			Authors:      authors,
+			AuthorList:   SplitAuthors(authors, false),
+			URL:          e.Request.URL.String(),
+			Hash:         hash,
+			Sources:      extractSources(meta),
+
+			Description: description,
+			TOC:         extractTOC(e.DOM),
+		},
+		Meta:            meta,
+		DownloadOptions: extractDownloadOptions(e),
+		Supplements:     extractSupplements(e, hash),
+	}
+}
@@ -0,0 +1,91 @@
+package anna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	AnnasMemberDownloadEndpoint = "https://annas-archive.org/fast_download/%s/0/%d"
+	AnnasAccountCookie          = "aa_account_id2"
+)
+
+// memberDownloadURL resolves a download URL through the fast download pages
+// that logged-in members use in the browser, on the server with the given
+// index. Depending on the server, the page either redirects to the file or
+// links to it.
+func (c *Client) memberDownloadURL(ctx context.Context, b *Book, source int) (string, error) {
+	if c.accountCookie == "" {
+		return "", ErrNoCredentials
+	}
+
+	pageURL := fmt.Sprintf(AnnasMemberDownloadEndpoint, b.Hash, source)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.AddCookie(&http.Cookie{Name: AnnasAccountCookie, Value: c.accountCookie})
+
+	client := *c.httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		location, err := resp.Location()
+		if err != nil {
+			return "", err
+		}
+		// Logged-out visitors are redirected to the login page.
+		if strings.Contains(location.Path, "/login") || strings.Contains(location.Path, "/account") {
+			return "", ErrNotLoggedIn
+		}
+		return location.String(), nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{Endpoint: "fast download page", StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	base, _ := url.Parse(pageURL)
+	downloadURL := ""
+	doc.Find("a[href]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		href, _ := a.Attr("href")
+		link, err := base.Parse(href)
+		if err != nil || link.Host == base.Host || !strings.HasPrefix(link.Scheme, "http") {
+			return true
+		}
+		if strings.Contains(strings.ToLower(a.Text()), "download") {
+			downloadURL = link.String()
+			return false
+		}
+		return true
+	})
+
+	if downloadURL == "" {
+		// Without a valid session the page asks to log in instead of linking.
+		if doc.Find("a[href^='/login']").Length() > 0 {
+			return "", ErrNotLoggedIn
+		}
+		return "", errors.New("no download link found on the fast download page")
+	}
+
+	return downloadURL, nil
+}
@@ -0,0 +1,147 @@
+package anna
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mozillazg/go-unidecode"
+	"golang.org/x/text/unicode/norm"
+)
+
+// organizationPresets are the names accepted in place of an organization
+// template.
+var organizationPresets = map[string]string{
+	"flat":        "{Title}",
+	"by-author":   "{Author}/{Title}",
+	"by-language": "{Language}/{Title}",
+	"by-format":   "{Format}/{Title}",
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// unknownFolder names folders whose placeholders have no value, such as the
+// author folder of a book without authors.
+const unknownFolder = "Unknown"
+
+// OrganizationTemplate resolves a preset name (flat, by-author, by-language
+// or by-format) or checks a template such as "{Author}/{Title}/{Title}". The
+// placeholders are {Title}, {Author} (the first author), {Authors},
+// {Publisher}, {Language}, {Format}, {Year} and {Hash}, and the last path
+// element names the file, which always gets the extension of the format.
+func OrganizationTemplate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if preset, ok := organizationPresets[strings.ToLower(value)]; ok {
+		return preset, nil
+	}
+	if strings.Trim(value, "/") == "" {
+		return "", fmt.Errorf("empty organization template: %q", value)
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(value, -1) {
+		if _, ok := placeholderValue(match[1], &Book{}, ""); !ok {
+			return "", fmt.Errorf("unknown placeholder %s in organization template", match[0])
+		}
+	}
+
+	return value, nil
+}
+
+// OrganizedPath renders an organization template for a book stored in the
+// given format, returning a slash-separated path relative to the download
+// directory. Every element is made safe the way SanitizeFilename does.
+func OrganizedPath(template string, b *Book, format, encoding string) string {
+	elements := make([]string, 0)
+	for _, element := range strings.Split(template, "/") {
+		if element = strings.TrimSpace(element); element != "" {
+			elements = append(elements, element)
+		}
+	}
+	if len(elements) == 0 {
+		return SanitizeFilename(b.Title, format, encoding)
+	}
+
+	rendered := make([]string, 0, len(elements))
+	for i, element := range elements {
+		value := placeholderPattern.ReplaceAllStringFunc(element, func(placeholder string) string {
+			value, _ := placeholderValue(placeholder[1:len(placeholder)-1], b, format)
+			return value
+		})
+
+		if i == len(elements)-1 {
+			rendered = append(rendered, SanitizeFilename(value, format, encoding))
+		} else {
+			rendered = append(rendered, sanitizeFolder(value, encoding))
+		}
+	}
+
+	return strings.Join(rendered, "/")
+}
+
+func placeholderValue(name string, b *Book, format string) (string, bool) {
+	switch name {
+	case "Title":
+		return b.Title, true
+	case "Author":
+		return firstAuthor(b.Authors), true
+	case "Authors":
+		return b.Authors, true
+	case "Publisher":
+		return b.Publisher, true
+	case "Language":
+		return b.Language, true
+	case "Format":
+		return strings.ToLower(strings.TrimPrefix(format, ".")), true
+	case "Year":
+		return b.Year, true
+	case "Hash":
+		return b.Hash, true
+	default:
+		return "", false
+	}
+}
+
+// firstAuthor returns the first of the authors, which Anna's Archive
+// separates with semicolons.
+func firstAuthor(authors string) string {
+	if names := SplitAuthors(authors, false); len(names) > 0 {
+		return names[0]
+	}
+
+	return ""
+}
+
+// sanitizeFolder makes a folder name safe with the rules of SanitizeFilename.
+func sanitizeFolder(name, encoding string) string {
+	name = norm.NFC.String(name)
+	if encoding == FilenameASCII {
+		name = unidecode.Unidecode(name)
+	}
+
+	name = sanitizeComponent(name)
+	if len(name) > maxFilenameLength {
+		name = strings.TrimRight(truncateUTF8(name, maxFilenameLength), ". ")
+	}
+	if name == "" {
+		return unknownFolder
+	}
+	if isReservedName(name) {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// needsDetails reports whether the template uses metadata that downloads
+// do not carry, which then comes from the detail page.
+func needsDetails(template string) bool {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "Title", "Format", "Hash":
+		default:
+			return true
+		}
+	}
+
+	return false
+}
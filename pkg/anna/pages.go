@@ -0,0 +1,65 @@
+package anna
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// SearchPages scrapes up to pages result pages of the query, one after the
+// other, calling fn with every result as soon as it is parsed. Only the page
+// being parsed is held in memory, so that exports of many pages do not keep
+// the results, let alone the documents, of the previous ones. Results whose
+// hash was already passed to fn, as happens when the results shift between
+// two pages, are left out. It stops after the first page without results, or
+// when fn returns an error, which is then returned. It returns the number of
+// results passed to fn, and the number of repeats left out.
+func (c *Client) SearchPages(ctx context.Context, query string, pages int, fn func(*Book) error) (int, int, error) {
+	l := logger.GetLogger()
+
+	// Only the hashes are kept, which stay small even for large exports.
+	seen := make(map[string]bool)
+	count, duplicates := 0, 0
+	for page := 1; page <= pages; page++ {
+		pageURL := fmt.Sprintf(AnnasSearchEndpoint, url.QueryEscape(query))
+		if page > 1 {
+			pageURL += fmt.Sprintf("&page=%d", page)
+		}
+
+		var fnErr error
+		found := 0
+		_, err := c.scrapeSearchAt(ctx, pageURL, func(b *Book) {
+			found++
+			if fnErr != nil {
+				return
+			}
+			if seen[b.Hash] {
+				duplicates++
+				return
+			}
+			seen[b.Hash] = true
+			if fnErr = fn(b); fnErr == nil {
+				count++
+			}
+		})
+		if err != nil {
+			return count, duplicates, err
+		}
+		if fnErr != nil {
+			return count, duplicates, fnErr
+		}
+
+		l.Info("Search page scraped",
+			zap.Int("page", page),
+			zap.Int("booksCount", found),
+		)
+		if found == 0 {
+			break
+		}
+	}
+
+	return count, duplicates, nil
+}
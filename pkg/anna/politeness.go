@@ -0,0 +1,225 @@
+package anna
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Names of the politeness profiles.
+const (
+	PolitenessAggressive = "aggressive"
+	PolitenessNormal     = "normal"
+	PolitenessStealth    = "stealth"
+)
+
+// Politeness paces the requests of a client to lower the risk of being
+// blocked by the anti-bot protection of the site. Most users should pick one
+// of the profiles returned by PolitenessProfile.
+type Politeness struct {
+	// Delay is the minimum time between two requests to the same host.
+	Delay time.Duration
+	// RandomDelay adds up to this much random jitter to Delay.
+	RandomDelay time.Duration
+	// Parallelism caps the concurrent requests per host, zero disables the
+	// cap.
+	Parallelism int
+	// UserAgents are sent in turn as the User-Agent header. A single entry
+	// sets a fixed user agent, none keeps the one of the request.
+	UserAgents []string
+	// Headers are added to every request that does not set them already.
+	Headers map[string]string
+}
+
+var browserUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+}
+
+var browserHeaders = map[string]string{
+	"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+	"Accept-Language": "en-US,en;q=0.5",
+}
+
+// PolitenessProfile returns the named politeness profile:
+//   - aggressive: no pacing, for mirrors and self-hosted instances.
+//   - normal: short delays, two requests at a time, and a browser user agent.
+//   - stealth: long random delays, one request at a time, rotating browser
+//     user agents and headers.
+func PolitenessProfile(name string) (Politeness, error) {
+	switch name {
+	case PolitenessAggressive:
+		return Politeness{}, nil
+	case PolitenessNormal:
+		return Politeness{
+			Delay:       500 * time.Millisecond,
+			RandomDelay: 500 * time.Millisecond,
+			Parallelism: 2,
+			UserAgents:  browserUserAgents[:1],
+			Headers:     browserHeaders,
+		}, nil
+	case PolitenessStealth:
+		return Politeness{
+			Delay:       2 * time.Second,
+			RandomDelay: 3 * time.Second,
+			Parallelism: 1,
+			UserAgents:  browserUserAgents,
+			Headers:     browserHeaders,
+		}, nil
+	default:
+		return Politeness{}, fmt.Errorf("unknown politeness profile: %s", name)
+	}
+}
+
+// WithPoliteness paces all requests of the client, including those of the
+// HTTP client set with WithHTTPClient, according to the given settings.
+// Clients with the same settings are paced together.
+func WithPoliteness(p Politeness) Option {
+	return func(c *Client) {
+		c.politeness = &p
+	}
+}
+
+// politeTransport applies a Politeness to the requests it forwards.
+type politeTransport struct {
+	base  http.RoundTripper
+	p     Politeness
+	hosts *politeHosts
+}
+
+// politeHosts paces the hosts of a politeness setting. It is shared by the
+// clients of the process with the same setting, since they usually live for
+// a single call, and the pacing must hold across calls.
+type politeHosts struct {
+	mu    sync.Mutex
+	hosts map[string]*hostPacer
+	next  int
+}
+
+type hostPacer struct {
+	slots chan struct{}
+	// mu serializes the waits, so requests leave Delay apart.
+	mu   sync.Mutex
+	last time.Time
+}
+
+var sharedPoliteness = struct {
+	mu    sync.Mutex
+	hosts map[string]*politeHosts
+}{hosts: make(map[string]*politeHosts)}
+
+func sharedPoliteHosts(p Politeness) *politeHosts {
+	sharedPoliteness.mu.Lock()
+	defer sharedPoliteness.mu.Unlock()
+
+	// Politeness holds slices and maps, so the setting is keyed by its
+	// printed form, in which fmt sorts the headers.
+	key := fmt.Sprintf("%+v", p)
+	if hosts, ok := sharedPoliteness.hosts[key]; ok {
+		return hosts
+	}
+
+	hosts := &politeHosts{hosts: make(map[string]*hostPacer)}
+	sharedPoliteness.hosts[key] = hosts
+
+	return hosts
+}
+
+func newPoliteTransport(base http.RoundTripper, p Politeness) *politeTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &politeTransport{
+		base:  base,
+		p:     p,
+		hosts: sharedPoliteHosts(p),
+	}
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	pacer, userAgent := t.hosts.prepare(req.URL.Host, t.p)
+
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	for name, value := range t.p.Headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+
+	if pacer.slots != nil {
+		select {
+		case pacer.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		defer func() { <-pacer.slots }()
+	}
+
+	if err := pacer.wait(req, t.p); err != nil {
+		return nil, err
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// prepare returns the pacer of the host and the user agent for the next
+// request.
+func (h *politeHosts) prepare(host string, p Politeness) (*hostPacer, string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	pacer, ok := h.hosts[host]
+	if !ok {
+		pacer = &hostPacer{}
+		if p.Parallelism > 0 {
+			pacer.slots = make(chan struct{}, p.Parallelism)
+		}
+		h.hosts[host] = pacer
+	}
+
+	userAgent := ""
+	if len(p.UserAgents) > 0 {
+		userAgent = p.UserAgents[h.next%len(p.UserAgents)]
+		h.next++
+	}
+
+	return pacer, userAgent
+}
+
+func (h *hostPacer) wait(req *http.Request, p Politeness) error {
+	if p.Delay <= 0 && p.RandomDelay <= 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delay := p.Delay
+	if p.RandomDelay > 0 {
+		delay += rand.N(p.RandomDelay)
+	}
+
+	if wait := time.Until(h.last.Add(delay)); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	}
+	h.last = time.Now()
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package anna
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// timedTransport answers every request with an empty page and records when
+// it was sent.
+type timedTransport struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (t *timedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.times = append(t.times, time.Now())
+	t.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+func TestClientsShareThePacing(t *testing.T) {
+	// The delay is unlike that of the profiles, so that other tests do not
+	// share the pacing.
+	p := Politeness{Delay: 300 * time.Millisecond, Parallelism: 1}
+	transport := &timedTransport{}
+	newClient := func() *Client {
+		return New(WithHTTPClient(&http.Client{Transport: transport}), WithPoliteness(p))
+	}
+
+	for _, client := range []*Client{newClient(), newClient()} {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://annas-archive.test/search", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(transport.times) != 2 {
+		t.Fatalf("%d requests were sent, want 2", len(transport.times))
+	}
+	if gap := transport.times[1].Sub(transport.times[0]); gap < p.Delay {
+		t.Errorf("the requests of two clients with the same politeness were %s apart, want at least %s", gap, p.Delay)
+	}
+}
@@ -0,0 +1,52 @@
+package anna
+
+import "strings"
+
+// preferenceWeight scales the preference bonus against the fused rank of a
+// deep search, where the best result of a single query scores 1.
+const preferenceWeight = 0.5
+
+// Preferences lists the formats and language codes a user prefers, most
+// preferred first, for example epub before pdf for Kobo readers.
+type Preferences struct {
+	Formats   []string
+	Languages []string
+}
+
+// WithPreferences makes rankings favor the preferred formats and languages.
+func WithPreferences(p Preferences) Option {
+	return func(c *Client) {
+		c.prefs = p
+	}
+}
+
+// score rates how well a book matches the preferences, from 0 for no match
+// to 1 for the first choice of both format and language.
+func (p Preferences) score(b *Book) float64 {
+	return (preferenceRank(p.Formats, b.Format) + preferenceRank(p.Languages, b.LanguageCode)) / 2
+}
+
+// hints describes which preferences a book matches.
+func (p Preferences) hints(b *Book) []string {
+	hints := make([]string, 0)
+	if preferenceRank(p.Formats, b.Format) > 0 {
+		hints = append(hints, "preferred format")
+	}
+	if preferenceRank(p.Languages, b.LanguageCode) > 0 {
+		hints = append(hints, "preferred language")
+	}
+
+	return hints
+}
+
+// preferenceRank returns 1 for the first entry of the list, decreasing
+// linearly for later entries, and 0 if the value is not listed.
+func preferenceRank(preferred []string, value string) float64 {
+	for i, candidate := range preferred {
+		if strings.EqualFold(candidate, value) {
+			return float64(len(preferred)-i) / float64(len(preferred))
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,110 @@
+package anna
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// QueryIntent describes a book the way a user asks for it.
+type QueryIntent struct {
+	Title    string `json:"title,omitempty"`
+	Author   string `json:"author,omitempty"`
+	YearFrom int    `json:"year_from,omitempty"`
+	YearTo   int    `json:"year_to,omitempty"`
+	Format   string `json:"format,omitempty"`
+}
+
+// BuiltQuery is the search a QueryIntent translates to. Query is the term
+// sent to Anna's Archive and URL the search page it is sent to. The site does
+// not search by year or format, so these are listed in Filters and checked
+// against the results with Matches instead.
+type BuiltQuery struct {
+	Query   string   `json:"query"`
+	URL     string   `json:"url"`
+	Filters []string `json:"filters,omitempty"`
+
+	intent QueryIntent
+	years  ResultFilter
+}
+
+// BuildQuery translates an intent into the query sent to Anna's Archive.
+func BuildQuery(intent QueryIntent) (*BuiltQuery, error) {
+	query := strings.Join(strings.Fields(intent.Title+" "+intent.Author), " ")
+	if query == "" {
+		return nil, errors.New("a title or an author is required")
+	}
+	years := ResultFilter{YearFrom: intent.YearFrom, YearTo: intent.YearTo}
+	if err := years.Validate(); err != nil {
+		return nil, err
+	}
+	intent.Format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(intent.Format), "."))
+
+	filters := years.Describe()
+	if intent.Format != "" {
+		filters = append(filters, "format "+intent.Format)
+	}
+
+	return &BuiltQuery{
+		Query:   query,
+		URL:     fmt.Sprintf(AnnasSearchEndpoint, url.QueryEscape(query)),
+		Filters: filters,
+		intent:  intent,
+		years:   years,
+	}, nil
+}
+
+// Matches reports whether a search result meets the year and format of the
+// intent, and if not, why.
+func (q *BuiltQuery) Matches(b *Book) (bool, string) {
+	if q.intent.Format != "" && !strings.EqualFold(b.Format, q.intent.Format) {
+		return false, "format " + strings.ToLower(b.Format)
+	}
+
+	return q.years.Matches(b)
+}
+
+// FieldQuery is a search term limited to fields of the metadata, written in
+// the field-scoped syntax of Anna's Archive, such as title:"war and peace",
+// so that callers do not have to know it.
+type FieldQuery struct {
+	Term      string
+	Title     string
+	Author    string
+	Publisher string
+}
+
+// String returns the query sent to Anna's Archive, the free term followed by
+// the fields, or an empty string if all of them are empty.
+func (q FieldQuery) String() string {
+	parts := make([]string, 0, 4)
+	if term := strings.Join(strings.Fields(q.Term), " "); term != "" {
+		parts = append(parts, term)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"title", q.Title},
+		{"author", q.Author},
+		{"publisher", q.Publisher},
+	} {
+		if scoped := scopedField(field.name, field.value); scoped != "" {
+			parts = append(parts, scoped)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// scopedField limits a value to a field, quoting it if it has several words.
+// Quotes inside the value would end the phrase early, so they are dropped.
+func scopedField(name, value string) string {
+	value = strings.Join(strings.Fields(strings.ReplaceAll(value, `"`, " ")), " ")
+	if value == "" {
+		return ""
+	}
+	if strings.Contains(value, " ") {
+		value = `"` + value + `"`
+	}
+
+	return name + ":" + value
+}
@@ -0,0 +1,89 @@
+package anna
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// annasOrigin is the origin of the endpoints of Anna's Archive, which is
+// replaced by the origin of a mirror to query it instead.
+const annasOrigin = "https://annas-archive.org"
+
+// WithMirrors makes searches race the main site against the given mirrors,
+// such as "https://annas-archive.li", and use the first answer. This cuts the
+// latency when one of them is slow, at the cost of sending every search to all
+// of them.
+func WithMirrors(mirrors []string) Option {
+	return func(c *Client) {
+		c.mirrors = mirrors
+	}
+}
+
+// RacingSearcher scrapes the search page of several mirrors at once and
+// returns the results of the first one to answer with books, cancelling the
+// others. Parked domains and mirrors with another layout answer without
+// books, often the fastest, so a search only has no results once every
+// mirror answered without any.
+type RacingSearcher struct {
+	client  *Client
+	mirrors []string
+}
+
+type raceResult struct {
+	mirror string
+	books  []*Book
+	err    error
+}
+
+func (s *RacingSearcher) Search(ctx context.Context, query string) ([]*Book, error) {
+	l := logger.GetLogger()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	origins := append([]string{annasOrigin}, s.mirrors...)
+	results := make(chan raceResult, len(origins))
+	for _, origin := range origins {
+		go func(origin string) {
+			searchURL := fmt.Sprintf(strings.Replace(AnnasSearchEndpoint, annasOrigin, origin, 1), url.QueryEscape(query))
+			books, err := s.client.scrapeSearchAt(ctx, searchURL, nil)
+			results <- raceResult{mirror: origin, books: books, err: err}
+		}(strings.TrimSuffix(origin, "/"))
+	}
+
+	var (
+		firstErr error
+		empty    []*Book
+		answered bool
+	)
+	for range origins {
+		result := <-results
+		if result.err == nil && len(result.books) > 0 {
+			l.Info("Search answered by mirror", zap.String("mirror", result.mirror))
+			return result.books, nil
+		}
+		if result.err == nil {
+			l.Info("Mirror search found no books", zap.String("mirror", result.mirror))
+			empty, answered = result.books, true
+			continue
+		}
+
+		l.Warn("Mirror search failed",
+			zap.String("mirror", result.mirror),
+			zap.Error(result.err),
+		)
+		if firstErr == nil {
+			firstErr = result.err
+		}
+	}
+	if answered {
+		return empty, nil
+	}
+
+	return nil, firstErr
+}
@@ -0,0 +1,78 @@
+package anna
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/fixtures"
+)
+
+// mirrorTransport answers the searches of the main site at once with a page
+// without books, and those of mirror.test from the fixtures of the main site
+// after a delay. Mirrors in failing fail their searches.
+type mirrorTransport struct {
+	replayer http.RoundTripper
+	failing  map[string]bool
+}
+
+func (t mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failing[req.URL.Host] {
+		return nil, errors.New("connection refused")
+	}
+	if req.URL.Host != "mirror.test" {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": {"text/html"}},
+			Body:       io.NopCloser(bytes.NewReader([]byte("<html><body>Parked domain</body></html>"))),
+			Request:    req,
+		}, nil
+	}
+
+	select {
+	case <-time.After(50 * time.Millisecond):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	replayed := req.Clone(req.Context())
+	replayed.URL.Host = "annas-archive.org"
+	replayed.Host = ""
+
+	return t.replayer.RoundTrip(replayed)
+}
+
+func mirrorClient(failing ...string) *Client {
+	transport := mirrorTransport{replayer: fixtures.NewReplayer("testdata/fixtures"), failing: make(map[string]bool)}
+	for _, host := range failing {
+		transport.failing[host] = true
+	}
+
+	return New(WithHTTPClient(&http.Client{Transport: transport}), WithMirrors([]string{"https://mirror.test"}))
+}
+
+func TestRacingSearchWaitsForBooks(t *testing.T) {
+	books, err := mirrorClient().Search(context.Background(), "dune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 {
+		t.Errorf("Search returned %d books, want the 2 of the slower mirror", len(books))
+	}
+}
+
+func TestRacingSearchWithoutBooks(t *testing.T) {
+	books, err := mirrorClient("mirror.test").Search(context.Background(), "dune")
+	if err != nil || books == nil || len(books) != 0 {
+		t.Errorf("Search returned %d books and %v, want no books once a mirror answered without any", len(books), err)
+	}
+
+	_, err = mirrorClient("mirror.test", "annas-archive.org").Search(context.Background(), "dune")
+	if err == nil {
+		t.Error("Search succeeded with every mirror failing")
+	}
+}
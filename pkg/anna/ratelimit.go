@@ -0,0 +1,70 @@
+package anna
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRateBurst caps the size of a single read so that throttled downloads
+// progress smoothly instead of in large bursts.
+const maxRateBurst = 32 * 1024
+
+// rateLimitedReader throttles reads to a fixed number of bytes per second,
+// and stops waiting once the context of the download ends.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func newRateLimitedReader(ctx context.Context, r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+
+	burst := maxRateBurst
+	if bytesPerSecond < int64(burst) {
+		burst = int(bytesPerSecond)
+	}
+
+	return &rateLimitedReader{
+		ctx:     ctx,
+		r:       r,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst),
+	}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			if err := r.ctx.Err(); err != nil {
+				return n, err
+			}
+			// The limiter fails right away when the wait would go past the
+			// deadline of the context.
+			return n, fmt.Errorf("%w: %v", context.DeadlineExceeded, waitErr)
+		}
+	}
+
+	return n, err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,31 @@
+package anna
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedReaderStopsWithItsContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	expiring, cancelExpiring := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelExpiring()
+
+	for want, ctx := range map[error]context.Context{context.Canceled: canceled, context.DeadlineExceeded: expiring} {
+		// At a byte per second, reading the data would take ten seconds.
+		r := newRateLimitedReader(ctx, bytes.NewReader(make([]byte, 10)), 1)
+
+		start := time.Now()
+		_, err := io.ReadAll(r)
+		if !errors.Is(err, want) {
+			t.Errorf("the read returned %v, want %v", err, want)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("the read returned after %s, long after its context ended", elapsed)
+		}
+	}
+}
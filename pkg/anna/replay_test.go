@@ -0,0 +1,202 @@
+package anna
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/fixtures"
+)
+
+// Hashes of the books in testdata/fixtures.
+const (
+	duneHash    = "7c8b3f1a2e4d5c6b7a8f9e0d1c2b3a4f"
+	missingHash = "ffffffffffffffffffffffffffffffff"
+	quotaHash   = "1234567890abcdef1234567890abcdef"
+)
+
+// replayClient returns a client answering every request from the fixtures
+// of testdata/fixtures.
+func replayClient(opts ...Option) *Client {
+	opts = append([]Option{
+		WithHTTPClient(&http.Client{Transport: fixtures.NewReplayer("testdata/fixtures")}),
+		WithSecretKey("secret"),
+	}, opts...)
+
+	return New(opts...)
+}
+
+// memoryStorage keeps stored files in memory.
+type memoryStorage struct {
+	files map[string][]byte
+}
+
+func (s *memoryStorage) Store(name string, r io.Reader, size int64) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if s.files == nil {
+		s.files = make(map[string][]byte)
+	}
+	s.files[name] = data
+
+	return "memory://" + name, nil
+}
+
+func TestReplaySearch(t *testing.T) {
+	books, err := replayClient().Search(context.Background(), "dune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("Search returned %d books, want 2", len(books))
+	}
+
+	dune := books[0]
+	if dune.Hash != duneHash || dune.Title != "Dune" || dune.Authors != "Frank Herbert" {
+		t.Errorf("first result is %q by %q with hash %s, want Dune by Frank Herbert", dune.Title, dune.Authors, dune.Hash)
+	}
+	if dune.Format != "EPUB" || dune.LanguageCode != "en" || dune.Year != "1990" || dune.SizeBytes == 0 {
+		t.Errorf("first result has format %q, language %q, year %q, and %d bytes", dune.Format, dune.LanguageCode, dune.Year, dune.SizeBytes)
+	}
+	if dune.Language != "English" {
+		t.Errorf("first result has language %q, want English without its code", dune.Language)
+	}
+	if dune.CoverURL == "" {
+		t.Error("first result has no cover")
+	}
+	if books[1].Title != "Dune Messiah" || books[1].Format != "PDF" {
+		t.Errorf("second result is %q in %q, want Dune Messiah in PDF", books[1].Title, books[1].Format)
+	}
+}
+
+func TestReplayGetBook(t *testing.T) {
+	details, err := replayClient().GetBook(context.Background(), duneHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Book.Title != "Dune" || details.Book.Authors != "Frank Herbert" || details.Book.Publisher != "Ace, 1990" {
+		t.Errorf("GetBook returned %q by %q published by %q", details.Book.Title, details.Book.Authors, details.Book.Publisher)
+	}
+	if details.Book.Description == "" {
+		t.Error("GetBook returned no description")
+	}
+	if details.Book.Format != "EPUB" {
+		t.Errorf("GetBook returned format %q, want EPUB", details.Book.Format)
+	}
+}
+
+func TestReplayDownload(t *testing.T) {
+	store := &memoryStorage{}
+	result, err := replayClient().Download(context.Background(), &Book{Hash: duneHash, Title: "Dune", Format: "epub"}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "Dune.epub" {
+		t.Errorf("the download was stored as %q, want Dune.epub", result.Name)
+	}
+	if result.Quota == nil || result.Quota.DownloadsLeft != 24 || result.Quota.DownloadsPerDay != 25 {
+		t.Errorf("Download returned the quota %+v, want 24 of 25 downloads left", result.Quota)
+	}
+	if data := store.files["Dune.epub"]; !bytes.HasPrefix(data, []byte("PK")) || int64(len(data)) != result.Size {
+		t.Errorf("stored %d bytes that are not the EPUB of the fixture, the result says %d", len(data), result.Size)
+	}
+}
+
+// failingFiles fails the requests for files with 502 and replays the others.
+type failingFiles struct {
+	next http.RoundTripper
+}
+
+func (t failingFiles) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != "momot.rs" {
+		return t.next.RoundTrip(req)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Status:     "502 Bad Gateway",
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}, nil
+}
+
+func TestReplayDownloadSpendsQuota(t *testing.T) {
+	client := replayClient(WithHTTPClient(&http.Client{Transport: failingFiles{next: fixtures.NewReplayer("testdata/fixtures")}}))
+
+	_, err := client.Download(context.Background(), &Book{Hash: duneHash, Title: "Dune", Format: "epub"}, &memoryStorage{})
+	if !IsQuotaSpent(err) {
+		t.Errorf("Download of a file the server failed to send returned %v, want it to have spent the quota", err)
+	}
+	if !IsDeadLink(err) {
+		t.Errorf("Download of a file the server failed to send returned %v, want a dead link", err)
+	}
+}
+
+func TestReplayErrorPages(t *testing.T) {
+	client := replayClient()
+	ctx := context.Background()
+
+	var statusErr *StatusError
+	if _, err := client.GetBook(ctx, missingHash); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GetBook of a missing book returned %v, want a 404 status error", err)
+	}
+
+	if _, err := client.Search(ctx, "throttled"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Search of a throttled query returned %v, want a 429 status error", err)
+	}
+
+	if _, err := client.Download(ctx, &Book{Hash: quotaHash, Title: "Quota", Format: "epub"}, &memoryStorage{}); !errors.Is(err, ErrNoDownloadsLeft) || IsQuotaSpent(err) {
+		t.Errorf("Download without downloads left returned %v, want %v without spending the quota", err, ErrNoDownloadsLeft)
+	}
+
+	if _, err := client.Search(ctx, "not recorded"); !errors.Is(err, fixtures.ErrNotRecorded) {
+		t.Errorf("Search of an unrecorded query returned %v, want %v", err, fixtures.ErrNotRecorded)
+	}
+}
+
+func TestReplayCacheSkipsErrorPages(t *testing.T) {
+	dir := t.TempDir()
+	client := replayClient(WithCache(dir, time.Hour))
+	ctx := context.Background()
+
+	if _, err := client.Search(ctx, "dune"); err != nil {
+		t.Fatal(err)
+	}
+	if cached := cachedFiles(t, dir); cached != 1 {
+		t.Fatalf("%d pages are cached after a search, want 1", cached)
+	}
+
+	var statusErr *StatusError
+	if _, err := client.Search(ctx, "throttled"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Search of a throttled query returned %v, want a 429 status error", err)
+	}
+	if cached := cachedFiles(t, dir); cached != 1 {
+		t.Errorf("%d pages are cached after a throttled search, want the 429 page left out", cached)
+	}
+}
+
+// cachedFiles counts the files in the cache directory.
+func cachedFiles(t *testing.T, dir string) int {
+	t.Helper()
+
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
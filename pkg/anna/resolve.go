@@ -0,0 +1,88 @@
+package anna
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	colly "github.com/gocolly/colly/v2"
+)
+
+// AnnasZlibEndpoint is the page of Anna's Archive describing a Z-Library
+// record, which links to the files of the record by their MD5 hash.
+const AnnasZlibEndpoint = "https://annas-archive.org/zlib/%s"
+
+var (
+	md5Pattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	// zlibBookPattern matches the path of Z-Library book pages, such as
+	// "/book/1234567/abcdef/title.html".
+	zlibBookPattern = regexp.MustCompile(`^/book/(\d+)(/|$)`)
+)
+
+// ResolveHash returns the MD5 hash a download argument refers to. The
+// argument can be the hash itself, the URL of a detail page on Anna's
+// Archive or one of its mirrors, a Library Genesis URL with the hash in its
+// path or query, or the URL of a Z-Library book page, which is looked up on
+// Anna's Archive.
+func (c *Client) ResolveHash(ctx context.Context, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if md5Pattern.MatchString(input) {
+		return strings.ToLower(input), nil
+	}
+
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%q is neither an MD5 hash nor a URL", input)
+	}
+
+	for _, key := range []string{"md5", "hash"} {
+		if value := u.Query().Get(key); md5Pattern.MatchString(value) {
+			return strings.ToLower(value), nil
+		}
+	}
+	for _, element := range strings.Split(u.Path, "/") {
+		if md5Pattern.MatchString(element) {
+			return strings.ToLower(element), nil
+		}
+	}
+
+	if match := zlibBookPattern.FindStringSubmatch(u.Path); match != nil {
+		return c.zlibHash(ctx, match[1])
+	}
+
+	return "", fmt.Errorf("no MD5 hash found in %s", input)
+}
+
+// CheckHash returns an error unless hash is an MD5 hash, so that nothing else
+// ends up in the URLs built from it.
+func CheckHash(hash string) error {
+	if !md5Pattern.MatchString(hash) {
+		return fmt.Errorf("%q is not an MD5 hash", hash)
+	}
+
+	return nil
+}
+
+// zlibHash looks up the MD5 hash of the file of a Z-Library record.
+func (c *Client) zlibHash(ctx context.Context, id string) (string, error) {
+	var hash string
+
+	err := c.fetch(ctx, OperationResolve, fmt.Sprintf(AnnasZlibEndpoint, id), func(e *colly.HTMLElement) {
+		e.ForEachWithBreak(`a[href^="/md5/"]`, func(_ int, el *colly.HTMLElement) bool {
+			if candidate := strings.TrimPrefix(el.Attr("href"), "/md5/"); md5Pattern.MatchString(candidate) {
+				hash = strings.ToLower(candidate)
+			}
+			return hash == ""
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	if hash == "" {
+		return "", fmt.Errorf("%w: no file of Z-Library record %s is known", ErrBookNotFound, id)
+	}
+
+	return hash, nil
+}
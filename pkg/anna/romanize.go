@@ -0,0 +1,170 @@
+package anna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-unidecode"
+)
+
+// Transliteration schemes romanizing queries in other scripts than Latin.
+const (
+	// TransliterationDefault romanizes every script without diacritics, with
+	// pinyin for Chinese characters, as in "Dostoevskii" or "Xi You Ji".
+	TransliterationDefault = "default"
+	// TransliterationBGN romanizes Cyrillic following BGN/PCGN without its
+	// apostrophes, as in "Dostoyevskiy", which English catalogs use.
+	TransliterationBGN = "bgn"
+	// TransliterationScholarly romanizes Cyrillic with the diacritics of the
+	// scientific transliteration, as in "Dostoevskij", which European
+	// libraries use.
+	TransliterationScholarly = "scholarly"
+)
+
+// Transliterations lists the transliteration schemes.
+var Transliterations = []string{TransliterationDefault, TransliterationBGN, TransliterationScholarly}
+
+// ParseTransliterations parses a comma-separated list of transliteration
+// schemes, which defaults to TransliterationDefault if empty.
+func ParseTransliterations(value string) ([]string, error) {
+	schemes := make([]string, 0)
+	for _, scheme := range strings.Split(value, ",") {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if scheme == "" || slices.Contains(schemes, scheme) {
+			continue
+		}
+		if !slices.Contains(Transliterations, scheme) {
+			return nil, fmt.Errorf("unknown transliteration %q, expected %s", scheme, strings.Join(Transliterations, ", "))
+		}
+		schemes = append(schemes, scheme)
+	}
+	if len(schemes) == 0 {
+		schemes = append(schemes, TransliterationDefault)
+	}
+
+	return schemes, nil
+}
+
+// cyrillicSchemes map the lowercase Cyrillic letters to their romanization in
+// the schemes that differ from TransliterationDefault. Hard and soft signs are
+// dropped.
+var cyrillicSchemes = map[string]map[rune]string{
+	TransliterationBGN: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+		'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+		'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+		'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+		'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+		'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "w",
+	},
+	TransliterationScholarly: {
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë",
+		'ж': "ž", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+		'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+		'ф': "f", 'х': "x", 'ц': "c", 'ч': "č", 'ш': "š", 'щ': "šč",
+		'ъ': "", 'ы': "y", 'ь': "", 'э': "è", 'ю': "ju", 'я': "ja",
+		'і': "i", 'ї': "ji", 'є': "je", 'ґ': "g", 'ў': "ŭ",
+	},
+}
+
+// Transliterate romanizes s with the given scheme. Latin letters are kept,
+// and the letters of scripts the scheme does not cover are romanized as
+// TransliterationDefault would.
+func Transliterate(s, scheme string) string {
+	letters, ok := cyrillicSchemes[scheme]
+	if !ok {
+		return strings.Join(strings.Fields(unidecode.Unidecode(s)), " ")
+	}
+
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		roman, ok := letters[unicode.ToLower(r)]
+		if !ok {
+			sb.WriteString(unidecode.Unidecode(string(r)))
+			continue
+		}
+		// BGN/PCGN writes e as ye after vowels and signs and at the start of
+		// words, as in "Dostoyevskiy".
+		if scheme == TransliterationBGN && unicode.ToLower(r) == 'е' && (i == 0 || !unicode.IsLetter(runes[i-1]) || strings.ContainsRune("аеёиоуыэюяіїєъь", unicode.ToLower(runes[i-1]))) {
+			roman = "ye"
+		}
+		if unicode.IsUpper(r) && roman != "" {
+			// Whole words in capitals stay in capitals, others only get
+			// their first letter capitalized.
+			if i+1 < len(runes) && unicode.IsUpper(runes[i+1]) {
+				roman = strings.ToUpper(roman)
+			} else {
+				first := []rune(roman)
+				first[0] = unicode.ToUpper(first[0])
+				roman = string(first)
+			}
+		}
+		sb.WriteString(roman)
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// hasOtherScript tells if s has letters of another script than Latin.
+func hasOtherScript(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScriptVariants returns the queries a search across scripts runs: q, its
+// spelling in the original script if given, and the romanizations of those
+// in other scripts than Latin with each of the schemes. Uploads are often
+// indexed in a single script, so that searching for either spelling alone
+// misses some of them. Duplicate and empty queries are left out.
+func ScriptVariants(q, original FieldQuery, schemes []string) []FieldQuery {
+	variants := make([]FieldQuery, 0)
+	seen := make(map[string]bool)
+	add := func(variant FieldQuery) {
+		term := variant.String()
+		if term == "" || seen[term] {
+			return
+		}
+		seen[term] = true
+		variants = append(variants, variant)
+	}
+
+	bases := []FieldQuery{q, original}
+	for _, base := range bases {
+		add(base)
+	}
+	for _, base := range bases {
+		if !hasOtherScript(base.Term + base.Title + base.Author) {
+			continue
+		}
+		for _, scheme := range schemes {
+			add(base.rewrite(func(s string) string { return Transliterate(s, scheme) }, true))
+		}
+	}
+
+	return variants
+}
+
+// ScriptSearch runs the variants of a query across scripts, as returned by
+// ScriptVariants, concurrently and merges their results like DeepSearch.
+func (c *Client) ScriptSearch(ctx context.Context, variants []FieldQuery) ([]*RankedBook, int, error) {
+	if len(variants) == 0 {
+		return nil, 0, errors.New("a term, title, author, or publisher is required")
+	}
+
+	queries := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		queries = append(queries, variant.String())
+	}
+
+	return c.searchVariants(ctx, queries)
+}
@@ -0,0 +1,27 @@
+package anna
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+// BookSchemaVersion is the version of the JSON representation of Book, sent
+// along in its schema_version field. It is raised when a field is removed,
+// renamed, or changes meaning, but not when optional fields are added, so
+// that consumers can keep reading the objects of the versions they know.
+const BookSchemaVersion = 1
+
+// BookSchema is the JSON schema of the JSON representation of Book, with the
+// meaning of each field.
+//
+//go:embed book.schema.json
+var BookSchema string
+
+// MarshalJSON encodes the book with the version of its schema.
+func (b Book) MarshalJSON() ([]byte, error) {
+	type book Book
+	return json.Marshal(struct {
+		SchemaVersion int `json:"schema_version"`
+		book
+	}{BookSchemaVersion, book(b)})
+}
@@ -0,0 +1,292 @@
+package anna
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+const AnnasSearchAPIEndpoint = "https://annas-archive.org/dyn/api/search.json?q=%s&key=%s"
+
+// Searcher finds books matching a query.
+type Searcher interface {
+	Search(ctx context.Context, query string) ([]*Book, error)
+}
+
+// StreamSearcher is a Searcher that can report results while the search is
+// still running. fn is called sequentially, once per result, before
+// SearchStream returns all of them.
+type StreamSearcher interface {
+	Searcher
+	SearchStream(ctx context.Context, query string, fn func(*Book)) ([]*Book, error)
+}
+
+// searchStream streams the results of searchers that support it, and reports
+// the results of the others once they are all known.
+func searchStream(ctx context.Context, s Searcher, query string, fn func(*Book)) ([]*Book, error) {
+	if streamer, ok := s.(StreamSearcher); ok {
+		return streamer.SearchStream(ctx, query, fn)
+	}
+
+	books, err := s.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range books {
+		fn(book)
+	}
+
+	return books, nil
+}
+
+// ScrapeSearcher searches by scraping the HTML search page.
+type ScrapeSearcher struct {
+	client *Client
+}
+
+func (s *ScrapeSearcher) Search(ctx context.Context, query string) ([]*Book, error) {
+	return s.client.scrapeSearch(ctx, query, nil)
+}
+
+// SearchStream reports every result as soon as it is parsed from the page.
+func (s *ScrapeSearcher) SearchStream(ctx context.Context, query string, fn func(*Book)) ([]*Book, error) {
+	return s.client.scrapeSearch(ctx, query, fn)
+}
+
+// APISearcher searches through the JSON search endpoint available to members
+// whose key grants search access. It is faster than scraping and does not
+// break on site redesigns.
+type APISearcher struct {
+	client *Client
+}
+
+// The API returns records in the same shape as the /db/aarecord/ JSON export.
+type searchAPIResponse struct {
+	Error     string `json:"error"`
+	AARecords []struct {
+		ID              string `json:"id"`
+		FileUnifiedData struct {
+			TitleBest     string   `json:"title_best"`
+			AuthorBest    string   `json:"author_best"`
+			PublisherBest string   `json:"publisher_best"`
+			ExtensionBest string   `json:"extension_best"`
+			FilesizeBest  int64    `json:"filesize_best"`
+			YearBest      string   `json:"year_best"`
+			LanguageCodes []string `json:"language_codes"`
+			CoverURLBest  string   `json:"cover_url_best"`
+		} `json:"file_unified_data"`
+	} `json:"aarecords"`
+}
+
+func (s *APISearcher) Search(ctx context.Context, query string) ([]*Book, error) {
+	// The API is expected to answer quickly, a hanging request should fall
+	// back to scraping rather than block the search.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := s.client.get(ctx, fmt.Sprintf(AnnasSearchAPIEndpoint, url.QueryEscape(query), url.QueryEscape(s.client.secretKey)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return nil, ErrSearchAPIUnavailable
+	default:
+		return nil, &StatusError{Endpoint: "search API", StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var apiResp searchAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	if apiResp.Error != "" {
+		return nil, &APIError{Message: apiResp.Error}
+	}
+
+	books := make([]*Book, 0, len(apiResp.AARecords))
+	for _, record := range apiResp.AARecords {
+		hash, ok := strings.CutPrefix(record.ID, "md5:")
+		if !ok {
+			continue
+		}
+
+		data := record.FileUnifiedData
+		languageCode := ""
+		if len(data.LanguageCodes) > 0 {
+			languageCode = data.LanguageCodes[0]
+		}
+
+		books = append(books, &Book{
+			Language:     languageName(languageCode),
+			LanguageCode: languageCode,
+			Format:       strings.ToUpper(data.ExtensionBest),
+			Size:         formatSize(data.FilesizeBest),
+			SizeBytes:    max(data.FilesizeBest, 0),
+			Year:         data.YearBest,
+			Title:        data.TitleBest,
+			Publisher:    data.PublisherBest,
+			Authors:      data.AuthorBest,
+			AuthorList:   SplitAuthors(data.AuthorBest, false),
+			URL:          fmt.Sprintf(AnnasBookEndpoint, hash),
+			Hash:         hash,
+			CoverURL:     data.CoverURLBest,
+		})
+	}
+
+	return books, nil
+}
+
+// FallbackSearcher uses its primary searcher, falling back to the secondary
+// one if the primary fails.
+type FallbackSearcher struct {
+	Primary  Searcher
+	Fallback Searcher
+}
+
+func (s *FallbackSearcher) Search(ctx context.Context, query string) ([]*Book, error) {
+	l := logger.GetLogger()
+
+	books, err := s.Primary.Search(ctx, query)
+	if err == nil {
+		return books, nil
+	}
+
+	l.Warn("Primary search backend failed, falling back",
+		zap.String("query", query),
+		zap.Error(err),
+	)
+
+	return s.Fallback.Search(ctx, query)
+}
+
+// SearchStream streams the results of the fallback searcher if the primary
+// one fails. Results of the primary searcher are only reported once it
+// succeeded, so no partial results are reported twice.
+func (s *FallbackSearcher) SearchStream(ctx context.Context, query string, fn func(*Book)) ([]*Book, error) {
+	l := logger.GetLogger()
+
+	books, err := s.Primary.Search(ctx, query)
+	if err == nil {
+		for _, book := range books {
+			fn(book)
+		}
+		return books, nil
+	}
+
+	l.Warn("Primary search backend failed, falling back",
+		zap.String("query", query),
+		zap.Error(err),
+	)
+
+	return searchStream(ctx, s.Fallback, query, fn)
+}
+
+// languageName returns the English name of a language code, for example
+// "English" for "en".
+func languageName(code string) string {
+	if code == "" {
+		return ""
+	}
+
+	tag, err := language.Parse(code)
+	if err != nil {
+		return code
+	}
+
+	return display.English.Languages().Name(tag)
+}
+
+// formatSize renders a byte count the way the search page does, for example
+// "0.7MB".
+func formatSize(bytes int64) string {
+	switch {
+	case bytes <= 0:
+		return ""
+	case bytes < 1<<20:
+		return fmt.Sprintf("%.1fKB", float64(bytes)/(1<<10))
+	case bytes < 1<<30:
+		return fmt.Sprintf("%.1fMB", float64(bytes)/(1<<20))
+	default:
+		return fmt.Sprintf("%.1fGB", float64(bytes)/(1<<30))
+	}
+}
+
+// parseSize parses sizes as displayed by Anna's Archive, such as "1.2MB",
+// returning zero for anything else. Localized pages may use a decimal comma,
+// as in "1,2 MB", or group thousands, as in "1.234,5 KB".
+func parseSize(s string) int64 {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	// Binary units, such as "MiB", mean the same on Anna's Archive.
+	if number, ok := strings.CutSuffix(s, "IB"); ok {
+		s = number + "B"
+	}
+
+	for _, unit := range []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	} {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			value, err := strconv.ParseFloat(normalizeDecimal(strings.TrimSpace(number)), 64)
+			if err != nil || value < 0 {
+				return 0
+			}
+			return int64(value * unit.multiplier)
+		}
+	}
+
+	return 0
+}
+
+// normalizeDecimal rewrites a number with a decimal comma or thousands
+// separators into the form accepted by strconv.ParseFloat. When both a comma
+// and a dot appear, the last one is the decimal separator. A lone comma
+// followed by three digits groups thousands, any other one is a decimal
+// comma.
+func normalizeDecimal(number string) string {
+	number = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "").Replace(number)
+
+	comma, dot := strings.LastIndex(number, ","), strings.LastIndex(number, ".")
+	switch {
+	case comma < 0:
+		return number
+	case dot > comma:
+		return strings.ReplaceAll(number, ",", "")
+	case dot >= 0:
+		return strings.Replace(strings.ReplaceAll(number, ".", ""), ",", ".", 1)
+	case strings.Count(number, ",") > 1 || len(number)-comma-1 == 3:
+		return strings.ReplaceAll(number, ",", "")
+	default:
+		return strings.Replace(number, ",", ".", 1)
+	}
+}
+
+// sizeMismatch describes how far the actual size of a file is off the
+// expected one, or returns an empty string if it is within the rounding of
+// the displayed size.
+func sizeMismatch(actual, expected int64) string {
+	tolerance := max(expected/20, 64<<10)
+	if diff := actual - expected; diff >= -tolerance && diff <= tolerance {
+		return ""
+	}
+
+	return fmt.Sprintf("expected about %s, got %s", formatSize(expected), formatSize(actual))
+}
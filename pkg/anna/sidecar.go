@@ -0,0 +1,161 @@
+package anna
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Sidecar formats written next to downloaded files.
+const (
+	SidecarOPF  = "opf"
+	SidecarJSON = "json"
+)
+
+type opfPackage struct {
+	XMLName          xml.Name    `xml:"package"`
+	Xmlns            string      `xml:"xmlns,attr"`
+	Version          string      `xml:"version,attr"`
+	UniqueIdentifier string      `xml:"unique-identifier,attr"`
+	Metadata         opfMetadata `xml:"metadata"`
+}
+
+type opfMetadata struct {
+	XmlnsDC    string          `xml:"xmlns:dc,attr"`
+	XmlnsOPF   string          `xml:"xmlns:opf,attr"`
+	Identifier []opfIdentifier `xml:"dc:identifier"`
+	Title      string          `xml:"dc:title"`
+	Creators   []opfCreator    `xml:"dc:creator,omitempty"`
+	Publisher  string          `xml:"dc:publisher,omitempty"`
+	Date       string          `xml:"dc:date,omitempty"`
+	Language   string          `xml:"dc:language,omitempty"`
+	Format     string          `xml:"dc:format,omitempty"`
+	Desc       string          `xml:"dc:description,omitempty"`
+	Source     string          `xml:"dc:source,omitempty"`
+}
+
+type opfIdentifier struct {
+	ID     string `xml:"id,attr,omitempty"`
+	Scheme string `xml:"opf:scheme,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type opfCreator struct {
+	Role   string `xml:"opf:role,attr"`
+	FileAs string `xml:"opf:file-as,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+// ToOPF renders the metadata of the book as a Calibre-compatible OPF
+// document.
+func (b *Book) ToOPF() (string, error) {
+	metadata := opfMetadata{
+		XmlnsDC:  "http://purl.org/dc/elements/1.1/",
+		XmlnsOPF: "http://www.idpf.org/2007/opf",
+		Identifier: []opfIdentifier{
+			{ID: "md5", Scheme: "MD5", Value: b.Hash},
+		},
+		Title:     b.Title,
+		Publisher: b.Publisher,
+		Date:      b.Year,
+		Language:  b.LanguageCode,
+		Format:    b.Format,
+		Desc:      b.Description,
+		Source:    b.URL,
+	}
+	if metadata.Language == "" {
+		metadata.Language = b.Language
+	}
+	// Calibre expects one creator per author, named "First Last" and sorted
+	// by the "Last, First" form.
+	for _, name := range SplitAuthors(b.Authors, false) {
+		creator := opfCreator{Role: "aut", Value: DisplayName(name)}
+		if creator.Value != name {
+			creator.FileAs = name
+		}
+		metadata.Creators = append(metadata.Creators, creator)
+	}
+
+	pkg := opfPackage{
+		Xmlns:            "http://www.idpf.org/2007/opf",
+		Version:          "2.0",
+		UniqueIdentifier: "md5",
+		Metadata:         metadata,
+	}
+
+	data, err := xml.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return xml.Header + string(data) + "\n", nil
+}
+
+// withDetails returns a copy of the book enriched from its detail page, since
+// downloads usually only know the hash, title, and format. The title and
+// format the caller asked for are kept, as the file is saved under them.
+func (c *Client) withDetails(ctx context.Context, b *Book) *Book {
+	l := logger.GetLogger()
+
+	details, err := c.GetBook(ctx, b.Hash)
+	if err != nil {
+		l.Warn("Failed to fetch book details, using partial metadata",
+			zap.String("bookHash", b.Hash),
+			zap.Error(err),
+		)
+		return b
+	}
+
+	book := details.Book
+	if b.Title != "" {
+		book.Title = b.Title
+	}
+	if b.Format != "" {
+		book.Format = b.Format
+	}
+
+	return book
+}
+
+// writeSidecars stores metadata files next to the downloaded file. Failures
+// are logged but do not fail the download.
+func writeSidecars(store Storage, filename string, book *Book, formats []string) {
+	l := logger.GetLogger()
+
+	base := filename
+	if book.Format != "" {
+		base = strings.TrimSuffix(filename, path.Ext(filename))
+	}
+	for _, format := range formats {
+		var (
+			content string
+			err     error
+		)
+		switch format {
+		case SidecarOPF:
+			content, err = book.ToOPF()
+		case SidecarJSON:
+			content, err = book.ToJSON()
+		default:
+			err = fmt.Errorf("unknown sidecar format: %s", format)
+		}
+		if err != nil {
+			l.Warn("Failed to render sidecar", zap.String("format", format), zap.Error(err))
+			continue
+		}
+
+		location, err := store.Store(base+"."+format, bytes.NewReader([]byte(content)), int64(len(content)))
+		if err != nil {
+			l.Warn("Failed to store sidecar", zap.String("format", format), zap.Error(err))
+			continue
+		}
+
+		l.Info("Sidecar written", zap.String("format", format), zap.String("location", location))
+	}
+}
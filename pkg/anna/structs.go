@@ -0,0 +1,130 @@
+package anna
+
+type Book struct {
+	Language     string `json:"language"`
+	LanguageCode string `json:"language_code"`
+	Format       string `json:"format"`
+	Size         string `json:"size"`
+	SizeBytes    int64  `json:"size_bytes,omitempty"`
+	Year         string `json:"year"`
+	Title        string `json:"title"`
+	Publisher    string `json:"publisher"`
+	Authors      string `json:"authors"`
+	// AuthorList holds the names of Authors, split with SplitAuthors.
+	AuthorList []string `json:"author_list,omitempty"`
+	URL        string   `json:"url"`
+	Hash       string   `json:"hash"`
+	CoverURL   string   `json:"cover_url,omitempty"`
+	// Sources lists the upstream collections holding the file, such as
+	// "zlib", "lgli", "lgrs", or "ia".
+	Sources []string `json:"sources,omitempty"`
+
+	// Only populated from the detail page of a book.
+	Description string   `json:"description,omitempty"`
+	TOC         []string `json:"toc,omitempty"`
+}
+
+// BookComparison holds the attributes relevant for choosing between several
+// copies of the same work.
+type BookComparison struct {
+	Hash         string   `json:"hash"`
+	Title        string   `json:"title"`
+	Authors      string   `json:"authors"`
+	Language     string   `json:"language"`
+	Format       string   `json:"format"`
+	Size         string   `json:"size"`
+	SizeBytes    int64    `json:"size_bytes,omitempty"`
+	Year         string   `json:"year"`
+	Sources      []string `json:"sources"`
+	QualityHints []string `json:"quality_hints"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// DownloadOption is a download link listed on the detail page of a book.
+type DownloadOption struct {
+	Label       string `json:"label"`
+	URL         string `json:"url"`
+	Kind        string `json:"kind"`
+	Note        string `json:"note,omitempty"`
+	TypicalWait string `json:"typical_wait"`
+}
+
+// DownloadConfig tunes how Client.Download fetches files.
+type DownloadConfig struct {
+	// MaxRate caps the download speed in bytes per second, zero disables it.
+	MaxRate int64
+	// FilenameEncoding is either FilenameUnicode (the default) or
+	// FilenameASCII.
+	FilenameEncoding string
+	// Sidecars lists the metadata files, SidecarOPF or SidecarJSON, to write
+	// next to the downloaded file.
+	Sidecars []string
+	// EmbedMetadata fills missing or junk title, author, and language
+	// metadata inside downloaded EPUBs.
+	EmbedMetadata bool
+	// UnwrapArchives replaces zip and rar archives by the file most likely
+	// to be the book when the requested format is not an archive itself.
+	// Archives holding other files too are kept next to the book.
+	UnwrapArchives bool
+	// Organization is the template of the path files are stored under, see
+	// OrganizationTemplate. Files are stored flat in the download directory
+	// if it is empty.
+	Organization string
+}
+
+// FastDownloadInfo is the fast download quota of the account, as reported by
+// the fast download API.
+type FastDownloadInfo struct {
+	DownloadsLeft   int `json:"downloads_left"`
+	DownloadsPerDay int `json:"downloads_per_day"`
+}
+
+// DownloadResult describes a finished download.
+type DownloadResult struct {
+	Location string `json:"location"`
+	// Name is the slash-separated path the file was stored under, relative
+	// to the root of the storage.
+	Name string `json:"name,omitempty"`
+	// Size is the number of bytes stored.
+	Size int64 `json:"size"`
+	// Format is the format of the stored file, which differs from the
+	// requested one when the download was a zip archive.
+	Format string `json:"format"`
+	// Transform describes how the downloaded file was changed before it was
+	// stored, empty if it was stored as is.
+	Transform string `json:"transform,omitempty"`
+	// ArchiveMember is the path of the file inside the archive it was
+	// unpacked from.
+	ArchiveMember string `json:"archive_member,omitempty"`
+	// Archive is the location of the original archive, stored next to the
+	// file when it held other files too.
+	Archive string `json:"archive,omitempty"`
+	// SizeMismatch is set when the stored file does not match the size
+	// advertised on the detail page, which usually means it is truncated.
+	SizeMismatch string `json:"size_mismatch,omitempty"`
+	// Pages is the page count of a downloaded PDF, and TextLayer tells
+	// whether its text is born-digital (text), recognized from scans (ocr),
+	// or missing (none).
+	Pages     int    `json:"pages,omitempty"`
+	TextLayer string `json:"text_layer,omitempty"`
+	// QualityWarnings flag signs of a bad copy, such as a suspiciously small
+	// file or a scan without text.
+	QualityWarnings []string `json:"quality_warnings,omitempty"`
+	// FetchedHash is set when the requested copy could not be reached and
+	// another copy of the same book, with this hash, was downloaded instead.
+	FetchedHash string `json:"fetched_hash,omitempty"`
+	// Copies lists the other locations the file was delivered to.
+	Copies []string `json:"copies,omitempty"`
+	// Supplements holds the supplementary files downloaded next to the
+	// book.
+	Supplements []*DownloadResult `json:"supplements,omitempty"`
+	// Quota is nil when the download did not go through the fast download
+	// API.
+	Quota *FastDownloadInfo `json:"quota,omitempty"`
+}
+
+type fastDownloadResponse struct {
+	DownloadURL string            `json:"download_url"`
+	Error       string            `json:"error"`
+	AccountInfo *FastDownloadInfo `json:"account_fast_download_info"`
+}
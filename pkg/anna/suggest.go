@@ -0,0 +1,209 @@
+package anna
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-unidecode"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Kinds of suggestions for searches without results.
+const (
+	SuggestTypo            = "typo"
+	SuggestTransliteration = "transliteration"
+	SuggestDropSubtitle    = "drop_subtitle"
+	SuggestDropEdition     = "drop_edition"
+	SuggestDropAuthor      = "drop_author"
+	SuggestBroaden         = "broaden"
+	SuggestRelaxFilters    = "relax_filters"
+)
+
+// Suggestion is a way to recover from a search without results.
+type Suggestion struct {
+	Kind string `json:"kind"`
+	// Query is the search term to try instead, empty for advice that does
+	// not change the term.
+	Query  string `json:"query,omitempty"`
+	Advice string `json:"advice"`
+}
+
+var (
+	// subtitleSeparator splits a title from its subtitle, as in "Dune: The
+	// Graphic Novel" or "Dune - Book One".
+	subtitleSeparator = regexp.MustCompile(`\s*(:|\s[-–—]\s)`)
+	// editionPhrase matches edition statements, which uploads rarely have in
+	// their titles, such as "2nd edition" or "(Revised Ed.)".
+	editionPhrase = regexp.MustCompile(`(?i)[(\[]?\b(\d+(st|nd|rd|th)|first|second|third|fourth|fifth|revised|updated|expanded|new|international|anniversary|illustrated|annotated|special|deluxe)\s+(ed\.?|edition)(?:\s|$|[)\],.;])[)\]]?`)
+	// leetWord matches words with 0, 1, or 3 between their letters, which
+	// are often mistyped for o, l, and e.
+	leetWord = regexp.MustCompile(`\b\pL+[013]+\pL+\b`)
+)
+
+// romanizations rewrite spellings that transliterations of the same name,
+// most often from Russian, disagree on.
+var romanizations = [][2]string{
+	{"sky", "skii"},
+	{"skii", "sky"},
+	{"oevsky", "oyevsky"},
+	{"oyevsky", "oevsky"},
+	{"kh", "h"},
+	{"ya", "ia"},
+	{"yu", "iu"},
+	{"tch", "ch"},
+}
+
+// Suggest returns ways to recover from a search for q that found nothing:
+// possible typos, other transliterations of the term and author, and broader
+// versions of it without subtitle, edition, or author. The suggestions are
+// worked out locally, without searching again, and do not repeat the
+// original query.
+func Suggest(q FieldQuery) []Suggestion {
+	original := q.String()
+	suggestions := make([]Suggestion, 0)
+	seen := map[string]bool{original: true}
+	add := func(kind, advice string, query FieldQuery) {
+		term := query.String()
+		if term == "" || seen[term] {
+			return
+		}
+		seen[term] = true
+		suggestions = append(suggestions, Suggestion{Kind: kind, Query: term, Advice: advice})
+	}
+
+	if fixed := q.rewrite(fixTypos, true); fixed != q {
+		add(SuggestTypo, "Some words look mistyped, check their spelling.", fixed)
+	}
+
+	// Accented Latin letters are only folded, other scripts are romanized.
+	folded := q.rewrite(foldDiacritics, true)
+	if ascii := q.rewrite(unidecode.Unidecode, true); ascii != folded {
+		add(SuggestTransliteration, "Many uploads are indexed under a romanized title and author, try the Latin spelling.", ascii)
+	} else if folded != q {
+		add(SuggestTransliteration, "Try the spelling without accents, which some uploads use.", folded)
+	}
+	for _, alternate := range q.romanized() {
+		add(SuggestTransliteration, "Names transliterated from other scripts are spelled in several ways, try another spelling.", alternate)
+	}
+
+	if shorter := q.rewrite(dropSubtitle, false); shorter != q {
+		add(SuggestDropSubtitle, "Drop the subtitle, which uploads often leave out or word differently.", shorter)
+	}
+	if shorter := q.rewrite(dropEdition, false); shorter != q {
+		add(SuggestDropEdition, "Drop the edition, which uploads rarely mention in their titles.", shorter)
+	}
+
+	if q.Author != "" && (q.Title != "" || q.Term != "") {
+		add(SuggestDropAuthor, "Search without the author, whose name may be spelled differently in the metadata.", FieldQuery{Term: q.Term, Title: q.Title, Publisher: q.Publisher})
+	}
+	if q.Title != "" || q.Author != "" || q.Publisher != "" || strings.Contains(q.Term, `"`) {
+		words := []string{strings.ReplaceAll(q.Term, `"`, " "), q.Title, q.Author, q.Publisher}
+		add(SuggestBroaden, "Search the words anywhere in the metadata, without fields or quotes.", FieldQuery{Term: strings.Join(words, " ")})
+	}
+
+	return suggestions
+}
+
+// rewrite returns a copy of the query with its title and free term
+// rewritten by fn, and its author too if names is set.
+func (q FieldQuery) rewrite(fn func(string) string, names bool) FieldQuery {
+	q.Term = strings.TrimSpace(fn(q.Term))
+	q.Title = strings.TrimSpace(fn(q.Title))
+	if names {
+		q.Author = strings.TrimSpace(fn(q.Author))
+	}
+
+	return q
+}
+
+// romanized returns the query with the author spelled as other
+// transliterations would, at most two of them.
+func (q FieldQuery) romanized() []FieldQuery {
+	alternates := make([]FieldQuery, 0)
+	if q.Author == "" {
+		return alternates
+	}
+	for _, rewrite := range romanizations {
+		alternate := q
+		alternate.Author = replaceInWords(q.Author, rewrite[0], rewrite[1])
+		if alternate != q {
+			alternates = append(alternates, alternate)
+		}
+		if len(alternates) == 2 {
+			break
+		}
+	}
+
+	return alternates
+}
+
+// replaceInWords replaces old with new inside the words of s, keeping the
+// case of their first letter.
+func replaceInWords(s, old, new string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		at := strings.Index(lower, old)
+		if at < 0 {
+			continue
+		}
+		replaced := []rune(lower[:at] + new + lower[at+len(old):])
+		if first, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(first) {
+			replaced[0] = unicode.ToUpper(replaced[0])
+		}
+		words[i] = string(replaced)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// fixTypos collapses letters typed three times or more to two, and replaces
+// digits mistyped for letters inside words.
+func fixTypos(s string) string {
+	var sb strings.Builder
+	var last rune
+	repeats := 0
+	for _, r := range s {
+		if r == last && unicode.IsLetter(r) {
+			repeats++
+		} else {
+			last, repeats = r, 1
+		}
+		if repeats <= 2 {
+			sb.WriteRune(r)
+		}
+	}
+
+	return leetWord.ReplaceAllStringFunc(sb.String(), func(word string) string {
+		return strings.NewReplacer("0", "o", "1", "l", "3", "e").Replace(word)
+	})
+}
+
+// foldDiacritics drops the accents of letters, as in "Gabriel García
+// Márquez".
+func foldDiacritics(s string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if r < 0x0300 || r > 0x036f {
+			sb.WriteRune(r)
+		}
+	}
+
+	return norm.NFC.String(sb.String())
+}
+
+// dropSubtitle keeps the part of a title before its subtitle.
+func dropSubtitle(s string) string {
+	if loc := subtitleSeparator.FindStringIndex(s); loc != nil && loc[0] > 0 {
+		return s[:loc[0]]
+	}
+
+	return s
+}
+
+// dropEdition removes the edition statements of a title.
+func dropEdition(s string) string {
+	return strings.Join(strings.Fields(editionPhrase.ReplaceAllString(s, " ")), " ")
+}
@@ -0,0 +1,117 @@
+package anna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	colly "github.com/gocolly/colly/v2"
+	"github.com/iosifache/annas-mcp/internal/logger"
+	"go.uber.org/zap"
+)
+
+// supplementPattern matches the labels of links to files that accompany a
+// book, such as solution manuals or the content of a companion CD.
+var supplementPattern = regexp.MustCompile(`(?i)\b(supplement(s|ary)?|companion|extras?|solutions?|answer keys?|exercise files|accompanying|cd-?rom|dvd)\b`)
+
+// SupplementaryFile is a file linked from the detail page of a book as
+// accompanying it, and available on Anna's Archive under its own hash.
+type SupplementaryFile struct {
+	Label string `json:"label"`
+	Hash  string `json:"hash"`
+	URL   string `json:"url"`
+}
+
+// extractSupplements returns the links of the detail page to the pages of
+// other files whose label or list item names them as supplementary.
+func extractSupplements(e *colly.HTMLElement, hash string) []*SupplementaryFile {
+	supplements := make([]*SupplementaryFile, 0)
+	seen := map[string]bool{hash: true}
+
+	e.ForEach("a[href^='/md5/']", func(_ int, el *colly.HTMLElement) {
+		linked := strings.ToLower(strings.Trim(strings.TrimPrefix(el.Attr("href"), "/md5/"), "/"))
+		if !md5Pattern.MatchString(linked) || seen[linked] {
+			return
+		}
+
+		label := cleanText(el.Text)
+		surrounding := label
+		if item := el.DOM.Closest("li"); item.Length() > 0 {
+			surrounding = cleanText(item.Text())
+		}
+		if !supplementPattern.MatchString(surrounding) {
+			return
+		}
+		seen[linked] = true
+
+		supplements = append(supplements, &SupplementaryFile{
+			Label: label,
+			Hash:  linked,
+			URL:   e.Request.AbsoluteURL(el.Attr("href")),
+		})
+	})
+
+	return supplements
+}
+
+// SupplementsFolder returns the folder the supplementary files of a book
+// stored under name are downloaded to, next to the book.
+func SupplementsFolder(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+
+	return path.Join(path.Dir(name), base+" (supplements)")
+}
+
+// DownloadSupplements downloads the given supplementary files of a book into
+// folder, under their own titles. The organization template, sidecars, and
+// embedded metadata only apply to books, so they are left out. It returns the
+// results of the files that were downloaded, and the errors of the others.
+func (c *Client) DownloadSupplements(ctx context.Context, supplements []*SupplementaryFile, folder string, store Storage) ([]*DownloadResult, error) {
+	l := logger.GetLogger()
+
+	client := *c
+	client.downloadCfg.Organization = ""
+	client.downloadCfg.Sidecars = nil
+	client.downloadCfg.EmbedMetadata = false
+	prefixed := &prefixedStorage{store: store, prefix: folder}
+
+	results := make([]*DownloadResult, 0, len(supplements))
+	var errs []error
+	for _, supplement := range supplements {
+		book := &Book{Hash: supplement.Hash, Title: supplement.Label}
+		if details, err := c.GetBook(ctx, supplement.Hash); err == nil {
+			book = details.Book
+		} else {
+			l.Warn("Failed to fetch supplementary file details",
+				zap.String("bookHash", supplement.Hash),
+				zap.Error(err),
+			)
+		}
+
+		result, err := client.Download(ctx, book, prefixed)
+		if errors.Is(err, ErrNoDownloadsLeft) {
+			return results, err
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("supplementary file %s: %w", supplement.Hash, err))
+			continue
+		}
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// prefixedStorage stores files in a folder of another storage.
+type prefixedStorage struct {
+	store  Storage
+	prefix string
+}
+
+func (s *prefixedStorage) Store(name string, r io.Reader, size int64) (string, error) {
+	return s.store.Store(path.Join(s.prefix, name), r, size)
+}
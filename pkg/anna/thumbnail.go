@@ -0,0 +1,105 @@
+package anna
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+
+	// Decoders for the cover formats found on Anna's Archive.
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbnailMaxDimension is the maximum width and height of thumbnails in
+	// pixels.
+	ThumbnailMaxDimension = 160
+	// thumbnailMaxSourceSize bounds the size of covers that are fetched for
+	// thumbnails, so a huge scan does not stall a search.
+	thumbnailMaxSourceSize = 4 << 20
+	// thumbnailMaxSourcePixels bounds the dimensions of covers that are
+	// decoded, since a small file can declare a huge image that would take
+	// gigabytes once decoded.
+	thumbnailMaxSourcePixels = 25_000_000
+)
+
+var ErrNoCover = errors.New("book has no cover")
+
+// Thumbnail fetches the cover of a book and scales it down to fit within
+// ThumbnailMaxDimension pixels, returning it as a JPEG.
+func (c *Client) Thumbnail(ctx context.Context, b *Book) ([]byte, error) {
+	if b.CoverURL == "" {
+		return nil, ErrNoCover
+	}
+
+	resp, err := c.get(ctx, b.CoverURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Endpoint: "cover", StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	if resp.ContentLength > thumbnailMaxSourceSize {
+		return nil, fmt.Errorf("cover is too large: %d bytes", resp.ContentLength)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, thumbnailMaxSourceSize))
+	if err != nil {
+		return nil, err
+	}
+	src, err := decodeCover(data)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("cover is empty")
+	}
+	if width > ThumbnailMaxDimension || height > ThumbnailMaxDimension {
+		if width >= height {
+			width, height = ThumbnailMaxDimension, max(1, height*ThumbnailMaxDimension/width)
+		} else {
+			width, height = max(1, width*ThumbnailMaxDimension/height), ThumbnailMaxDimension
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeCover decodes a cover after checking from its header that its
+// dimensions are within thumbnailMaxSourcePixels.
+func decodeCover(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, errors.New("cover is empty")
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > thumbnailMaxSourcePixels {
+		return nil, fmt.Errorf("cover is too large: %dx%d pixels", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	return src, err
+}
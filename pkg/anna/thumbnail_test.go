@@ -0,0 +1,55 @@
+package anna
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// pngHeader returns the signature and IHDR chunk of a PNG declaring the given
+// dimensions, which is all DecodeConfig reads.
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // RGBA
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+
+	return buf.Bytes()
+}
+
+func TestDecodeCoverRejectsHugeImages(t *testing.T) {
+	_, err := decodeCover(pngHeader(100_000, 100_000))
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("decodeCover of a 100000x100000 image returned %v, want a too large error", err)
+	}
+}
+
+func TestDecodeCover(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 450))
+	img.Set(10, 10, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := decodeCover(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := src.Bounds().Size(); got != (image.Point{X: 300, Y: 450}) {
+		t.Errorf("decoded cover is %v, want 300x450", got)
+	}
+}
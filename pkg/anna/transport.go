@@ -0,0 +1,37 @@
+package anna
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// SharedTransport is the transport of the clients created without
+// WithHTTPClient. Clients are cheap and usually live for a single call, so
+// they share it to reuse the connections to Anna's Archive and its download
+// servers, instead of paying for a new TLS handshake on every call.
+var SharedTransport http.RoundTripper = newSharedTransport()
+
+// defaultHTTPClient has no timeout, as downloads of large files can take
+// long. Calls are bounded by their context instead.
+var defaultHTTPClient = &http.Client{Transport: SharedTransport}
+
+func newSharedTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2: true,
+		MaxIdleConns:      100,
+		// Comparisons and deep searches send several requests to the same
+		// host at once, more than the default of two idle connections per
+		// host would keep.
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
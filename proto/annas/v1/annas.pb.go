@@ -0,0 +1,1345 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: annas/v1/annas.proto
+
+package annasv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Book struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Authors       string                 `protobuf:"bytes,3,opt,name=authors,proto3" json:"authors,omitempty"`
+	Publisher     string                 `protobuf:"bytes,4,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	Language      string                 `protobuf:"bytes,5,opt,name=language,proto3" json:"language,omitempty"`
+	LanguageCode  string                 `protobuf:"bytes,6,opt,name=language_code,json=languageCode,proto3" json:"language_code,omitempty"`
+	Format        string                 `protobuf:"bytes,7,opt,name=format,proto3" json:"format,omitempty"`
+	Size          string                 `protobuf:"bytes,8,opt,name=size,proto3" json:"size,omitempty"`
+	Year          string                 `protobuf:"bytes,9,opt,name=year,proto3" json:"year,omitempty"`
+	Url           string                 `protobuf:"bytes,10,opt,name=url,proto3" json:"url,omitempty"`
+	Description   string                 `protobuf:"bytes,11,opt,name=description,proto3" json:"description,omitempty"`
+	Toc           []string               `protobuf:"bytes,12,rep,name=toc,proto3" json:"toc,omitempty"`
+	SizeBytes     int64                  `protobuf:"varint,13,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	AuthorList    []string               `protobuf:"bytes,14,rep,name=author_list,json=authorList,proto3" json:"author_list,omitempty"`
+	Sources       []string               `protobuf:"bytes,15,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Book) Reset() {
+	*x = Book{}
+	mi := &file_annas_v1_annas_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Book) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book) ProtoMessage() {}
+
+func (x *Book) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor instead.
+func (*Book) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Book) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Book) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Book) GetAuthors() string {
+	if x != nil {
+		return x.Authors
+	}
+	return ""
+}
+
+func (x *Book) GetPublisher() string {
+	if x != nil {
+		return x.Publisher
+	}
+	return ""
+}
+
+func (x *Book) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Book) GetLanguageCode() string {
+	if x != nil {
+		return x.LanguageCode
+	}
+	return ""
+}
+
+func (x *Book) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Book) GetSize() string {
+	if x != nil {
+		return x.Size
+	}
+	return ""
+}
+
+func (x *Book) GetYear() string {
+	if x != nil {
+		return x.Year
+	}
+	return ""
+}
+
+func (x *Book) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Book) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Book) GetToc() []string {
+	if x != nil {
+		return x.Toc
+	}
+	return nil
+}
+
+func (x *Book) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *Book) GetAuthorList() []string {
+	if x != nil {
+		return x.AuthorList
+	}
+	return nil
+}
+
+func (x *Book) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          string                 `protobuf:"bytes,1,opt,name=term,proto3" json:"term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchRequest) GetTerm() string {
+	if x != nil {
+		return x.Term
+	}
+	return ""
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SearchResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+type GetBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookRequest) Reset() {
+	*x = GetBookRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookRequest) ProtoMessage() {}
+
+func (x *GetBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookRequest.ProtoReflect.Descriptor instead.
+func (*GetBookRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBookRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type GetBookResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Book          *Book                  `protobuf:"bytes,1,opt,name=book,proto3" json:"book,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBookResponse) Reset() {
+	*x = GetBookResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBookResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBookResponse) ProtoMessage() {}
+
+func (x *GetBookResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBookResponse.ProtoReflect.Descriptor instead.
+func (*GetBookResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBookResponse) GetBook() *Book {
+	if x != nil {
+		return x.Book
+	}
+	return nil
+}
+
+type DownloadRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Hash  string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	// Title of the book, used for the filename.
+	Title string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// Format of the book, for example pdf or epub.
+	Format string `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	// Download even if the library already holds the same book in another
+	// format.
+	AllowDuplicateFormats bool `protobuf:"varint,4,opt,name=allow_duplicate_formats,json=allowDuplicateFormats,proto3" json:"allow_duplicate_formats,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *DownloadRequest) Reset() {
+	*x = DownloadRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadRequest) ProtoMessage() {}
+
+func (x *DownloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadRequest.ProtoReflect.Descriptor instead.
+func (*DownloadRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DownloadRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *DownloadRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *DownloadRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *DownloadRequest) GetAllowDuplicateFormats() bool {
+	if x != nil {
+		return x.AllowDuplicateFormats
+	}
+	return false
+}
+
+type Quota struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DownloadsLeft   int32                  `protobuf:"varint,1,opt,name=downloads_left,json=downloadsLeft,proto3" json:"downloads_left,omitempty"`
+	DownloadsPerDay int32                  `protobuf:"varint,2,opt,name=downloads_per_day,json=downloadsPerDay,proto3" json:"downloads_per_day,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Quota) Reset() {
+	*x = Quota{}
+	mi := &file_annas_v1_annas_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quota) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quota) ProtoMessage() {}
+
+func (x *Quota) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quota.ProtoReflect.Descriptor instead.
+func (*Quota) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Quota) GetDownloadsLeft() int32 {
+	if x != nil {
+		return x.DownloadsLeft
+	}
+	return 0
+}
+
+func (x *Quota) GetDownloadsPerDay() int32 {
+	if x != nil {
+		return x.DownloadsPerDay
+	}
+	return 0
+}
+
+type DownloadResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Location string                 `protobuf:"bytes,1,opt,name=location,proto3" json:"location,omitempty"`
+	// Unset when the download did not go through the fast download API.
+	Quota         *Quota `protobuf:"bytes,2,opt,name=quota,proto3" json:"quota,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadResponse) Reset() {
+	*x = DownloadResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadResponse) ProtoMessage() {}
+
+func (x *DownloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadResponse.ProtoReflect.Descriptor instead.
+func (*DownloadResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DownloadResponse) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *DownloadResponse) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+type EnqueueDownloadRequest struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Hash                  string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Title                 string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Format                string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	AllowDuplicateFormats bool                   `protobuf:"varint,4,opt,name=allow_duplicate_formats,json=allowDuplicateFormats,proto3" json:"allow_duplicate_formats,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *EnqueueDownloadRequest) Reset() {
+	*x = EnqueueDownloadRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueDownloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueDownloadRequest) ProtoMessage() {}
+
+func (x *EnqueueDownloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueDownloadRequest.ProtoReflect.Descriptor instead.
+func (*EnqueueDownloadRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EnqueueDownloadRequest) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *EnqueueDownloadRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *EnqueueDownloadRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *EnqueueDownloadRequest) GetAllowDuplicateFormats() bool {
+	if x != nil {
+		return x.AllowDuplicateFormats
+	}
+	return false
+}
+
+type EnqueueDownloadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *Job                   `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueDownloadResponse) Reset() {
+	*x = EnqueueDownloadResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueDownloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueDownloadResponse) ProtoMessage() {}
+
+func (x *EnqueueDownloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueDownloadResponse.ProtoReflect.Descriptor instead.
+func (*EnqueueDownloadResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EnqueueDownloadResponse) GetJob() *Job {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type Job struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// One of queued, scheduled, running, done, or failed.
+	Status string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Hash   string `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Title  string `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Format string `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`
+	// Set once the job is done.
+	Location string `protobuf:"bytes,6,opt,name=location,proto3" json:"location,omitempty"`
+	Quota    *Quota `protobuf:"bytes,7,opt,name=quota,proto3" json:"quota,omitempty"`
+	// Set if the job failed.
+	Error         string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAtUnix int64  `protobuf:"varint,9,opt,name=created_at_unix,json=createdAtUnix,proto3" json:"created_at_unix,omitempty"`
+	// Zero until the job is finished.
+	FinishedAtUnix int64 `protobuf:"varint,10,opt,name=finished_at_unix,json=finishedAtUnix,proto3" json:"finished_at_unix,omitempty"`
+	// Set while the job waits for the fast download quota to reset.
+	ScheduledForUnix int64 `protobuf:"varint,11,opt,name=scheduled_for_unix,json=scheduledForUnix,proto3" json:"scheduled_for_unix,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_annas_v1_annas_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Job) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Job) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Job) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Job) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Job) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Job) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Job) GetQuota() *Quota {
+	if x != nil {
+		return x.Quota
+	}
+	return nil
+}
+
+func (x *Job) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Job) GetCreatedAtUnix() int64 {
+	if x != nil {
+		return x.CreatedAtUnix
+	}
+	return 0
+}
+
+func (x *Job) GetFinishedAtUnix() int64 {
+	if x != nil {
+		return x.FinishedAtUnix
+	}
+	return 0
+}
+
+func (x *Job) GetScheduledForUnix() int64 {
+	if x != nil {
+		return x.ScheduledForUnix
+	}
+	return 0
+}
+
+type ListJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{11}
+}
+
+type ListJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jobs          []*Job                 `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListJobsResponse) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type SearchHistoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of searches to return, 20 if zero.
+	Limit         int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHistoryRequest) Reset() {
+	*x = SearchHistoryRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHistoryRequest) ProtoMessage() {}
+
+func (x *SearchHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHistoryRequest.ProtoReflect.Descriptor instead.
+func (*SearchHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SearchHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type Search struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Query       string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	ResultCount int32                  `protobuf:"varint,2,opt,name=result_count,json=resultCount,proto3" json:"result_count,omitempty"`
+	// Hashes of the first results, in order.
+	Results []string `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	// Hashes of the results that were downloaded afterwards.
+	Downloaded     []string `protobuf:"bytes,4,rep,name=downloaded,proto3" json:"downloaded,omitempty"`
+	SearchedAtUnix int64    `protobuf:"varint,5,opt,name=searched_at_unix,json=searchedAtUnix,proto3" json:"searched_at_unix,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Search) Reset() {
+	*x = Search{}
+	mi := &file_annas_v1_annas_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Search) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Search) ProtoMessage() {}
+
+func (x *Search) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Search.ProtoReflect.Descriptor instead.
+func (*Search) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Search) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *Search) GetResultCount() int32 {
+	if x != nil {
+		return x.ResultCount
+	}
+	return 0
+}
+
+func (x *Search) GetResults() []string {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *Search) GetDownloaded() []string {
+	if x != nil {
+		return x.Downloaded
+	}
+	return nil
+}
+
+func (x *Search) GetSearchedAtUnix() int64 {
+	if x != nil {
+		return x.SearchedAtUnix
+	}
+	return 0
+}
+
+type SearchHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Searches      []*Search              `protobuf:"bytes,1,rep,name=searches,proto3" json:"searches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHistoryResponse) Reset() {
+	*x = SearchHistoryResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHistoryResponse) ProtoMessage() {}
+
+func (x *SearchHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHistoryResponse.ProtoReflect.Descriptor instead.
+func (*SearchHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SearchHistoryResponse) GetSearches() []*Search {
+	if x != nil {
+		return x.Searches
+	}
+	return nil
+}
+
+type LibraryStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LibraryStatsRequest) Reset() {
+	*x = LibraryStatsRequest{}
+	mi := &file_annas_v1_annas_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LibraryStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LibraryStatsRequest) ProtoMessage() {}
+
+func (x *LibraryStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LibraryStatsRequest.ProtoReflect.Descriptor instead.
+func (*LibraryStatsRequest) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{16}
+}
+
+type LibraryStatsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Books int32                  `protobuf:"varint,1,opt,name=books,proto3" json:"books,omitempty"`
+	// Size of all stored files in bytes.
+	TotalSize  int64            `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	ByFormat   map[string]int32 `protobuf:"bytes,3,rep,name=by_format,json=byFormat,proto3" json:"by_format,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	ByLanguage map[string]int32 `protobuf:"bytes,4,rep,name=by_language,json=byLanguage,proto3" json:"by_language,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Books with several authors count once for each of them.
+	ByAuthor map[string]int32 `protobuf:"bytes,5,rep,name=by_author,json=byAuthor,proto3" json:"by_author,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Downloads per month, keyed as 2006-01.
+	ByMonth       map[string]int32 `protobuf:"bytes,6,rep,name=by_month,json=byMonth,proto3" json:"by_month,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LibraryStatsResponse) Reset() {
+	*x = LibraryStatsResponse{}
+	mi := &file_annas_v1_annas_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LibraryStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LibraryStatsResponse) ProtoMessage() {}
+
+func (x *LibraryStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_annas_v1_annas_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LibraryStatsResponse.ProtoReflect.Descriptor instead.
+func (*LibraryStatsResponse) Descriptor() ([]byte, []int) {
+	return file_annas_v1_annas_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *LibraryStatsResponse) GetBooks() int32 {
+	if x != nil {
+		return x.Books
+	}
+	return 0
+}
+
+func (x *LibraryStatsResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *LibraryStatsResponse) GetByFormat() map[string]int32 {
+	if x != nil {
+		return x.ByFormat
+	}
+	return nil
+}
+
+func (x *LibraryStatsResponse) GetByLanguage() map[string]int32 {
+	if x != nil {
+		return x.ByLanguage
+	}
+	return nil
+}
+
+func (x *LibraryStatsResponse) GetByAuthor() map[string]int32 {
+	if x != nil {
+		return x.ByAuthor
+	}
+	return nil
+}
+
+func (x *LibraryStatsResponse) GetByMonth() map[string]int32 {
+	if x != nil {
+		return x.ByMonth
+	}
+	return nil
+}
+
+var File_annas_v1_annas_proto protoreflect.FileDescriptor
+
+const file_annas_v1_annas_proto_rawDesc = "" +
+	"\n" +
+	"\x14annas/v1/annas.proto\x12\bannas.v1\"\x89\x03\n" +
+	"\x04Book\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\aauthors\x18\x03 \x01(\tR\aauthors\x12\x1c\n" +
+	"\tpublisher\x18\x04 \x01(\tR\tpublisher\x12\x1a\n" +
+	"\blanguage\x18\x05 \x01(\tR\blanguage\x12#\n" +
+	"\rlanguage_code\x18\x06 \x01(\tR\flanguageCode\x12\x16\n" +
+	"\x06format\x18\a \x01(\tR\x06format\x12\x12\n" +
+	"\x04size\x18\b \x01(\tR\x04size\x12\x12\n" +
+	"\x04year\x18\t \x01(\tR\x04year\x12\x10\n" +
+	"\x03url\x18\n" +
+	" \x01(\tR\x03url\x12 \n" +
+	"\vdescription\x18\v \x01(\tR\vdescription\x12\x10\n" +
+	"\x03toc\x18\f \x03(\tR\x03toc\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\r \x01(\x03R\tsizeBytes\x12\x1f\n" +
+	"\vauthor_list\x18\x0e \x03(\tR\n" +
+	"authorList\x12\x18\n" +
+	"\asources\x18\x0f \x03(\tR\asources\"#\n" +
+	"\rSearchRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\tR\x04term\"6\n" +
+	"\x0eSearchResponse\x12$\n" +
+	"\x05books\x18\x01 \x03(\v2\x0e.annas.v1.BookR\x05books\"$\n" +
+	"\x0eGetBookRequest\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\"5\n" +
+	"\x0fGetBookResponse\x12\"\n" +
+	"\x04book\x18\x01 \x01(\v2\x0e.annas.v1.BookR\x04book\"\x8b\x01\n" +
+	"\x0fDownloadRequest\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x126\n" +
+	"\x17allow_duplicate_formats\x18\x04 \x01(\bR\x15allowDuplicateFormats\"Z\n" +
+	"\x05Quota\x12%\n" +
+	"\x0edownloads_left\x18\x01 \x01(\x05R\rdownloadsLeft\x12*\n" +
+	"\x11downloads_per_day\x18\x02 \x01(\x05R\x0fdownloadsPerDay\"U\n" +
+	"\x10DownloadResponse\x12\x1a\n" +
+	"\blocation\x18\x01 \x01(\tR\blocation\x12%\n" +
+	"\x05quota\x18\x02 \x01(\v2\x0f.annas.v1.QuotaR\x05quota\"\x92\x01\n" +
+	"\x16EnqueueDownloadRequest\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x126\n" +
+	"\x17allow_duplicate_formats\x18\x04 \x01(\bR\x15allowDuplicateFormats\":\n" +
+	"\x17EnqueueDownloadResponse\x12\x1f\n" +
+	"\x03job\x18\x01 \x01(\v2\r.annas.v1.JobR\x03job\"\xc8\x02\n" +
+	"\x03Job\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12\x16\n" +
+	"\x06format\x18\x05 \x01(\tR\x06format\x12\x1a\n" +
+	"\blocation\x18\x06 \x01(\tR\blocation\x12%\n" +
+	"\x05quota\x18\a \x01(\v2\x0f.annas.v1.QuotaR\x05quota\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x12&\n" +
+	"\x0fcreated_at_unix\x18\t \x01(\x03R\rcreatedAtUnix\x12(\n" +
+	"\x10finished_at_unix\x18\n" +
+	" \x01(\x03R\x0efinishedAtUnix\x12,\n" +
+	"\x12scheduled_for_unix\x18\v \x01(\x03R\x10scheduledForUnix\"\x11\n" +
+	"\x0fListJobsRequest\"5\n" +
+	"\x10ListJobsResponse\x12!\n" +
+	"\x04jobs\x18\x01 \x03(\v2\r.annas.v1.JobR\x04jobs\",\n" +
+	"\x14SearchHistoryRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"\xa5\x01\n" +
+	"\x06Search\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12!\n" +
+	"\fresult_count\x18\x02 \x01(\x05R\vresultCount\x12\x18\n" +
+	"\aresults\x18\x03 \x03(\tR\aresults\x12\x1e\n" +
+	"\n" +
+	"downloaded\x18\x04 \x03(\tR\n" +
+	"downloaded\x12(\n" +
+	"\x10searched_at_unix\x18\x05 \x01(\x03R\x0esearchedAtUnix\"E\n" +
+	"\x15SearchHistoryResponse\x12,\n" +
+	"\bsearches\x18\x01 \x03(\v2\x10.annas.v1.SearchR\bsearches\"\x15\n" +
+	"\x13LibraryStatsRequest\"\xef\x04\n" +
+	"\x14LibraryStatsResponse\x12\x14\n" +
+	"\x05books\x18\x01 \x01(\x05R\x05books\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x03R\ttotalSize\x12I\n" +
+	"\tby_format\x18\x03 \x03(\v2,.annas.v1.LibraryStatsResponse.ByFormatEntryR\bbyFormat\x12O\n" +
+	"\vby_language\x18\x04 \x03(\v2..annas.v1.LibraryStatsResponse.ByLanguageEntryR\n" +
+	"byLanguage\x12I\n" +
+	"\tby_author\x18\x05 \x03(\v2,.annas.v1.LibraryStatsResponse.ByAuthorEntryR\bbyAuthor\x12F\n" +
+	"\bby_month\x18\x06 \x03(\v2+.annas.v1.LibraryStatsResponse.ByMonthEntryR\abyMonth\x1a;\n" +
+	"\rByFormatEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a=\n" +
+	"\x0fByLanguageEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a;\n" +
+	"\rByAuthorEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a:\n" +
+	"\fByMonthEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x012\x8a\x04\n" +
+	"\fAnnasService\x12;\n" +
+	"\x06Search\x12\x17.annas.v1.SearchRequest\x1a\x18.annas.v1.SearchResponse\x12>\n" +
+	"\aGetBook\x12\x18.annas.v1.GetBookRequest\x1a\x19.annas.v1.GetBookResponse\x12A\n" +
+	"\bDownload\x12\x19.annas.v1.DownloadRequest\x1a\x1a.annas.v1.DownloadResponse\x12V\n" +
+	"\x0fEnqueueDownload\x12 .annas.v1.EnqueueDownloadRequest\x1a!.annas.v1.EnqueueDownloadResponse\x12A\n" +
+	"\bListJobs\x12\x19.annas.v1.ListJobsRequest\x1a\x1a.annas.v1.ListJobsResponse\x12P\n" +
+	"\rSearchHistory\x12\x1e.annas.v1.SearchHistoryRequest\x1a\x1f.annas.v1.SearchHistoryResponse\x12M\n" +
+	"\fLibraryStats\x12\x1d.annas.v1.LibraryStatsRequest\x1a\x1e.annas.v1.LibraryStatsResponseB7Z5github.com/iosifache/annas-mcp/proto/annas/v1;annasv1b\x06proto3"
+
+var (
+	file_annas_v1_annas_proto_rawDescOnce sync.Once
+	file_annas_v1_annas_proto_rawDescData []byte
+)
+
+func file_annas_v1_annas_proto_rawDescGZIP() []byte {
+	file_annas_v1_annas_proto_rawDescOnce.Do(func() {
+		file_annas_v1_annas_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_annas_v1_annas_proto_rawDesc), len(file_annas_v1_annas_proto_rawDesc)))
+	})
+	return file_annas_v1_annas_proto_rawDescData
+}
+
+var file_annas_v1_annas_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_annas_v1_annas_proto_goTypes = []any{
+	(*Book)(nil),                    // 0: annas.v1.Book
+	(*SearchRequest)(nil),           // 1: annas.v1.SearchRequest
+	(*SearchResponse)(nil),          // 2: annas.v1.SearchResponse
+	(*GetBookRequest)(nil),          // 3: annas.v1.GetBookRequest
+	(*GetBookResponse)(nil),         // 4: annas.v1.GetBookResponse
+	(*DownloadRequest)(nil),         // 5: annas.v1.DownloadRequest
+	(*Quota)(nil),                   // 6: annas.v1.Quota
+	(*DownloadResponse)(nil),        // 7: annas.v1.DownloadResponse
+	(*EnqueueDownloadRequest)(nil),  // 8: annas.v1.EnqueueDownloadRequest
+	(*EnqueueDownloadResponse)(nil), // 9: annas.v1.EnqueueDownloadResponse
+	(*Job)(nil),                     // 10: annas.v1.Job
+	(*ListJobsRequest)(nil),         // 11: annas.v1.ListJobsRequest
+	(*ListJobsResponse)(nil),        // 12: annas.v1.ListJobsResponse
+	(*SearchHistoryRequest)(nil),    // 13: annas.v1.SearchHistoryRequest
+	(*Search)(nil),                  // 14: annas.v1.Search
+	(*SearchHistoryResponse)(nil),   // 15: annas.v1.SearchHistoryResponse
+	(*LibraryStatsRequest)(nil),     // 16: annas.v1.LibraryStatsRequest
+	(*LibraryStatsResponse)(nil),    // 17: annas.v1.LibraryStatsResponse
+	nil,                             // 18: annas.v1.LibraryStatsResponse.ByFormatEntry
+	nil,                             // 19: annas.v1.LibraryStatsResponse.ByLanguageEntry
+	nil,                             // 20: annas.v1.LibraryStatsResponse.ByAuthorEntry
+	nil,                             // 21: annas.v1.LibraryStatsResponse.ByMonthEntry
+}
+var file_annas_v1_annas_proto_depIdxs = []int32{
+	0,  // 0: annas.v1.SearchResponse.books:type_name -> annas.v1.Book
+	0,  // 1: annas.v1.GetBookResponse.book:type_name -> annas.v1.Book
+	6,  // 2: annas.v1.DownloadResponse.quota:type_name -> annas.v1.Quota
+	10, // 3: annas.v1.EnqueueDownloadResponse.job:type_name -> annas.v1.Job
+	6,  // 4: annas.v1.Job.quota:type_name -> annas.v1.Quota
+	10, // 5: annas.v1.ListJobsResponse.jobs:type_name -> annas.v1.Job
+	14, // 6: annas.v1.SearchHistoryResponse.searches:type_name -> annas.v1.Search
+	18, // 7: annas.v1.LibraryStatsResponse.by_format:type_name -> annas.v1.LibraryStatsResponse.ByFormatEntry
+	19, // 8: annas.v1.LibraryStatsResponse.by_language:type_name -> annas.v1.LibraryStatsResponse.ByLanguageEntry
+	20, // 9: annas.v1.LibraryStatsResponse.by_author:type_name -> annas.v1.LibraryStatsResponse.ByAuthorEntry
+	21, // 10: annas.v1.LibraryStatsResponse.by_month:type_name -> annas.v1.LibraryStatsResponse.ByMonthEntry
+	1,  // 11: annas.v1.AnnasService.Search:input_type -> annas.v1.SearchRequest
+	3,  // 12: annas.v1.AnnasService.GetBook:input_type -> annas.v1.GetBookRequest
+	5,  // 13: annas.v1.AnnasService.Download:input_type -> annas.v1.DownloadRequest
+	8,  // 14: annas.v1.AnnasService.EnqueueDownload:input_type -> annas.v1.EnqueueDownloadRequest
+	11, // 15: annas.v1.AnnasService.ListJobs:input_type -> annas.v1.ListJobsRequest
+	13, // 16: annas.v1.AnnasService.SearchHistory:input_type -> annas.v1.SearchHistoryRequest
+	16, // 17: annas.v1.AnnasService.LibraryStats:input_type -> annas.v1.LibraryStatsRequest
+	2,  // 18: annas.v1.AnnasService.Search:output_type -> annas.v1.SearchResponse
+	4,  // 19: annas.v1.AnnasService.GetBook:output_type -> annas.v1.GetBookResponse
+	7,  // 20: annas.v1.AnnasService.Download:output_type -> annas.v1.DownloadResponse
+	9,  // 21: annas.v1.AnnasService.EnqueueDownload:output_type -> annas.v1.EnqueueDownloadResponse
+	12, // 22: annas.v1.AnnasService.ListJobs:output_type -> annas.v1.ListJobsResponse
+	15, // 23: annas.v1.AnnasService.SearchHistory:output_type -> annas.v1.SearchHistoryResponse
+	17, // 24: annas.v1.AnnasService.LibraryStats:output_type -> annas.v1.LibraryStatsResponse
+	18, // [18:25] is the sub-list for method output_type
+	11, // [11:18] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_annas_v1_annas_proto_init() }
+func file_annas_v1_annas_proto_init() {
+	if File_annas_v1_annas_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_annas_v1_annas_proto_rawDesc), len(file_annas_v1_annas_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_annas_v1_annas_proto_goTypes,
+		DependencyIndexes: file_annas_v1_annas_proto_depIdxs,
+		MessageInfos:      file_annas_v1_annas_proto_msgTypes,
+	}.Build()
+	File_annas_v1_annas_proto = out.File
+	file_annas_v1_annas_proto_goTypes = nil
+	file_annas_v1_annas_proto_depIdxs = nil
+}
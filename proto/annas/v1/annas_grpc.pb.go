@@ -0,0 +1,375 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: annas/v1/annas.proto
+
+package annasv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnnasService_Search_FullMethodName          = "/annas.v1.AnnasService/Search"
+	AnnasService_GetBook_FullMethodName         = "/annas.v1.AnnasService/GetBook"
+	AnnasService_Download_FullMethodName        = "/annas.v1.AnnasService/Download"
+	AnnasService_EnqueueDownload_FullMethodName = "/annas.v1.AnnasService/EnqueueDownload"
+	AnnasService_ListJobs_FullMethodName        = "/annas.v1.AnnasService/ListJobs"
+	AnnasService_SearchHistory_FullMethodName   = "/annas.v1.AnnasService/SearchHistory"
+	AnnasService_LibraryStats_FullMethodName    = "/annas.v1.AnnasService/LibraryStats"
+)
+
+// AnnasServiceClient is the client API for AnnasService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AnnasService exposes the operations of the MCP server to services that do
+// not speak MCP. Calls are authenticated with the same bearer tokens as the
+// HTTP transport, sent in the "authorization" metadata.
+type AnnasServiceClient interface {
+	// Search returns the books matching a search term.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// GetBook returns the full metadata of a book, including its description
+	// and table of contents.
+	GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*GetBookResponse, error)
+	// Download fetches a book into the configured storage and waits until it
+	// is stored.
+	Download(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (*DownloadResponse, error)
+	// EnqueueDownload queues a download and returns immediately.
+	EnqueueDownload(ctx context.Context, in *EnqueueDownloadRequest, opts ...grpc.CallOption) (*EnqueueDownloadResponse, error)
+	// ListJobs returns the queued, scheduled, running, and finished downloads.
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	// SearchHistory returns the most recent searches, newest first.
+	SearchHistory(ctx context.Context, in *SearchHistoryRequest, opts ...grpc.CallOption) (*SearchHistoryResponse, error)
+	// LibraryStats summarizes the downloaded books.
+	LibraryStats(ctx context.Context, in *LibraryStatsRequest, opts ...grpc.CallOption) (*LibraryStatsResponse, error)
+}
+
+type annasServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnnasServiceClient(cc grpc.ClientConnInterface) AnnasServiceClient {
+	return &annasServiceClient{cc}
+}
+
+func (c *annasServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, AnnasService_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *annasServiceClient) GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*GetBookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBookResponse)
+	err := c.cc.Invoke(ctx, AnnasService_GetBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *annasServiceClient) Download(ctx context.Context, in *DownloadRequest, opts ...grpc.CallOption) (*DownloadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DownloadResponse)
+	err := c.cc.Invoke(ctx, AnnasService_Download_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *annasServiceClient) EnqueueDownload(ctx context.Context, in *EnqueueDownloadRequest, opts ...grpc.CallOption) (*EnqueueDownloadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnqueueDownloadResponse)
+	err := c.cc.Invoke(ctx, AnnasService_EnqueueDownload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *annasServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, AnnasService_ListJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *annasServiceClient) SearchHistory(ctx context.Context, in *SearchHistoryRequest, opts ...grpc.CallOption) (*SearchHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchHistoryResponse)
+	err := c.cc.Invoke(ctx, AnnasService_SearchHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *annasServiceClient) LibraryStats(ctx context.Context, in *LibraryStatsRequest, opts ...grpc.CallOption) (*LibraryStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LibraryStatsResponse)
+	err := c.cc.Invoke(ctx, AnnasService_LibraryStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnnasServiceServer is the server API for AnnasService service.
+// All implementations must embed UnimplementedAnnasServiceServer
+// for forward compatibility.
+//
+// AnnasService exposes the operations of the MCP server to services that do
+// not speak MCP. Calls are authenticated with the same bearer tokens as the
+// HTTP transport, sent in the "authorization" metadata.
+type AnnasServiceServer interface {
+	// Search returns the books matching a search term.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// GetBook returns the full metadata of a book, including its description
+	// and table of contents.
+	GetBook(context.Context, *GetBookRequest) (*GetBookResponse, error)
+	// Download fetches a book into the configured storage and waits until it
+	// is stored.
+	Download(context.Context, *DownloadRequest) (*DownloadResponse, error)
+	// EnqueueDownload queues a download and returns immediately.
+	EnqueueDownload(context.Context, *EnqueueDownloadRequest) (*EnqueueDownloadResponse, error)
+	// ListJobs returns the queued, scheduled, running, and finished downloads.
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	// SearchHistory returns the most recent searches, newest first.
+	SearchHistory(context.Context, *SearchHistoryRequest) (*SearchHistoryResponse, error)
+	// LibraryStats summarizes the downloaded books.
+	LibraryStats(context.Context, *LibraryStatsRequest) (*LibraryStatsResponse, error)
+	mustEmbedUnimplementedAnnasServiceServer()
+}
+
+// UnimplementedAnnasServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnnasServiceServer struct{}
+
+func (UnimplementedAnnasServiceServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedAnnasServiceServer) GetBook(context.Context, *GetBookRequest) (*GetBookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
+}
+func (UnimplementedAnnasServiceServer) Download(context.Context, *DownloadRequest) (*DownloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Download not implemented")
+}
+func (UnimplementedAnnasServiceServer) EnqueueDownload(context.Context, *EnqueueDownloadRequest) (*EnqueueDownloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnqueueDownload not implemented")
+}
+func (UnimplementedAnnasServiceServer) ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (UnimplementedAnnasServiceServer) SearchHistory(context.Context, *SearchHistoryRequest) (*SearchHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchHistory not implemented")
+}
+func (UnimplementedAnnasServiceServer) LibraryStats(context.Context, *LibraryStatsRequest) (*LibraryStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LibraryStats not implemented")
+}
+func (UnimplementedAnnasServiceServer) mustEmbedUnimplementedAnnasServiceServer() {}
+func (UnimplementedAnnasServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAnnasServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnnasServiceServer will
+// result in compilation errors.
+type UnsafeAnnasServiceServer interface {
+	mustEmbedUnimplementedAnnasServiceServer()
+}
+
+func RegisterAnnasServiceServer(s grpc.ServiceRegistrar, srv AnnasServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAnnasServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnnasService_ServiceDesc, srv)
+}
+
+func _AnnasService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnnasService_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).GetBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_GetBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).GetBook(ctx, req.(*GetBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnnasService_Download_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DownloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).Download(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_Download_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).Download(ctx, req.(*DownloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnnasService_EnqueueDownload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnqueueDownloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).EnqueueDownload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_EnqueueDownload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).EnqueueDownload(ctx, req.(*EnqueueDownloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnnasService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_ListJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnnasService_SearchHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).SearchHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_SearchHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).SearchHistory(ctx, req.(*SearchHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnnasService_LibraryStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LibraryStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnnasServiceServer).LibraryStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnnasService_LibraryStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnnasServiceServer).LibraryStats(ctx, req.(*LibraryStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AnnasService_ServiceDesc is the grpc.ServiceDesc for AnnasService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnnasService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "annas.v1.AnnasService",
+	HandlerType: (*AnnasServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _AnnasService_Search_Handler,
+		},
+		{
+			MethodName: "GetBook",
+			Handler:    _AnnasService_GetBook_Handler,
+		},
+		{
+			MethodName: "Download",
+			Handler:    _AnnasService_Download_Handler,
+		},
+		{
+			MethodName: "EnqueueDownload",
+			Handler:    _AnnasService_EnqueueDownload_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _AnnasService_ListJobs_Handler,
+		},
+		{
+			MethodName: "SearchHistory",
+			Handler:    _AnnasService_SearchHistory_Handler,
+		},
+		{
+			MethodName: "LibraryStats",
+			Handler:    _AnnasService_LibraryStats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "annas/v1/annas.proto",
+}